@@ -11,8 +11,10 @@ import (
 	"github.com/csusmGDSC/csusmgdsc-api/config"
 	"github.com/csusmGDSC/csusmgdsc-api/internal/auth"
 	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_handlers"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_utils"
 	"github.com/csusmGDSC/csusmgdsc-api/internal/db"
 	"github.com/csusmGDSC/csusmgdsc-api/internal/handlers"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/mailer"
 	"github.com/csusmGDSC/csusmgdsc-api/routes"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
@@ -23,10 +25,33 @@ func main() {
 	defer dbConn.Close()
 
 	e := echo.New()
+	if err := config.InitIPExtractor(e); err != nil {
+		log.Fatalf("Invalid trusted proxies: %v", err)
+	}
+
+	m, err := mailer.New(config.LoadConfig())
+	if err != nil {
+		log.Fatalf("Failed to initialize mailer: %v", err)
+	}
+
+	if err := auth_utils.InitSigningKeys(); err != nil {
+		log.Fatalf("Failed to load token signing keys: %v", err)
+	}
+
+	if err := auth_utils.InitCookiePolicy(); err != nil {
+		log.Fatalf("Invalid cookie settings: %v", err)
+	}
 
 	// Initialize OAuth
-	auth.InitOAuth()
-	authHandler := auth_handlers.NewOAuthHandler(dbConn)
+	if err := auth.InitOAuth(); err != nil {
+		log.Fatalf("Failed to initialize OAuth providers: %v", err)
+	}
+	// Purge accounts whose deletion grace period has ended
+	purgeCtx, stopPurger := context.WithCancel(context.Background())
+	defer stopPurger()
+	auth_utils.StartAccountPurger(purgeCtx, dbConn.GetDB())
+
+	authHandler := auth_handlers.NewOAuthHandler(dbConn, m)
 	routes.InitOAuthRoutes(e, authHandler)
 
 	e.Use(middleware.Logger())
@@ -44,6 +69,7 @@ func main() {
 	go func() {
 		<-quit
 		log.Println("Shutting down server...")
+		stopPurger()
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
 		if err := e.Shutdown(ctx); err != nil {
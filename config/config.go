@@ -1,10 +1,14 @@
 package config
 
 import (
+	"fmt"
 	"log"
+	"net"
 	"os"
 	"path/filepath"
 	"runtime"
+	"strconv"
+	"strings"
 	"sync"
 
 	"github.com/joho/godotenv"
@@ -13,21 +17,66 @@ import (
 )
 
 type Config struct {
-	DBConnectionUrl     string
-	JWTAccessSecret     string
-	JWTRefreshSecret    string
-	GitHubClientID      string
-	GitHubClientSecret  string
-	GoogleClientID      string
-	GoogleClientSecret  string
-	OAuthRedirectUrl    string
-	FrontendOrigin      string
-	ResendAPIKey        string
-	S3BucketName        string
-	AWSRegion           string
-	AWSAccessKey        string
-	AWSSecretAccessKey  string
-	AWSCloudfrontDomain string
+	DBConnectionUrl      string
+	Environment          string
+	JWTKeyDir            string
+	JWTSigningKeyID      string
+	JWTIssuer            string
+	JWTAudience          string
+	GitHubClientID       string
+	GitHubClientSecret   string
+	GoogleClientID       string
+	GoogleClientSecret   string
+	OAuthRedirectUrl     string
+	FrontendOrigin       string
+	FrontendURL          string
+	ResendAPIKey         string
+	S3BucketName         string
+	AWSRegion            string
+	AWSAccessKey         string
+	AWSSecretAccessKey   string
+	AWSCloudfrontDomain  string
+	MailBackend          string
+	MailFrom             string
+	MailOutputDir        string
+	SMTPHost             string
+	SMTPPort             string
+	SMTPUsername         string
+	SMTPPassword         string
+	MFARequiredForAdmins bool
+	CookieSecure         bool
+	CookieDomain         string
+	CookiePath           string // Defaults to /auth, or / with CookieHostPrefix
+	CookieSameSite       string // strict, lax or none
+	CookieHostPrefix     bool
+	PasswordMinLength    int
+	PasswordMinStrength  int      // 0 (anything) to 4 (very hard to guess)
+	BreachedPasswordsDir string   // Breached password hash files by prefix, empty to skip the check
+	RegistrationMode     string   // open, domain or invite
+	AllowedEmailDomains  []string // Domains that can register when RegistrationMode is domain
+	TrustedProxies       []string // CIDR ranges of proxies whose X-Forwarded-For is trusted
+	OIDCProviders        []OIDCProviderConfig
+}
+
+// OIDCProviderConfig configures an OpenID Connect issuer users can sign in with.
+// Providers are listed in OIDC_PROVIDERS and configured with OIDC_<NAME>_* variables.
+type OIDCProviderConfig struct {
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+
+	// Claims of the ID token mapped into the user's profile
+	IDClaim            string
+	EmailClaim         string
+	EmailVerifiedClaim string
+	NameClaim          string
+	PictureClaim       string
+
+	// TrustEmail treats the email claim as verified when the issuer doesn't send
+	// an email_verified claim, e.g. for an organization's own Entra ID tenant
+	TrustEmail bool
 }
 
 var (
@@ -73,25 +122,109 @@ func LoadConfig() *Config {
 		}
 
 		config = &Config{
-			DBConnectionUrl:     getEnv("DATABASE_URL"),
-			JWTAccessSecret:     getEnv("JWT_ACCESS_SECRET"),
-			JWTRefreshSecret:    getEnv("JWT_REFRESH_SECRET"),
-			GitHubClientID:      getEnv("GITHUB_CLIENT_ID"),
-			GitHubClientSecret:  getEnv("GITHUB_CLIENT_SECRET"),
-			GoogleClientID:      getEnv("GOOGLE_CLIENT_ID"),
-			GoogleClientSecret:  getEnv("GOOGLE_CLIENT_SECRET"),
-			FrontendOrigin:      getEnv("FRONTEND_ORIGIN", "http://localhost:8081"),
-			ResendAPIKey:        getEnv("RESEND_API_KEY"),
-			S3BucketName:        getEnv("S3_BUCKET_NAME"),
-			AWSRegion:           getEnv("AWS_REGION"),
-			AWSAccessKey:        getEnv("AWS_ACCESS_KEY"),
-			AWSSecretAccessKey:  getEnv("AWS_SECRET_ACCESS_KEY"),
-			AWSCloudfrontDomain: getEnv("AWS_CLOUDFRONT_DOMAIN"),
+			DBConnectionUrl:      getEnv("DATABASE_URL"),
+			Environment:          getEnv("GO_ENV", "development"),
+			JWTKeyDir:            getEnv("JWT_KEY_DIR", ""),
+			JWTSigningKeyID:      getEnv("JWT_SIGNING_KEY_ID", ""),
+			JWTIssuer:            getEnv("JWT_ISSUER", "https://api.gdsc-csusm.com"),
+			JWTAudience:          getEnv("JWT_AUDIENCE", "gdsc-csusm-api"),
+			GitHubClientID:       getEnv("GITHUB_CLIENT_ID"),
+			GitHubClientSecret:   getEnv("GITHUB_CLIENT_SECRET"),
+			GoogleClientID:       getEnv("GOOGLE_CLIENT_ID"),
+			GoogleClientSecret:   getEnv("GOOGLE_CLIENT_SECRET"),
+			FrontendOrigin:       getEnv("FRONTEND_ORIGIN", "http://localhost:8081"),
+			FrontendURL:          getEnv("FRONTEND_URL", "https://gdsc-csusm.com"),
+			ResendAPIKey:         getEnv("RESEND_API_KEY"),
+			S3BucketName:         getEnv("S3_BUCKET_NAME"),
+			AWSRegion:            getEnv("AWS_REGION"),
+			AWSAccessKey:         getEnv("AWS_ACCESS_KEY"),
+			AWSSecretAccessKey:   getEnv("AWS_SECRET_ACCESS_KEY"),
+			AWSCloudfrontDomain:  getEnv("AWS_CLOUDFRONT_DOMAIN"),
+			MailBackend:          getEnv("MAIL_BACKEND", "resend"),
+			MailFrom:             getEnv("MAIL_FROM", "CSUSM_GDSC <CSUSM_GDSC@gdsc-csusm.com>"),
+			MailOutputDir:        getEnv("MAIL_OUTPUT_DIR", ""),
+			SMTPHost:             getEnv("SMTP_HOST", ""),
+			SMTPPort:             getEnv("SMTP_PORT", "587"),
+			SMTPUsername:         getEnv("SMTP_USERNAME", ""),
+			SMTPPassword:         getEnv("SMTP_PASSWORD", ""),
+			MFARequiredForAdmins: getEnv("MFA_REQUIRED_FOR_ADMINS", "false") == "true",
+			CookieSecure:         getEnv("COOKIE_SECURE", defaultForProduction("true", "false")) == "true",
+			CookieDomain:         getEnv("COOKIE_DOMAIN", ""),
+			CookiePath:           getEnv("COOKIE_PATH", ""),
+			CookieSameSite:       getEnv("COOKIE_SAMESITE", "strict"),
+			CookieHostPrefix:     getEnv("COOKIE_HOST_PREFIX", defaultForProduction("true", "false")) == "true",
+			PasswordMinLength:    getEnvInt("PASSWORD_MIN_LENGTH", 10),
+			PasswordMinStrength:  getEnvInt("PASSWORD_MIN_STRENGTH", 2),
+			BreachedPasswordsDir: getEnv("BREACHED_PASSWORDS_DIR", ""),
+			RegistrationMode:     strings.ToLower(getEnv("REGISTRATION_MODE", "open")),
+			AllowedEmailDomains:  splitList(getEnv("REGISTRATION_ALLOWED_DOMAINS", "csusm.edu,cougars.csusm.edu")),
+			OAuthRedirectUrl:     getEnv("OAUTH_REDIRECT_URL", ""),
+			TrustedProxies:       splitList(getEnv("TRUSTED_PROXIES", "")),
+			OIDCProviders:        loadOIDCProviders(),
 		}
 	})
 	return config
 }
 
+func loadOIDCProviders() []OIDCProviderConfig {
+	var providers []OIDCProviderConfig
+
+	for _, name := range strings.Split(getEnv("OIDC_PROVIDERS", ""), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+		providers = append(providers, OIDCProviderConfig{
+			Name:               name,
+			Issuer:             getEnv(prefix+"ISSUER", ""),
+			ClientID:           getEnv(prefix+"CLIENT_ID", ""),
+			ClientSecret:       getEnv(prefix+"CLIENT_SECRET", ""),
+			Scopes:             strings.Fields(getEnv(prefix+"SCOPES", "openid email profile")),
+			IDClaim:            getEnv(prefix+"CLAIM_ID", "sub"),
+			EmailClaim:         getEnv(prefix+"CLAIM_EMAIL", "email"),
+			EmailVerifiedClaim: getEnv(prefix+"CLAIM_EMAIL_VERIFIED", "email_verified"),
+			NameClaim:          getEnv(prefix+"CLAIM_NAME", "name"),
+			PictureClaim:       getEnv(prefix+"CLAIM_PICTURE", "picture"),
+			TrustEmail:         getEnv(prefix+"TRUST_EMAIL", "false") == "true",
+		})
+	}
+
+	return providers
+}
+
+// splitList splits a comma separated list, lowercasing and dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		item = strings.ToLower(strings.TrimSpace(item))
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// defaultForProduction picks a default depending on GO_ENV, so local development over
+// plain HTTP works without extra settings
+func defaultForProduction(production string, otherwise string) string {
+	if getEnv("GO_ENV", "development") == "production" {
+		return production
+	}
+	return otherwise
+}
+
+// getEnvInt reads an integer variable, falling back to the default if it isn't a number
+func getEnvInt(key string, defaultValue int) int {
+	value, err := strconv.Atoi(getEnv(key, strconv.Itoa(defaultValue)))
+	if err != nil {
+		log.Printf("Warning: %s is not a number, using %d", key, defaultValue)
+		return defaultValue
+	}
+	return value
+}
+
 func getEnv(key string, defaultValue ...string) string {
 	if value, exists := os.LookupEnv(key); exists {
 		return value
@@ -105,7 +238,34 @@ func InitCORS(e *echo.Echo) {
 	e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
 		AllowOrigins:     []string{cfg.FrontendOrigin}, // Explicitly allow frontend origin
 		AllowMethods:     []string{echo.GET, echo.POST, echo.PUT, echo.DELETE, echo.PATCH},
-		AllowHeaders:     []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept, "Authorization"},
+		AllowHeaders:     []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept, "Authorization", "X-CSRF-Token"},
+		ExposeHeaders:    []string{echo.HeaderRetryAfter},
 		AllowCredentials: true, // Important: Allow credentials to send over the cookie
 	}))
 }
+
+// InitIPExtractor decides where c.RealIP() comes from. Client addresses are used for
+// login throttling and the audit log, so X-Forwarded-For is only read when the request
+// comes through one of the TRUSTED_PROXIES, otherwise any client could pick its own address.
+func InitIPExtractor(e *echo.Echo) error {
+	cfg := LoadConfig()
+	if len(cfg.TrustedProxies) == 0 {
+		e.IPExtractor = echo.ExtractIPDirect()
+		return nil
+	}
+
+	options := []echo.TrustOption{
+		echo.TrustLoopback(false),
+		echo.TrustLinkLocal(false),
+		echo.TrustPrivateNet(false),
+	}
+	for _, cidr := range cfg.TrustedProxies {
+		_, ipRange, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return fmt.Errorf("TRUSTED_PROXIES: %w", err)
+		}
+		options = append(options, echo.TrustIPRange(ipRange))
+	}
+	e.IPExtractor = echo.ExtractIPFromXFFHeader(options...)
+	return nil
+}
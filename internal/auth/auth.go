@@ -1,6 +1,9 @@
 package auth
 
 import (
+	"context"
+	"fmt"
+	"regexp"
 	"sync"
 
 	"github.com/csusmGDSC/csusmgdsc-api/config"
@@ -10,46 +13,83 @@ import (
 )
 
 var (
-	once         sync.Once
-	githubConfig *oauth2.Config
-	googleConfig *oauth2.Config
+	once      sync.Once
+	initErr   error
+	providers map[string]Provider
 )
 
 type OAuthUserData struct {
-	ID        string  `json:"id"`
-	Name      string  `json:"name"`
-	Email     *string `json:"email,omitempty"`
-	AvatarURL *string `json:"avatar_url,omitempty"`
-	Provider  string  `json:"provider"`
+	ID            string  `json:"id"`
+	Name          string  `json:"name"`
+	Email         *string `json:"email,omitempty"`
+	EmailVerified bool    `json:"email_verified"` // The provider confirmed the user owns Email
+	AvatarURL     *string `json:"avatar_url,omitempty"`
+	Provider      string  `json:"provider"`
 }
 
-func InitOAuth() {
+// Provider is an OAuth 2.0 or OpenID Connect login provider
+type Provider interface {
+	Name() string
+	// AuthCodeURL returns the URL to send the user to. The PKCE challenge is derived from
+	// codeVerifier; nonce is bound into the ID token by OpenID Connect providers.
+	AuthCodeURL(state string, codeVerifier string, nonce string) (string, error)
+	// Exchange trades the authorization code for the signed in user's profile
+	Exchange(ctx context.Context, code string, codeVerifier string, nonce string) (*OAuthUserData, error)
+}
+
+var providerNamePattern = regexp.MustCompile(`^[a-z0-9_-]+$`)
+
+// InitOAuth registers GitHub, Google and every OpenID Connect provider from the config
+func InitOAuth() error {
 	once.Do(func() {
 		cfg := config.LoadConfig()
+		providers = map[string]Provider{}
 
-		githubConfig = &oauth2.Config{
+		register(&githubProvider{config: &oauth2.Config{
 			ClientID:     cfg.GitHubClientID,
 			ClientSecret: cfg.GitHubClientSecret,
 			Endpoint:     github.Endpoint,
 			RedirectURL:  cfg.OAuthRedirectUrl + "/auth/github/callback",
 			Scopes:       []string{"user:email"},
-		}
+		}})
 
-		googleConfig = &oauth2.Config{
+		register(&googleProvider{config: &oauth2.Config{
 			ClientID:     cfg.GoogleClientID,
 			ClientSecret: cfg.GoogleClientSecret,
 			Endpoint:     google.Endpoint,
 			RedirectURL:  cfg.OAuthRedirectUrl + "/auth/google/callback",
 			Scopes:       []string{"email", "profile"},
-		}
+		}})
+
+		for _, providerCfg := range cfg.OIDCProviders {
+			if !providerNamePattern.MatchString(providerCfg.Name) {
+				initErr = fmt.Errorf("invalid OIDC provider name: %q", providerCfg.Name)
+				return
+			}
+			if _, exists := providers[providerCfg.Name]; exists {
+				initErr = fmt.Errorf("duplicate OAuth provider: %s", providerCfg.Name)
+				return
+			}
+			if providerCfg.Issuer == "" || providerCfg.ClientID == "" {
+				initErr = fmt.Errorf("OIDC provider %s needs an issuer and client ID", providerCfg.Name)
+				return
+			}
 
+			register(NewOIDCProvider(providerCfg, cfg.OAuthRedirectUrl+"/auth/"+providerCfg.Name+"/callback"))
+		}
 	})
+	return initErr
 }
 
-func GetGitHubConfig() *oauth2.Config {
-	return githubConfig
+func register(provider Provider) {
+	providers[provider.Name()] = provider
 }
 
-func GetGoogleConfig() *oauth2.Config {
-	return googleConfig
+// GetProvider returns the registered provider with the given name
+func GetProvider(name string) (Provider, error) {
+	provider, ok := providers[name]
+	if !ok {
+		return nil, fmt.Errorf("unsupported provider: %s", name)
+	}
+	return provider, nil
 }
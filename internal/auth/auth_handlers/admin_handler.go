@@ -0,0 +1,308 @@
+package auth_handlers
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_models"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_repositories"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_utils"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/models"
+	"github.com/go-playground/validator"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// AdminUpdateUser changes a user's primary role, position, branch and/or total points.
+// Every change is written to the audit log. A role change signs the user out everywhere so their
+// next access token carries the new role. Only admins can change an admin or make someone
+// an admin. Requires the users:manage permission.
+func (h *OAuthHandler) AdminUpdateUser(c echo.Context) error {
+	adminID, err := contextUserID(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Unauthorized"})
+	}
+
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid user ID"})
+	}
+
+	var req auth_models.AdminUpdateUserRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request format"})
+	}
+
+	if err := h.Validate.Struct(req); err != nil {
+		var validationErrors []string
+		for _, err := range err.(validator.ValidationErrors) {
+			validationErrors = append(validationErrors, err.Field()+" "+err.Tag())
+		}
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"errors": validationErrors,
+		})
+	}
+
+	if req.Role == nil && req.Position == nil && req.Branch == nil && req.TotalPoints == nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Nothing to update"})
+	}
+
+	actorRole, _ := c.Get("user_role").(string)
+	var role *models.Role
+	if req.Role != nil {
+		r := models.Role(*req.Role)
+		if r != models.UserRole && r != models.AdminRole {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Role must be USER or ADMIN; use the roles endpoint for officer roles"})
+		}
+		if userID == adminID {
+			return c.JSON(http.StatusForbidden, map[string]string{"error": "You can't change your own role"})
+		}
+		if !auth_utils.CanAssignRole(actorRole, r) {
+			return c.JSON(http.StatusForbidden, map[string]string{"error": "Only admins can make someone an admin"})
+		}
+		role = &r
+	}
+	if req.Position != nil {
+		if _, ok := models.GDSCPositionMap[*req.Position]; !ok {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid position"})
+		}
+	}
+	if req.Branch != nil {
+		if _, ok := models.GDSCBranchMap[*req.Branch]; !ok {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid branch"})
+		}
+	}
+
+	dbConn := h.DB.GetDB()
+	userRepo := auth_repositories.NewUserRepository(dbConn)
+	user, err := userRepo.GetByID(userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "User not found"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get user"})
+	}
+
+	if !auth_utils.CanModerateUser(actorRole, user) {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "Only admins can change an admin"})
+	}
+
+	err = userRepo.Update(userID.String(), auth_models.UpdateUserRequest{
+		Role:        role,
+		Position:    req.Position,
+		Branch:      req.Branch,
+		TotalPoints: req.TotalPoints,
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to update user"})
+	}
+
+	if role != nil && (user.Role == nil || *user.Role != *role) {
+		var from interface{}
+		if user.Role != nil {
+			from = user.Role.String()
+		}
+		details := map[string]interface{}{"from": from, "to": role.String(), "reason": req.Reason}
+		if err := auth_utils.RecordAudit(dbConn, &adminID, auth_utils.AuditUserRoleChanged, auth_utils.AuditTargetUser, userID.String(), c.RealIP(), details); err != nil {
+			log.Printf("Failed to record role change for user %s: %v", userID, err)
+		}
+
+		refreshTokensRepo := auth_repositories.NewRefreshTokenRepository(dbConn)
+		if err := refreshTokensRepo.DeleteAllByUserID(userID.String()); err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Role updated but failed to revoke the user's sessions"})
+		}
+	}
+
+	if req.Position != nil && (user.Position == nil || *user.Position != *req.Position) {
+		var from interface{}
+		if user.Position != nil {
+			from = user.Position.String()
+		}
+		details := map[string]interface{}{"from": from, "to": req.Position.String(), "reason": req.Reason}
+		if err := auth_utils.RecordAudit(dbConn, &adminID, auth_utils.AuditUserPositionChanged, auth_utils.AuditTargetUser, userID.String(), c.RealIP(), details); err != nil {
+			log.Printf("Failed to record position change for user %s: %v", userID, err)
+		}
+	}
+
+	if req.Branch != nil && (user.Branch == nil || *user.Branch != *req.Branch) {
+		var from interface{}
+		if user.Branch != nil {
+			from = user.Branch.String()
+		}
+		details := map[string]interface{}{"from": from, "to": req.Branch.String(), "reason": req.Reason}
+		if err := auth_utils.RecordAudit(dbConn, &adminID, auth_utils.AuditUserBranchChanged, auth_utils.AuditTargetUser, userID.String(), c.RealIP(), details); err != nil {
+			log.Printf("Failed to record branch change for user %s: %v", userID, err)
+		}
+	}
+
+	if req.TotalPoints != nil && (user.TotalPoints == nil || *user.TotalPoints != *req.TotalPoints) {
+		var from interface{}
+		if user.TotalPoints != nil {
+			from = *user.TotalPoints
+		}
+		details := map[string]interface{}{"from": from, "to": *req.TotalPoints, "reason": req.Reason}
+		if err := auth_utils.RecordAudit(dbConn, &adminID, auth_utils.AuditUserPointsChanged, auth_utils.AuditTargetUser, userID.String(), c.RealIP(), details); err != nil {
+			log.Printf("Failed to record points change for user %s: %v", userID, err)
+		}
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "User updated successfully"})
+}
+
+// ImpersonateUser issues a short-lived, read-only access token for viewing the API as
+// another user, e.g. to reproduce what a member reports seeing. Users who can manage users
+// can't be impersonated, so impersonation never grants more access than the admin has.
+// Every request made with the token is audited. Requires the users:manage permission.
+func (h *OAuthHandler) ImpersonateUser(c echo.Context) error {
+	adminID, err := contextUserID(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Unauthorized"})
+	}
+
+	// Impersonation tokens can't mint further impersonation tokens
+	if _, ok := c.Get("impersonator_id").(string); ok {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "Already impersonating a user"})
+	}
+
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid user ID"})
+	}
+
+	var req auth_models.ImpersonateRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request format"})
+	}
+
+	if err := h.Validate.Struct(req); err != nil {
+		var validationErrors []string
+		for _, err := range err.(validator.ValidationErrors) {
+			validationErrors = append(validationErrors, err.Field()+" "+err.Tag())
+		}
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"errors": validationErrors,
+		})
+	}
+
+	dbConn := h.DB.GetDB()
+	userRepo := auth_repositories.NewUserRepository(dbConn)
+	rbacRepo := auth_repositories.NewRBACRepository(dbConn)
+	auditRepo := auth_repositories.NewAuditLogRepository(dbConn)
+
+	token, expiresAt, user, err := auth_utils.StartImpersonation(userRepo, rbacRepo, auditRepo, adminID, userID, req.Reason, c.RealIP())
+	if err != nil {
+		switch err {
+		case auth_utils.ErrUserDoesntExist:
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "User not found"})
+		case auth_utils.ErrCannotImpersonate:
+			return c.JSON(http.StatusForbidden, map[string]string{"error": "You can't impersonate yourself, admins or users who can manage users"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to start impersonation"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"accessToken":   token,
+		"expires_at":    expiresAt,
+		"impersonating": user,
+	})
+}
+
+// ListAuditLogs returns audit log entries, newest first. Supports the filters
+// ?action=, ?target_type=, ?target_id= and ?actor_id=, and pagination with ?page=x&limit=y.
+// Requires the users:manage permission.
+func (h *OAuthHandler) ListAuditLogs(c echo.Context) error {
+	page, err := strconv.Atoi(c.QueryParam("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	limit, err := strconv.Atoi(c.QueryParam("limit"))
+	if err != nil || limit < 1 {
+		limit = 50
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	filter := auth_models.AuditLogFilter{
+		Action:     c.QueryParam("action"),
+		TargetType: c.QueryParam("target_type"),
+		TargetID:   c.QueryParam("target_id"),
+		ActorID:    c.QueryParam("actor_id"),
+		Limit:      limit,
+		Offset:     (page - 1) * limit,
+	}
+
+	auditRepo := auth_repositories.NewAuditLogRepository(h.DB.GetDB())
+	entries, err := auditRepo.List(filter)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get audit logs"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"audit_logs": entries,
+		"page":       page,
+		"limit":      limit,
+	})
+}
+
+// ListLoginLockouts returns the emails and IP addresses that are currently locked out
+// after too many failed logins. Requires the users:manage permission.
+func (h *OAuthHandler) ListLoginLockouts(c echo.Context) error {
+	throttleRepo := auth_repositories.NewLoginThrottleRepository(h.DB.GetDB())
+	lockouts, err := throttleRepo.GetLocked()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get login lockouts"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{"lockouts": lockouts})
+}
+
+// ClearLoginLockout lifts a lockout early, e.g. after a user confirms they forgot their
+// password. Takes ?scope=email|ip|mfa&key=x, where the key for mfa is the user ID.
+// Requires the users:manage permission.
+func (h *OAuthHandler) ClearLoginLockout(c echo.Context) error {
+	adminID, err := contextUserID(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Unauthorized"})
+	}
+
+	scope := c.QueryParam("scope")
+	key := c.QueryParam("key")
+	targetType := scope
+	switch scope {
+	case auth_utils.ThrottleScopeEmail:
+		key = auth_utils.NormalizeLoginEmail(key)
+	case auth_utils.ThrottleScopeIP:
+	case auth_utils.ThrottleScopeMFA:
+		if _, err := uuid.Parse(key); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "key must be a user ID for the mfa scope"})
+		}
+		targetType = auth_utils.AuditTargetUser
+	default:
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "scope must be email, ip or mfa"})
+	}
+	if key == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "key is required"})
+	}
+
+	dbConn := h.DB.GetDB()
+	throttleRepo := auth_repositories.NewLoginThrottleRepository(dbConn)
+	cleared, err := throttleRepo.Delete(scope, key)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to clear login lockout"})
+	}
+	if !cleared {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "No failed logins recorded for this key"})
+	}
+
+	details := map[string]interface{}{"scope": scope, "key": key}
+	if err := auth_utils.RecordAudit(dbConn, &adminID, auth_utils.AuditLoginUnlocked, targetType, key, c.RealIP(), details); err != nil {
+		log.Printf("Failed to record login unlock for %s %s: %v", scope, key, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "Login lockout cleared"})
+}
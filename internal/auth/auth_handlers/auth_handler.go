@@ -2,16 +2,19 @@ package auth_handlers
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
+	"log"
+	"math"
 	"net/http"
 	"net/url"
-	"time"
+	"strconv"
 
 	"github.com/csusmGDSC/csusmgdsc-api/config"
 	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_models"
 	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_repositories"
 	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_utils"
-	"github.com/csusmGDSC/csusmgdsc-api/internal/models"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/db/repositories"
 	"github.com/go-playground/validator"
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
@@ -42,15 +45,21 @@ func (h *OAuthHandler) RegisterUser(c echo.Context) error {
 		if err == auth_utils.ErrUserExists {
 			return c.JSON(http.StatusConflict, map[string]string{"error": "Email already registered"})
 		}
+		if status, message, ok := registrationError(err); ok {
+			return c.JSON(status, map[string]string{"error": message})
+		}
+		if body, ok := passwordPolicyError(err); ok {
+			return c.JSON(http.StatusBadRequest, body)
+		}
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Registration failed"})
 	}
 
-	verificationToken, err := auth_utils.GenerateJWT(user.ID, nil, auth_utils.RefreshTokenExpiry)
+	verificationToken, err := auth_utils.GeneratePurposeToken(user.ID, auth_utils.PurposeVerifyEmail, user.Email, auth_utils.VerificationTokenExpiry)
 	if err != nil {
-		return auth_utils.ErrVerificationToken
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": auth_utils.ErrVerificationToken.Error()})
 	}
 
-	err = auth_utils.SendVerificationEmail(user.Email, verificationToken)
+	err = auth_utils.SendVerificationEmail(h.Mailer, requestLocale(c), user.Email, verificationToken)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to send verification email"})
 	}
@@ -76,15 +85,44 @@ func (h *OAuthHandler) LoginUser(c echo.Context) error {
 
 	dbConn := h.DB.GetDB()
 
-	user, err := auth_utils.AuthenticateUser(dbConn, req)
+	user, err := auth_utils.AuthenticateUser(dbConn, req, c.RealIP())
 	if err != nil {
+		var lockedErr *auth_utils.LoginLockedError
+		if errors.As(err, &lockedErr) {
+			retryAfter := int(math.Ceil(lockedErr.RetryAfter.Seconds()))
+			c.Response().Header().Set("Retry-After", strconv.Itoa(retryAfter))
+			return c.JSON(http.StatusTooManyRequests, map[string]interface{}{
+				"error":       "Too many failed login attempts, try again later",
+				"retry_after": retryAfter,
+			})
+		}
 		if err == auth_utils.ErrInvalidCredentials {
-			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Invalid credentials: "})
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Invalid email or password"})
 		}
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Authentication failed:" + err.Error()})
+		log.Printf("Login failed: %v", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Authentication failed"})
 	}
 
-	accessToken, cookie, err := auth_utils.CreateLoginSession(dbConn, c.RealIP(), c.Request().Header.Get("User-Agent"), user)
+	// With two-factor authentication enabled the password alone doesn't create a session.
+	// The client exchanges the mfa_token and a code at /auth/mfa/verify instead.
+	if user.TOTPEnabled {
+		mfaToken, err := auth_utils.BeginMFALogin(user)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to start two-factor authentication"})
+		}
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"mfa_required": true,
+			"mfa_token":    mfaToken,
+		})
+	}
+
+	accessToken, cookie, err := auth_utils.CreateLoginSession(dbConn, c.RealIP(), c.Request().Header.Get("User-Agent"), user, false)
+	if body, ok := accountSuspendedError(err); ok {
+		return c.JSON(http.StatusForbidden, body)
+	}
+	if err == auth_utils.ErrEmailNotVerified {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "Verify your email before signing in"})
+	}
 
 	if err == auth_utils.ErrAccessToken {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to generate access token"})
@@ -94,19 +132,19 @@ func (h *OAuthHandler) LoginUser(c echo.Context) error {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to generate refresh token"})
 	}
 
-	if err == auth_utils.ErrNewSession {
+	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to create new session"})
 	}
 
-	c.SetCookie(cookie)
+	csrfToken := setSessionCookies(c, cookie)
 
 	// Check if the user's email is not verified
 	if !user.EmailVerified {
-		verificationToken, err := auth_utils.GenerateJWT(user.ID, user.Role, auth_utils.VerificationTokenExpiry)
+		verificationToken, err := auth_utils.GeneratePurposeToken(user.ID, auth_utils.PurposeVerifyEmail, user.Email, auth_utils.VerificationTokenExpiry)
 		if err != nil {
 			return c.JSON(http.StatusInternalServerError, auth_utils.ErrVerificationToken)
 		}
-		err = auth_utils.SendVerificationEmail(user.Email, verificationToken)
+		err = auth_utils.SendVerificationEmail(h.Mailer, requestLocale(c), user.Email, verificationToken)
 		if err != nil {
 			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to send verification email:"})
 		}
@@ -114,12 +152,13 @@ func (h *OAuthHandler) LoginUser(c echo.Context) error {
 
 	return c.JSON(http.StatusOK, map[string]interface{}{
 		"accessToken": accessToken,
+		"csrfToken":   csrfToken,
 		"user":        user,
 	})
 }
 
 func (h *OAuthHandler) LogoutUser(c echo.Context) error {
-	cookie, err := c.Cookie("refresh_token")
+	cookie, err := c.Cookie(auth_utils.RefreshCookieName())
 	if err != nil {
 		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Cookie not found"})
 	}
@@ -137,16 +176,7 @@ func (h *OAuthHandler) LogoutUser(c echo.Context) error {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to delete token"})
 	}
 
-	clearedCookie := &http.Cookie{
-		Name:     "refresh_token",
-		Value:    "",
-		Path:     "/",
-		Expires:  time.Unix(0, 0), // Expire immediately
-		HttpOnly: true,
-		Secure:   true,
-		SameSite: http.SameSiteStrictMode,
-	}
-	c.SetCookie(clearedCookie)
+	clearSessionCookies(c)
 
 	return c.JSON(http.StatusOK, map[string]string{"message": "Logged out successfully"})
 }
@@ -165,22 +195,15 @@ func (h *OAuthHandler) LogoutAll(c echo.Context) error {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to delete tokens"})
 	}
 
-	clearedCookie := &http.Cookie{
-		Name:     "refresh_token",
-		Value:    "",
-		Path:     "/",
-		Expires:  time.Unix(0, 0), // Expire immediately
-		HttpOnly: true,
-		Secure:   true,
-		SameSite: http.SameSiteStrictMode,
-	}
-	c.SetCookie(clearedCookie)
+	clearSessionCookies(c)
 
 	return c.JSON(http.StatusOK, map[string]string{"message": "All sessions have been logged out successfully"})
 }
 
+// RefreshUser exchanges the refresh token cookie for a new access token and rotates the refresh token.
+// Presenting a refresh token that was already exchanged revokes every token in its family.
 func (h *OAuthHandler) RefreshUser(c echo.Context) error {
-	cookie, err := c.Cookie("refresh_token")
+	cookie, err := c.Cookie(auth_utils.RefreshCookieName())
 	if err != nil {
 		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Cookie not found"})
 	}
@@ -190,36 +213,37 @@ func (h *OAuthHandler) RefreshUser(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Refresh token is required"})
 	}
 
-	cfg := config.LoadConfig()
-	claims, err := auth_utils.ValidateJWT(refreshToken, []byte(cfg.JWTRefreshSecret))
-	if err != nil {
-		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Invalid token"})
-	}
-
 	dbConn := h.DB.GetDB()
 
 	refreshTokensRepo := auth_repositories.NewRefreshTokenRepository(dbConn)
-	storedToken, err := refreshTokensRepo.GetByToken(refreshToken)
-	if err != nil {
-		return c.JSON(http.StatusExpectationFailed, map[string]string{"error": err.Error()})
-	}
+	userRepo := auth_repositories.NewUserRepository(dbConn)
 
-	if claims.UserID != storedToken.UserID.String() {
-		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Invalid token claims"})
-	}
+	newAccessToken, newCookie, err := auth_utils.RotateRefreshToken(refreshTokensRepo, userRepo, refreshToken, c.RealIP(), c.Request().Header.Get("User-Agent"))
+	if err != nil {
+		// Suspending a user revokes their sessions, this covers tokens issued in the meantime
+		if body, ok := accountSuspendedError(err); ok {
+			clearSessionCookies(c)
+			return c.JSON(http.StatusForbidden, body)
+		}
 
-	if time.Now().After(storedToken.ExpiresAt) {
-		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Refresh token expired"})
+		switch err {
+		case auth_utils.ErrInvalidToken:
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Invalid token"})
+		case auth_utils.ErrRefreshTokenExpired:
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Refresh token expired"})
+		case auth_utils.ErrRefreshTokenReused:
+			clearSessionCookies(c)
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Refresh token has already been used, please log in again"})
+		default:
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Could not refresh session"})
+		}
 	}
 
-	role := models.Role(claims.Role)
-	newAccessToken, err := auth_utils.GenerateJWT(storedToken.UserID, &role, auth_utils.AccessTokenExpiry)
-	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Could not create refresh token"})
-	}
+	csrfToken := setSessionCookies(c, newCookie)
 
 	return c.JSON(http.StatusOK, map[string]string{
 		"accessToken": newAccessToken,
+		"csrfToken":   csrfToken,
 	})
 }
 
@@ -229,10 +253,14 @@ func (h *OAuthHandler) UpdateUser(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "User ID is required"})
 	}
 
-	// Check if the authenticated user has permission to update this user
-	authenticatedUserID, ok := c.Get("user_id").(string)
-	if !ok || authenticatedUserID != userID {
-		return c.JSON(http.StatusForbidden, map[string]string{"error": "Not authorized to update this user"})
+	authenticatedUserID, err := contextUserID(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Unauthorized"})
+	}
+
+	targetID, err := uuid.Parse(userID)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid user ID"})
 	}
 
 	// Bind the update request
@@ -242,10 +270,33 @@ func (h *OAuthHandler) UpdateUser(c echo.Context) error {
 	}
 
 	dbConn := h.DB.GetDB()
+	userRepo := auth_repositories.NewUserRepository(dbConn)
+
+	target, err := userRepo.GetByID(targetID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "User not found"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get user"})
+	}
+
+	// Check which fields the authenticated user may change on this user
+	access, err := auth_utils.AccessForUserUpdate(dbConn, authenticatedUserID, target)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to check permissions"})
+	}
+	if !access.Allowed() {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "Not authorized to update this user"})
+	}
+	if forbidden := auth_utils.ForbiddenUserUpdateFields(req, access); len(forbidden) > 0 {
+		return c.JSON(http.StatusForbidden, map[string]interface{}{
+			"error":            "Not allowed to change some of the requested fields",
+			"forbidden_fields": forbidden,
+		})
+	}
 
 	// Update user
-	userRepo := auth_repositories.NewUserRepository(dbConn)
-	err := userRepo.Update(userID, req)
+	err = userRepo.Update(userID, req)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return c.JSON(http.StatusNotFound, map[string]string{"error": "User not found"})
@@ -256,54 +307,144 @@ func (h *OAuthHandler) UpdateUser(c echo.Context) error {
 	return c.JSON(http.StatusOK, map[string]string{"message": "User updated successfully"})
 }
 
-// // TODO: Add Role checking to allow Admin's to delete non-Admin users
+// DeleteUser schedules the authenticated user's own account for deletion after a grace
+// period and signs them out everywhere. Signing back in keeps the account. Admins delete
+// other accounts through DELETE /admin/users/:id.
 func (h *OAuthHandler) DeleteUser(c echo.Context) error {
 	userID := c.Param("id")
 	if userID == "" {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "User ID is required"})
 	}
 
-	authenticatedUserID, ok := c.Get("user_id").(string)
-	if !ok || authenticatedUserID != userID {
+	authenticatedUserID, err := contextUserID(c)
+	if err != nil || authenticatedUserID.String() != userID {
 		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Not authorized to delete this user"})
 	}
 
 	dbConn := h.DB.GetDB()
-
 	userRepo := auth_repositories.NewUserRepository(dbConn)
-	err := userRepo.DeleteByID(userID)
+	refreshTokenRepo := auth_repositories.NewRefreshTokenRepository(dbConn)
+	patRepo := auth_repositories.NewPersonalAccessTokenRepository(dbConn)
+
+	scheduledAt, err := auth_utils.ScheduleAccountDeletion(userRepo, refreshTokenRepo, patRepo, authenticatedUserID)
 	if err != nil {
+		if err == auth_utils.ErrUserDoesntExist {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "User not found"})
+		}
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to delete user"})
 	}
 
-	return c.JSON(http.StatusOK, map[string]string{"message": "User deleted successfully"})
+	details := map[string]interface{}{"scheduled_at": scheduledAt}
+	if err := auth_utils.RecordAudit(dbConn, &authenticatedUserID, auth_utils.AuditUserDeletionRequested, auth_utils.AuditTargetUser, userID, c.RealIP(), details); err != nil {
+		log.Printf("Failed to record deletion request of user %s: %v", userID, err)
+	}
+
+	clearSessionCookies(c)
+
+	return c.JSON(http.StatusAccepted, auth_models.AccountDeletionResponse{
+		Message:     "Your account will be deleted at the end of the grace period, sign in to cancel before then",
+		ScheduledAt: scheduledAt,
+	})
+}
+
+// CancelAccountDeletion keeps the authenticated user's account after they asked to delete it
+func (h *OAuthHandler) CancelAccountDeletion(c echo.Context) error {
+	userID, err := contextUserID(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Unauthorized"})
+	}
+
+	dbConn := h.DB.GetDB()
+	userRepo := auth_repositories.NewUserRepository(dbConn)
+	if err := auth_utils.CancelAccountDeletion(userRepo, userID); err != nil {
+		if err == auth_utils.ErrNoDeletionScheduled {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "Account is not scheduled for deletion"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to cancel deletion"})
+	}
+
+	if err := auth_utils.RecordAudit(dbConn, &userID, auth_utils.AuditUserDeletionCancelled, auth_utils.AuditTargetUser, userID.String(), c.RealIP(), nil); err != nil {
+		log.Printf("Failed to record cancelled deletion of user %s: %v", userID, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "Account deletion cancelled"})
 }
 
+// ExportAccount downloads everything stored about the authenticated user as a JSON file
+func (h *OAuthHandler) ExportAccount(c echo.Context) error {
+	userID, err := contextUserID(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Unauthorized"})
+	}
+
+	dbConn := h.DB.GetDB()
+	userRepo := auth_repositories.NewUserRepository(dbConn)
+	rbacRepo := auth_repositories.NewRBACRepository(dbConn)
+	identityRepo := auth_repositories.NewIdentityRepository(dbConn)
+	refreshTokenRepo := auth_repositories.NewRefreshTokenRepository(dbConn)
+	auditRepo := auth_repositories.NewAuditLogRepository(dbConn)
+	commentRepo := repositories.NewCommentRepository(dbConn)
+	eventOrganizerRepo := repositories.NewEventOrganizerRepository(dbConn)
+
+	export, err := auth_utils.ExportAccount(userRepo, rbacRepo, identityRepo, refreshTokenRepo, auditRepo, commentRepo, eventOrganizerRepo, userID)
+	if err != nil {
+		if err == auth_utils.ErrUserDoesntExist {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "User not found"})
+		}
+		log.Printf("Failed to export account %s: %v", userID, err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to export account"})
+	}
+
+	c.Response().Header().Set(echo.HeaderContentDisposition, `attachment; filename="gdsc-csusm-account.json"`)
+	return c.JSONPretty(http.StatusOK, export, "  ")
+}
+
+// VerifyUser marks the user's email as verified using the token from a verification email.
+// Each verification token can only be used once.
 func (h *OAuthHandler) VerifyUser(c echo.Context) error {
 	token := c.QueryParam("token")
 
-	cfg := config.LoadConfig()
-	claims, err := auth_utils.ValidateJWT(token, []byte(cfg.JWTAccessSecret))
+	dbConn := h.DB.GetDB()
+
+	claims, err := auth_utils.ConsumePurposeToken(dbConn, token, auth_utils.PurposeVerifyEmail)
+	if err != nil {
+		if err == auth_utils.ErrInvalidToken {
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid or expired verification token"})
+		}
+		if err == auth_utils.ErrTokenAlreadyUsed {
+			return c.JSON(http.StatusConflict, map[string]string{"error": "verification token has already been used"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to verify token"})
+	}
+
+	userID, err := uuid.Parse(claims.UserID)
 	if err != nil {
-		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid token"})
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid or expired verification token"})
 	}
-	// Check if token Expired
-	if claims.ExpiresAt.Before(time.Now()) {
-		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "verifiction token expired"})
+
+	userRepo := auth_repositories.NewUserRepository(dbConn)
+
+	user, err := userRepo.GetByID(userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "User not found"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get user"})
 	}
+
+	// The token only verifies the address it was sent to
+	if user.Email != claims.Email {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid or expired verification token"})
+	}
+
 	// Create an update request with emailVerified set to ture
 	emailVerified := true
 	req := auth_models.UpdateUserRequest{
 		EmailVerified: &emailVerified,
 	}
 
-	userID := claims.UserID
-
-	dbConn := h.DB.GetDB()
-	userRepo := auth_repositories.NewUserRepository(dbConn)
-
 	// Send update request to update the user’s email verification feild in the database
-	err = userRepo.Update(userID, req)
+	err = userRepo.Update(claims.UserID, req)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return c.JSON(http.StatusNotFound, map[string]string{"error": "User not found"})
@@ -311,16 +452,36 @@ func (h *OAuthHandler) VerifyUser(c echo.Context) error {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
 	}
 
+	// Only welcome users the first time they verify
+	if !user.EmailVerified {
+		name := user.Email
+		if user.FullName != nil && *user.FullName != "" {
+			name = *user.FullName
+		}
+		if err := auth_utils.SendWelcomeEmail(h.Mailer, requestLocale(c), user.Email, name); err != nil {
+			log.Printf("Failed to send welcome email: %v", err)
+		}
+	}
+
 	return c.JSON(http.StatusOK, map[string]string{"message": "User is verified"})
 }
 
 // Handles the initial OAuth login request
 func (h *OAuthHandler) OAuthLogin(c echo.Context) error {
 	provider := c.Param("provider")
-	url, err := auth_utils.GetOAuthURL(provider)
+
+	oauthStateRepo := auth_repositories.NewOAuthStateRepository(h.DB.GetDB())
+	oauthState, err := auth_utils.CreateOAuthState(oauthStateRepo, provider, nil, c.QueryParam("invite"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	url, err := auth_utils.GetOAuthURL(provider, oauthState.State, oauthState.CodeVerifier, oauthState.Nonce)
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
 	}
+
+	c.SetCookie(auth_utils.NewOAuthStateCookie(oauthState))
 	return c.Redirect(http.StatusTemporaryRedirect, url)
 }
 
@@ -329,46 +490,70 @@ func (h *OAuthHandler) OAuthCallback(c echo.Context) error {
 	code := c.QueryParam("code")
 	state := c.QueryParam("state")
 
-	// TODO: Implement state validation
 	if state == "" {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Missing state parameter"})
 	}
 
-	userData, err := auth_utils.HandleOAuthCallback(provider, code)
-	if err != nil {
-		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Failed to authenticate"})
+	cookieState := ""
+	if stateCookie, err := c.Cookie(auth_utils.OAuthStateCookieName()); err == nil {
+		cookieState = stateCookie.Value
 	}
+	c.SetCookie(auth_utils.ClearOAuthStateCookie())
 
 	dbConn := h.DB.GetDB()
-	userRepo := auth_repositories.NewUserRepository(dbConn)
+	oauthStateRepo := auth_repositories.NewOAuthStateRepository(dbConn)
 
-	var user *models.User
-	// Check if user exists
-	if userData.ID != "" {
-		user, _ = userRepo.GetByAuthID(userData.ID)
+	oauthState, err := auth_utils.ConsumeOAuthState(oauthStateRepo, provider, state, cookieState)
+	if err != nil {
+		if err == auth_utils.ErrInvalidOAuthState {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid or expired state parameter"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to validate state"})
 	}
 
-	if user == nil {
-		// User doesn't exist - register the user
-		req := &auth_models.CreateUserOAuthRequest{
-			Email:    userData.Email,
-			Provider: &userData.Provider,
-			AuthID:   &userData.ID,
-			Image:    userData.AvatarURL,
-			Name:     &userData.Name,
+	userData, err := auth_utils.HandleOAuthCallback(provider, code, oauthState.CodeVerifier, oauthState.Nonce)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Failed to authenticate"})
+	}
+
+	// The flow was started from account settings to link another provider
+	if oauthState.LinkUserID != nil {
+		return h.finishLinkIdentity(c, *oauthState.LinkUserID, userData)
+	}
+
+	user, err := auth_utils.ResolveOAuthUser(dbConn, userData, oauthState.InviteCode)
+	if err != nil {
+		if err == auth_utils.ErrUserExists {
+			return c.JSON(http.StatusConflict, map[string]string{"error": "Email already registered, sign in and link this provider from your account settings"})
 		}
+		if status, message, ok := registrationError(err); ok {
+			return c.JSON(status, map[string]string{"error": message})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to sign in"})
+	}
 
-		user, err = auth_utils.RegisterUserOAuthToDatabase(dbConn, *req)
+	if user.TOTPEnabled {
+		mfaToken, err := auth_utils.BeginMFALogin(user)
 		if err != nil {
-			if err == auth_utils.ErrUserExists {
-				return c.JSON(http.StatusConflict, map[string]string{"error": "Email already registered"})
-			}
-			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Registration failed: " + err.Error()})
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to start two-factor authentication"})
 		}
+
+		redirectURL := fmt.Sprintf("%s/mfa?mfa_token=%s",
+			config.LoadConfig().FrontendURL,
+			url.QueryEscape(mfaToken),
+		)
+		return c.Redirect(http.StatusTemporaryRedirect, redirectURL)
 	}
 
 	//	Refactored code
-	accessToken, cookie, err := auth_utils.CreateLoginSession(dbConn, c.RealIP(), c.Request().Header.Get("User-Agent"), user)
+	accessToken, cookie, err := auth_utils.CreateLoginSession(dbConn, c.RealIP(), c.Request().Header.Get("User-Agent"), user, false)
+	if body, ok := accountSuspendedError(err); ok {
+		return c.JSON(http.StatusForbidden, body)
+	}
+	if err == auth_utils.ErrEmailNotVerified {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "Verify your email before signing in"})
+	}
+
 	if err == auth_utils.ErrAccessToken {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to generate access token"})
 	}
@@ -377,21 +562,22 @@ func (h *OAuthHandler) OAuthCallback(c echo.Context) error {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to generate refresh token"})
 	}
 
-	if err == auth_utils.ErrNewSession {
+	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to create new session"})
 	}
 
-	c.SetCookie(cookie)
+	csrfToken := setSessionCookies(c, cookie)
 
-	frontendURL := "https://gdsc-csusm.com"
+	frontendURL := config.LoadConfig().FrontendURL
 
 	if !user.IsOnboarded {
 		frontendURL = frontendURL + "/onboarding"
 	}
 
-	redirectURL := fmt.Sprintf("%s?token=%s",
+	redirectURL := fmt.Sprintf("%s?token=%s&csrf_token=%s",
 		frontendURL,
 		url.QueryEscape(accessToken),
+		url.QueryEscape(csrfToken),
 	)
 
 	return c.Redirect(http.StatusTemporaryRedirect, redirectURL)
@@ -422,3 +608,15 @@ func (h *OAuthHandler) GetUserByIDHandler(c echo.Context) error {
 	user.Password = nil
 	return c.JSON(http.StatusOK, user)
 }
+
+// GetJWKS publishes the public keys tokens are signed with, so other club services can
+// verify access tokens without sharing a secret.
+func (h *OAuthHandler) GetJWKS(c echo.Context) error {
+	jwks, err := auth_utils.PublicJWKS()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to load signing keys"})
+	}
+
+	c.Response().Header().Set("Cache-Control", "public, max-age=300")
+	return c.JSON(http.StatusOK, jwks)
+}
@@ -0,0 +1,111 @@
+package auth_handlers
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_models"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_utils"
+	"github.com/go-playground/validator"
+	"github.com/labstack/echo/v4"
+)
+
+// RequestEmailChange emails a confirmation link to the new address of the authenticated
+// user and a notice to their current address. Accounts with a password must include it,
+// and accounts without one must have signed in recently.
+func (h *OAuthHandler) RequestEmailChange(c echo.Context) error {
+	userID, err := contextUserID(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Unauthorized"})
+	}
+
+	var req auth_models.ChangeEmailRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request format"})
+	}
+
+	if err := h.Validate.Struct(req); err != nil {
+		var validationErrors []string
+		for _, err := range err.(validator.ValidationErrors) {
+			validationErrors = append(validationErrors, err.Field()+" "+err.Tag())
+		}
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"errors": validationErrors,
+		})
+	}
+
+	authTime, _ := c.Get("auth_time").(time.Time)
+
+	dbConn := h.DB.GetDB()
+	err = auth_utils.RequestEmailChange(dbConn, h.Mailer, requestLocale(c), userID, req.NewEmail, req.Password, authTime)
+	if err != nil {
+		switch err {
+		case auth_utils.ErrInvalidCredentials:
+			return c.JSON(http.StatusForbidden, map[string]string{"error": "Incorrect password"})
+		case auth_utils.ErrReauthRequired:
+			return c.JSON(http.StatusForbidden, map[string]string{"error": "Sign in again to change your email"})
+		case auth_utils.ErrEmailUnchanged:
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "New email is the same as your current email"})
+		case auth_utils.ErrEmailInUse:
+			return c.JSON(http.StatusConflict, map[string]string{"error": "Email is already in use"})
+		case auth_utils.ErrUserDoesntExist:
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "User not found"})
+		}
+		log.Printf("Failed to request email change: %v", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to send confirmation email"})
+	}
+
+	return c.JSON(http.StatusAccepted, map[string]string{"message": "A confirmation link has been sent to the new email"})
+}
+
+// ConfirmEmailChange switches the user to the new email using the token from the
+// confirmation link. It doesn't need to be signed in, since following the link proves
+// ownership of the new address. Every other session of the user is revoked.
+func (h *OAuthHandler) ConfirmEmailChange(c echo.Context) error {
+	var req auth_models.ConfirmEmailChangeRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request format"})
+	}
+
+	if err := h.Validate.Struct(req); err != nil {
+		var validationErrors []string
+		for _, err := range err.(validator.ValidationErrors) {
+			validationErrors = append(validationErrors, err.Field()+" "+err.Tag())
+		}
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"errors": validationErrors,
+		})
+	}
+
+	currentToken := ""
+	if cookie, err := c.Cookie(auth_utils.RefreshCookieName()); err == nil {
+		currentToken = cookie.Value
+	}
+
+	dbConn := h.DB.GetDB()
+	user, oldEmail, err := auth_utils.ConfirmEmailChange(dbConn, req.Token, currentToken)
+	if err != nil {
+		switch err {
+		case auth_utils.ErrInvalidToken:
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid or expired confirmation link"})
+		case auth_utils.ErrEmailInUse:
+			return c.JSON(http.StatusConflict, map[string]string{"error": "Email is already in use"})
+		}
+		log.Printf("Failed to confirm email change: %v", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to change email"})
+	}
+
+	err = auth_utils.RecordAudit(dbConn, &user.ID, auth_utils.AuditUserEmailChanged, auth_utils.AuditTargetUser, user.ID.String(), c.RealIP(), map[string]interface{}{
+		"old_email": oldEmail,
+		"new_email": user.Email,
+	})
+	if err != nil {
+		log.Printf("Failed to record email change: %v", err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Email has been changed",
+		"user":    user,
+	})
+}
@@ -2,17 +2,26 @@ package auth_handlers
 
 import (
 	"github.com/csusmGDSC/csusmgdsc-api/internal/db"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/mailer"
 	"github.com/go-playground/validator"
+	"github.com/labstack/echo/v4"
 )
 
 type OAuthHandler struct {
 	Validate *validator.Validate
 	DB       db.DatabaseConnection
+	Mailer   mailer.Mailer
 }
 
-func NewOAuthHandler(dbConn db.DatabaseConnection) *OAuthHandler {
+func NewOAuthHandler(dbConn db.DatabaseConnection, m mailer.Mailer) *OAuthHandler {
 	return &OAuthHandler{
 		Validate: validator.New(),
 		DB:       dbConn,
+		Mailer:   m,
 	}
 }
+
+// requestLocale picks the language for emails sent as a result of this request
+func requestLocale(c echo.Context) string {
+	return mailer.LocaleFromAcceptLanguage(c.Request().Header.Get("Accept-Language"))
+}
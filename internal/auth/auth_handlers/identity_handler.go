@@ -0,0 +1,117 @@
+package auth_handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+
+	"github.com/csusmGDSC/csusmgdsc-api/config"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_repositories"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_utils"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// LinkIdentity starts linking an OAuth provider to the authenticated user's account.
+//
+// Responds with the provider's authorization URL for the frontend to navigate to. Since the
+// request is authenticated with a bearer token it can't simply redirect like OAuthLogin.
+// The provider redirects back to OAuthCallback, which links the identity and redirects
+// to the frontend's account settings.
+func (h *OAuthHandler) LinkIdentity(c echo.Context) error {
+	userID, err := contextUserID(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Unauthorized"})
+	}
+
+	provider := c.Param("provider")
+
+	oauthStateRepo := auth_repositories.NewOAuthStateRepository(h.DB.GetDB())
+	oauthState, err := auth_utils.CreateOAuthState(oauthStateRepo, provider, &userID, "")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	authURL, err := auth_utils.GetOAuthURL(provider, oauthState.State, oauthState.CodeVerifier, oauthState.Nonce)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	c.SetCookie(auth_utils.NewOAuthStateCookie(oauthState))
+	return c.JSON(http.StatusOK, map[string]string{"url": authURL})
+}
+
+func (h *OAuthHandler) finishLinkIdentity(c echo.Context, userID uuid.UUID, userData *auth.OAuthUserData) error {
+	settingsURL := config.LoadConfig().FrontendURL + "/settings/accounts"
+
+	err := auth_utils.LinkIdentity(h.DB.GetDB(), userID, userData)
+	if err != nil {
+		reason := "link_failed"
+		if err == auth_utils.ErrIdentityInUse {
+			reason = "identity_in_use"
+		} else {
+			log.Printf("Failed to link %s identity: %v", userData.Provider, err)
+		}
+		return c.Redirect(http.StatusTemporaryRedirect, fmt.Sprintf("%s?error=%s", settingsURL, reason))
+	}
+
+	return c.Redirect(http.StatusTemporaryRedirect, fmt.Sprintf("%s?linked=%s", settingsURL, url.QueryEscape(userData.Provider)))
+}
+
+// ListIdentities returns the OAuth providers linked to the authenticated user's account,
+// and whether the account also has a password.
+func (h *OAuthHandler) ListIdentities(c echo.Context) error {
+	userID, err := contextUserID(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Unauthorized"})
+	}
+
+	dbConn := h.DB.GetDB()
+	userRepo := auth_repositories.NewUserRepository(dbConn)
+	user, err := userRepo.GetByID(userID)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "User not found"})
+	}
+
+	identityRepo := auth_repositories.NewIdentityRepository(dbConn)
+	identities, err := identityRepo.GetByUserID(userID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get linked accounts"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"identities":   identities,
+		"has_password": user.Password != nil,
+	})
+}
+
+// UnlinkIdentity removes a linked provider from the authenticated user's account.
+//
+// The last identity of an account without a password can't be removed.
+func (h *OAuthHandler) UnlinkIdentity(c echo.Context) error {
+	userID, err := contextUserID(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Unauthorized"})
+	}
+
+	identityID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid identity ID"})
+	}
+
+	dbConn := h.DB.GetDB()
+	err = auth_utils.UnlinkIdentity(dbConn, userID, identityID)
+	if err != nil {
+		switch err {
+		case auth_utils.ErrIdentityNotFound:
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "Linked account not found"})
+		case auth_utils.ErrLastLoginMethod:
+			return c.JSON(http.StatusConflict, map[string]string{"error": "Can't unlink your only sign in method, set a password or link another account first"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to unlink account"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "Account unlinked"})
+}
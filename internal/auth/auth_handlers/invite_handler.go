@@ -0,0 +1,124 @@
+package auth_handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+
+	"github.com/csusmGDSC/csusmgdsc-api/config"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_models"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_repositories"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_utils"
+	"github.com/go-playground/validator"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// registrationError maps registration policy errors to a response
+func registrationError(err error) (int, string, bool) {
+	switch err {
+	case auth_utils.ErrInviteRequired:
+		return http.StatusForbidden, "Registration is invite only", true
+	case auth_utils.ErrInvalidInvite:
+		return http.StatusForbidden, "Invalid or expired invite", true
+	case auth_utils.ErrInviteEmailUnverified:
+		return http.StatusForbidden, "This invite is for a verified email, sign up with a magic link or a provider that verifies your email", true
+	case auth_utils.ErrEmailDomainNotAllowed:
+		return http.StatusForbidden, "Registration is limited to verified emails from allowed domains", true
+	}
+	return 0, "", false
+}
+
+// GetRegistrationPolicy tells the frontend whether to ask for an invite code
+func (h *OAuthHandler) GetRegistrationPolicy(c echo.Context) error {
+	cfg := config.LoadConfig()
+
+	policy := map[string]interface{}{"mode": cfg.RegistrationMode}
+	if cfg.RegistrationMode == auth_utils.RegistrationDomain {
+		policy["allowed_domains"] = cfg.AllowedEmailDomains
+	}
+
+	return c.JSON(http.StatusOK, policy)
+}
+
+// CreateInvite generates an invite code and sign up link. The code is only included in this
+// response. Requires the users:manage permission.
+func (h *OAuthHandler) CreateInvite(c echo.Context) error {
+	adminID, err := contextUserID(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Unauthorized"})
+	}
+
+	var req auth_models.CreateInviteRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request format"})
+	}
+
+	if err := h.Validate.Struct(req); err != nil {
+		var validationErrors []string
+		for _, err := range err.(validator.ValidationErrors) {
+			validationErrors = append(validationErrors, err.Field()+" "+err.Tag())
+		}
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"errors": validationErrors,
+		})
+	}
+
+	dbConn := h.DB.GetDB()
+	code, invite, err := auth_utils.CreateInvite(dbConn, adminID, req)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to create invite"})
+	}
+
+	details := map[string]interface{}{"email": invite.Email, "max_uses": invite.MaxUses, "expires_at": invite.ExpiresAt}
+	if err := auth_utils.RecordAudit(dbConn, &adminID, auth_utils.AuditInviteCreated, auth_utils.AuditTargetInvite, invite.ID.String(), c.RealIP(), details); err != nil {
+		log.Printf("Failed to record invite creation: %v", err)
+	}
+
+	return c.JSON(http.StatusCreated, auth_models.CreateInviteResponse{
+		Code:   code,
+		Link:   fmt.Sprintf("%s/register?invite=%s", config.LoadConfig().FrontendURL, url.QueryEscape(code)),
+		Invite: invite,
+	})
+}
+
+// ListInvites returns every invite, without the codes. Requires the users:manage permission.
+func (h *OAuthHandler) ListInvites(c echo.Context) error {
+	inviteRepo := auth_repositories.NewInviteRepository(h.DB.GetDB())
+	invites, err := inviteRepo.GetAll()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get invites"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{"invites": invites})
+}
+
+// RevokeInvite deletes an invite so it can't be used anymore. Requires the users:manage permission.
+func (h *OAuthHandler) RevokeInvite(c echo.Context) error {
+	adminID, err := contextUserID(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Unauthorized"})
+	}
+
+	inviteID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid invite ID"})
+	}
+
+	dbConn := h.DB.GetDB()
+	inviteRepo := auth_repositories.NewInviteRepository(dbConn)
+	deleted, err := inviteRepo.Delete(inviteID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to revoke invite"})
+	}
+	if !deleted {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Invite not found"})
+	}
+
+	if err := auth_utils.RecordAudit(dbConn, &adminID, auth_utils.AuditInviteRevoked, auth_utils.AuditTargetInvite, inviteID.String(), c.RealIP(), nil); err != nil {
+		log.Printf("Failed to record invite revocation: %v", err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "Invite revoked"})
+}
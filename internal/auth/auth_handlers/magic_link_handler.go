@@ -0,0 +1,128 @@
+package auth_handlers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_models"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_repositories"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_utils"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/models"
+	"github.com/go-playground/validator"
+	"github.com/labstack/echo/v4"
+)
+
+// RequestMagicLink emails a single-use sign-in link.
+//
+// It always responds with 202 Accepted, whether or not the email is registered,
+// so the endpoint can't be used to find out who has an account.
+func (h *OAuthHandler) RequestMagicLink(c echo.Context) error {
+	var req auth_models.MagicLinkRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request format"})
+	}
+
+	if err := h.Validate.Struct(req); err != nil {
+		var validationErrors []string
+		for _, err := range err.(validator.ValidationErrors) {
+			validationErrors = append(validationErrors, err.Field()+" "+err.Tag())
+		}
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"errors": validationErrors,
+		})
+	}
+
+	// Sent in the background like ForgotPassword, so the response time doesn't depend on
+	// whether the email is registered
+	dbConn := h.DB.GetDB()
+	locale := requestLocale(c)
+	go func() {
+		if err := auth_utils.RequestMagicLink(dbConn, h.Mailer, locale, req.Email, req.InviteCode); err != nil {
+			log.Printf("Failed to send magic link email: %v", err)
+		}
+	}()
+
+	return c.JSON(http.StatusAccepted, map[string]string{"message": "If this email can sign in, a sign-in link has been sent"})
+}
+
+// ConsumeMagicLink signs in with the token from a sign-in link. The emailed link opens a
+// frontend page that posts the token here, so link scanners in mail clients that fetch
+// every URL in a message can't use it up.
+//
+// Accounts with two-factor authentication get an mfa_token to finish signing in at
+// /auth/mfa/verify, like a password login.
+func (h *OAuthHandler) ConsumeMagicLink(c echo.Context) error {
+	var req auth_models.ConsumeMagicLinkRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request format"})
+	}
+
+	if err := h.Validate.Struct(req); err != nil {
+		var validationErrors []string
+		for _, err := range err.(validator.ValidationErrors) {
+			validationErrors = append(validationErrors, err.Field()+" "+err.Tag())
+		}
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"errors": validationErrors,
+		})
+	}
+
+	user, err := h.consumeMagicLink(c, req.Token, req.InviteCode)
+	if err != nil {
+		if err == auth_utils.ErrInvalidToken {
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Invalid or expired sign-in link"})
+		}
+		if status, message, ok := registrationError(err); ok {
+			return c.JSON(status, map[string]string{"error": message})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to sign in"})
+	}
+
+	if user.TOTPEnabled {
+		mfaToken, err := auth_utils.BeginMFALogin(user)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to start two-factor authentication"})
+		}
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"mfa_required": true,
+			"mfa_token":    mfaToken,
+		})
+	}
+
+	accessToken, cookie, err := auth_utils.CreateLoginSession(h.DB.GetDB(), c.RealIP(), c.Request().Header.Get("User-Agent"), user, false)
+	if body, ok := accountSuspendedError(err); ok {
+		return c.JSON(http.StatusForbidden, body)
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to create new session"})
+	}
+
+	csrfToken := setSessionCookies(c, cookie)
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"accessToken": accessToken,
+		"csrfToken":   csrfToken,
+		"user":        user,
+	})
+}
+
+// consumeMagicLink uses up the link and welcomes users whose account it created
+func (h *OAuthHandler) consumeMagicLink(c echo.Context, token string, inviteCode string) (*models.User, error) {
+	dbConn := h.DB.GetDB()
+	usedTokenRepo := auth_repositories.NewUsedTokenRepository(dbConn)
+	userRepo := auth_repositories.NewUserRepository(dbConn)
+	inviteRepo := auth_repositories.NewInviteRepository(dbConn)
+
+	user, created, err := auth_utils.ConsumeMagicLink(usedTokenRepo, userRepo, inviteRepo, token, inviteCode)
+	if err != nil {
+		return nil, err
+	}
+
+	if created {
+		if err := auth_utils.SendWelcomeEmail(h.Mailer, requestLocale(c), user.Email, user.Email); err != nil {
+			log.Printf("Failed to send welcome email: %v", err)
+		}
+	}
+
+	return user, nil
+}
@@ -0,0 +1,244 @@
+package auth_handlers
+
+import (
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_models"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_repositories"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_utils"
+	"github.com/go-playground/validator"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// EnrollTOTP starts two-factor authentication setup for the authenticated user.
+//
+// Responds with the secret and an otpauth:// URI to show as a QR code. TOTP isn't
+// enabled until a code from the authenticator app is sent to ConfirmTOTP.
+func (h *OAuthHandler) EnrollTOTP(c echo.Context) error {
+	userID, err := contextUserID(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Unauthorized"})
+	}
+
+	dbConn := h.DB.GetDB()
+	userRepo := auth_repositories.NewUserRepository(dbConn)
+	user, err := userRepo.GetByID(userID)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "User not found"})
+	}
+
+	secret, otpauthURL, err := auth_utils.EnrollTOTP(dbConn, user.ID, user.Email)
+	if err != nil {
+		if err == auth_utils.ErrMFAAlreadyEnabled {
+			return c.JSON(http.StatusConflict, map[string]string{"error": "Two-factor authentication is already enabled"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to start two-factor authentication setup"})
+	}
+
+	return c.JSON(http.StatusOK, auth_models.TOTPEnrollResponse{
+		Secret:     secret,
+		OTPAuthURL: otpauthURL,
+	})
+}
+
+// ConfirmTOTP enables two-factor authentication once the user enters a valid code.
+//
+// Responds with the recovery codes. They are stored hashed and can't be shown again.
+func (h *OAuthHandler) ConfirmTOTP(c echo.Context) error {
+	userID, err := contextUserID(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Unauthorized"})
+	}
+
+	var req auth_models.TOTPCodeRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request format"})
+	}
+
+	if err := h.Validate.Struct(req); err != nil {
+		var validationErrors []string
+		for _, err := range err.(validator.ValidationErrors) {
+			validationErrors = append(validationErrors, err.Field()+" "+err.Tag())
+		}
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"errors": validationErrors,
+		})
+	}
+
+	dbConn := h.DB.GetDB()
+	recoveryCodes, err := auth_utils.ConfirmTOTP(dbConn, userID, req.Code)
+	if err != nil {
+		if body, ok := mfaLockedError(c, err); ok {
+			return c.JSON(http.StatusTooManyRequests, body)
+		}
+		switch err {
+		case auth_utils.ErrMFAAlreadyEnabled:
+			return c.JSON(http.StatusConflict, map[string]string{"error": "Two-factor authentication is already enabled"})
+		case auth_utils.ErrMFANotEnrolled:
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Two-factor authentication setup has not been started"})
+		case auth_utils.ErrInvalidMFACode:
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid code"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to enable two-factor authentication"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{"recovery_codes": recoveryCodes})
+}
+
+// DisableTOTP turns off two-factor authentication. Requires a current code or a recovery code.
+func (h *OAuthHandler) DisableTOTP(c echo.Context) error {
+	userID, err := contextUserID(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Unauthorized"})
+	}
+
+	var req auth_models.MFACodeRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request format"})
+	}
+
+	dbConn := h.DB.GetDB()
+	err = auth_utils.DisableTOTP(dbConn, userID, req.Code, req.RecoveryCode)
+	if err != nil {
+		if body, ok := mfaLockedError(c, err); ok {
+			return c.JSON(http.StatusTooManyRequests, body)
+		}
+		switch err {
+		case auth_utils.ErrMFARequired:
+			return c.JSON(http.StatusForbidden, map[string]string{"error": "Two-factor authentication is required for your role"})
+		case auth_utils.ErrMFANotEnrolled:
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Two-factor authentication is not enabled"})
+		case auth_utils.ErrInvalidMFACode:
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid code"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to disable two-factor authentication"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "Two-factor authentication disabled"})
+}
+
+// RegenerateRecoveryCodes replaces the authenticated user's recovery codes. Requires a current code.
+func (h *OAuthHandler) RegenerateRecoveryCodes(c echo.Context) error {
+	userID, err := contextUserID(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Unauthorized"})
+	}
+
+	var req auth_models.TOTPCodeRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request format"})
+	}
+
+	if err := h.Validate.Struct(req); err != nil {
+		var validationErrors []string
+		for _, err := range err.(validator.ValidationErrors) {
+			validationErrors = append(validationErrors, err.Field()+" "+err.Tag())
+		}
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"errors": validationErrors,
+		})
+	}
+
+	dbConn := h.DB.GetDB()
+	recoveryCodes, err := auth_utils.RegenerateRecoveryCodes(dbConn, userID, req.Code)
+	if err != nil {
+		if body, ok := mfaLockedError(c, err); ok {
+			return c.JSON(http.StatusTooManyRequests, body)
+		}
+		switch err {
+		case auth_utils.ErrMFANotEnrolled:
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Two-factor authentication is not enabled"})
+		case auth_utils.ErrInvalidMFACode:
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid code"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to regenerate recovery codes"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{"recovery_codes": recoveryCodes})
+}
+
+// VerifyMFA completes a login for an account with two-factor authentication enabled.
+//
+// Takes the mfa_token returned by LoginUser (or the OAuth callback) with either a code
+// from the authenticator app or a recovery code. The mfa_token is single-use: after a
+// wrong code the user has to sign in again.
+func (h *OAuthHandler) VerifyMFA(c echo.Context) error {
+	var req auth_models.MFAVerifyRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request format"})
+	}
+
+	if err := h.Validate.Struct(req); err != nil {
+		var validationErrors []string
+		for _, err := range err.(validator.ValidationErrors) {
+			validationErrors = append(validationErrors, err.Field()+" "+err.Tag())
+		}
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"errors": validationErrors,
+		})
+	}
+
+	dbConn := h.DB.GetDB()
+	user, err := auth_utils.CompleteMFALogin(dbConn, req.MFAToken, req.Code, req.RecoveryCode)
+	if err != nil {
+		if body, ok := mfaLockedError(c, err); ok {
+			return c.JSON(http.StatusTooManyRequests, body)
+		}
+		switch err {
+		case auth_utils.ErrInvalidToken, auth_utils.ErrTokenAlreadyUsed, auth_utils.ErrMFANotEnrolled:
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Invalid or expired login, please sign in again"})
+		case auth_utils.ErrInvalidMFACode:
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Invalid code, please sign in again"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to verify two-factor authentication"})
+	}
+
+	accessToken, cookie, err := auth_utils.CreateLoginSession(dbConn, c.RealIP(), c.Request().Header.Get("User-Agent"), user, true)
+	if body, ok := accountSuspendedError(err); ok {
+		return c.JSON(http.StatusForbidden, body)
+	}
+	if err == auth_utils.ErrEmailNotVerified {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "Verify your email before signing in"})
+	}
+	if err != nil {
+		log.Printf("Failed to create session after two-factor authentication: %v", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to create new session"})
+	}
+
+	csrfToken := setSessionCookies(c, cookie)
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"accessToken": accessToken,
+		"csrfToken":   csrfToken,
+		"user":        user,
+	})
+}
+
+func contextUserID(c echo.Context) (uuid.UUID, error) {
+	userIDStr, ok := c.Get("user_id").(string)
+	if !ok || userIDStr == "" {
+		return uuid.Nil, auth_utils.ErrInvalidToken
+	}
+	return uuid.Parse(userIDStr)
+}
+
+// mfaLockedError returns the response body and sets Retry-After if err means the user
+// entered too many wrong two-factor codes
+func mfaLockedError(c echo.Context, err error) (map[string]interface{}, bool) {
+	var lockedErr *auth_utils.LoginLockedError
+	if !errors.As(err, &lockedErr) {
+		return nil, false
+	}
+
+	retryAfter := int(math.Ceil(lockedErr.RetryAfter.Seconds()))
+	c.Response().Header().Set("Retry-After", strconv.Itoa(retryAfter))
+	return map[string]interface{}{
+		"error":       "Too many wrong codes, try again later",
+		"retry_after": retryAfter,
+	}, true
+}
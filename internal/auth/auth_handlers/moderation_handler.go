@@ -0,0 +1,212 @@
+package auth_handlers
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_models"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_repositories"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_utils"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/models"
+	"github.com/go-playground/validator"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// accountSuspendedError maps an *AccountSuspendedError to a response body
+func accountSuspendedError(err error) (map[string]interface{}, bool) {
+	var suspendedErr *auth_utils.AccountSuspendedError
+	if !errors.As(err, &suspendedErr) {
+		return nil, false
+	}
+
+	if suspendedErr.Until == nil {
+		return map[string]interface{}{
+			"error":  "Account banned",
+			"reason": suspendedErr.Reason,
+		}, true
+	}
+	return map[string]interface{}{
+		"error":           "Account suspended",
+		"reason":          suspendedErr.Reason,
+		"suspended_until": suspendedErr.Until,
+	}, true
+}
+
+// moderationTarget loads the user in the :id parameter and checks the caller may act on
+// them. Returns a status and message to respond with if not.
+func (h *OAuthHandler) moderationTarget(c echo.Context) (uuid.UUID, *models.User, int, string) {
+	adminID, err := contextUserID(c)
+	if err != nil {
+		return uuid.Nil, nil, http.StatusUnauthorized, "Unauthorized"
+	}
+
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return uuid.Nil, nil, http.StatusBadRequest, "Invalid user ID"
+	}
+	if userID == adminID {
+		return uuid.Nil, nil, http.StatusForbidden, "You can't do this to your own account"
+	}
+
+	userRepo := auth_repositories.NewUserRepository(h.DB.GetDB())
+	user, err := userRepo.GetByID(userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return uuid.Nil, nil, http.StatusNotFound, "User not found"
+		}
+		return uuid.Nil, nil, http.StatusInternalServerError, "Failed to get user"
+	}
+
+	role, _ := c.Get("user_role").(string)
+	if !auth_utils.CanModerateUser(role, user) {
+		return uuid.Nil, nil, http.StatusForbidden, "Only admins can do this to an admin"
+	}
+
+	return adminID, user, 0, ""
+}
+
+// SuspendUser blocks a user from signing in or using the API until the given time and
+// signs them out everywhere. Requires the users:manage permission.
+func (h *OAuthHandler) SuspendUser(c echo.Context) error {
+	var req auth_models.SuspendUserRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request format"})
+	}
+
+	if err := h.Validate.Struct(req); err != nil {
+		var validationErrors []string
+		for _, err := range err.(validator.ValidationErrors) {
+			validationErrors = append(validationErrors, err.Field()+" "+err.Tag())
+		}
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"errors": validationErrors,
+		})
+	}
+
+	if !req.Until.After(time.Now()) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "until must be in the future"})
+	}
+
+	adminID, user, status, message := h.moderationTarget(c)
+	if status != 0 {
+		return c.JSON(status, map[string]string{"error": message})
+	}
+
+	dbConn := h.DB.GetDB()
+	if err := auth_utils.SuspendUser(dbConn, user.ID, req.Until, req.Reason); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to suspend user"})
+	}
+
+	details := map[string]interface{}{"until": req.Until, "reason": req.Reason}
+	if err := auth_utils.RecordAudit(dbConn, &adminID, auth_utils.AuditUserSuspended, auth_utils.AuditTargetUser, user.ID.String(), c.RealIP(), details); err != nil {
+		log.Printf("Failed to record suspension of user %s: %v", user.ID, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "User suspended"})
+}
+
+// BanUser blocks a user indefinitely and signs them out everywhere.
+// Requires the users:manage permission.
+func (h *OAuthHandler) BanUser(c echo.Context) error {
+	var req auth_models.BanUserRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request format"})
+	}
+
+	if err := h.Validate.Struct(req); err != nil {
+		var validationErrors []string
+		for _, err := range err.(validator.ValidationErrors) {
+			validationErrors = append(validationErrors, err.Field()+" "+err.Tag())
+		}
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"errors": validationErrors,
+		})
+	}
+
+	adminID, user, status, message := h.moderationTarget(c)
+	if status != 0 {
+		return c.JSON(status, map[string]string{"error": message})
+	}
+
+	dbConn := h.DB.GetDB()
+	if err := auth_utils.BanUser(dbConn, user.ID, req.Reason); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to ban user"})
+	}
+
+	details := map[string]interface{}{"reason": req.Reason}
+	if err := auth_utils.RecordAudit(dbConn, &adminID, auth_utils.AuditUserBanned, auth_utils.AuditTargetUser, user.ID.String(), c.RealIP(), details); err != nil {
+		log.Printf("Failed to record ban of user %s: %v", user.ID, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "User banned"})
+}
+
+// ReinstateUser lifts a suspension or ban. Requires the users:manage permission.
+func (h *OAuthHandler) ReinstateUser(c echo.Context) error {
+	adminID, user, status, message := h.moderationTarget(c)
+	if status != 0 {
+		return c.JSON(status, map[string]string{"error": message})
+	}
+
+	dbConn := h.DB.GetDB()
+	if err := auth_utils.ReinstateUser(dbConn, user.ID); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to reinstate user"})
+	}
+
+	if err := auth_utils.RecordAudit(dbConn, &adminID, auth_utils.AuditUserReinstated, auth_utils.AuditTargetUser, user.ID.String(), c.RealIP(), nil); err != nil {
+		log.Printf("Failed to record reinstatement of user %s: %v", user.ID, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "User reinstated"})
+}
+
+// ForceLogoutUser revokes every session and personal access token of a user. Access
+// tokens already issued stay valid until they expire. Requires the users:manage permission.
+func (h *OAuthHandler) ForceLogoutUser(c echo.Context) error {
+	adminID, user, status, message := h.moderationTarget(c)
+	if status != 0 {
+		return c.JSON(status, map[string]string{"error": message})
+	}
+
+	dbConn := h.DB.GetDB()
+	refreshTokensRepo := auth_repositories.NewRefreshTokenRepository(dbConn)
+	if err := refreshTokensRepo.DeleteAllByUserID(user.ID.String()); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to delete tokens"})
+	}
+	patRepo := auth_repositories.NewPersonalAccessTokenRepository(dbConn)
+	if err := patRepo.DeleteAllByUserID(user.ID); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to delete tokens"})
+	}
+
+	if err := auth_utils.RecordAudit(dbConn, &adminID, auth_utils.AuditUserLoggedOut, auth_utils.AuditTargetUser, user.ID.String(), c.RealIP(), nil); err != nil {
+		log.Printf("Failed to record logout of user %s: %v", user.ID, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "All sessions of the user have been logged out"})
+}
+
+// AdminDeleteUser deletes another user's account straight away, without the grace period
+// users get when deleting their own. Only admins can delete admins.
+// Requires the users:manage permission.
+func (h *OAuthHandler) AdminDeleteUser(c echo.Context) error {
+	adminID, user, status, message := h.moderationTarget(c)
+	if status != 0 {
+		return c.JSON(status, map[string]string{"error": message})
+	}
+
+	dbConn := h.DB.GetDB()
+	if err := auth_utils.PurgeUser(dbConn, user.ID); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to delete user"})
+	}
+
+	// The entry outlives the account, so it doesn't keep the email
+	if err := auth_utils.RecordAudit(dbConn, &adminID, auth_utils.AuditUserDeleted, auth_utils.AuditTargetUser, user.ID.String(), c.RealIP(), nil); err != nil {
+		log.Printf("Failed to record deletion of user %s: %v", user.ID, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "User deleted successfully"})
+}
@@ -0,0 +1,177 @@
+package auth_handlers
+
+import (
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/csusmGDSC/csusmgdsc-api/config"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_models"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_utils"
+	"github.com/go-playground/validator"
+	"github.com/labstack/echo/v4"
+)
+
+// passwordPolicyError maps a *PasswordPolicyError to a response body listing each
+// violation with a code the frontend can use to show its own message
+func passwordPolicyError(err error) (map[string]interface{}, bool) {
+	var policyErr *auth_utils.PasswordPolicyError
+	if !errors.As(err, &policyErr) {
+		return nil, false
+	}
+	return map[string]interface{}{
+		"error":           "Password does not meet the requirements",
+		"password_errors": policyErr.Violations,
+	}, true
+}
+
+// GetPasswordPolicy tells the frontend which password requirements to show
+func (h *OAuthHandler) GetPasswordPolicy(c echo.Context) error {
+	cfg := config.LoadConfig()
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"min_length":       cfg.PasswordMinLength,
+		"min_strength":     cfg.PasswordMinStrength,
+		"breached_checked": cfg.BreachedPasswordsDir != "",
+	})
+}
+
+// ForgotPassword emails a password reset link to the account with the given email.
+//
+// It always responds with 202 Accepted, whether or not the email is registered,
+// so the endpoint can't be used to find out who has an account.
+func (h *OAuthHandler) ForgotPassword(c echo.Context) error {
+	var req auth_models.ForgotPasswordRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request format"})
+	}
+
+	if err := h.Validate.Struct(req); err != nil {
+		var validationErrors []string
+		for _, err := range err.(validator.ValidationErrors) {
+			validationErrors = append(validationErrors, err.Field()+" "+err.Tag())
+		}
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"errors": validationErrors,
+		})
+	}
+
+	// The lookup and the email happen in the background, so an unknown email gets its
+	// response as quickly as a registered one
+	dbConn := h.DB.GetDB()
+	locale := requestLocale(c)
+	go func() {
+		if err := auth_utils.RequestPasswordReset(dbConn, h.Mailer, locale, req.Email); err != nil {
+			log.Printf("Failed to send password reset email: %v", err)
+		}
+	}()
+
+	return c.JSON(http.StatusAccepted, map[string]string{"message": "If an account exists for this email, a password reset link has been sent"})
+}
+
+// ResetPassword sets a new password using the token from a password reset email.
+//
+// The token can only be used once. On success every existing session of the user is revoked.
+func (h *OAuthHandler) ResetPassword(c echo.Context) error {
+	var req auth_models.ResetPasswordRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request format"})
+	}
+
+	if err := h.Validate.Struct(req); err != nil {
+		var validationErrors []string
+		for _, err := range err.(validator.ValidationErrors) {
+			validationErrors = append(validationErrors, err.Field()+" "+err.Tag())
+		}
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"errors": validationErrors,
+		})
+	}
+
+	dbConn := h.DB.GetDB()
+	err := auth_utils.ResetPassword(dbConn, req.Token, req.Password)
+	if err != nil {
+		if err == auth_utils.ErrInvalidToken {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid or expired reset token"})
+		}
+		if body, ok := passwordPolicyError(err); ok {
+			return c.JSON(http.StatusBadRequest, body)
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to reset password"})
+	}
+
+	clearSessionCookies(c)
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "Password has been reset"})
+}
+
+// ChangePassword sets a new password for the authenticated user, who must enter their
+// current one. With revoke_other_sessions, every other session is signed out. The user is
+// notified by email either way.
+func (h *OAuthHandler) ChangePassword(c echo.Context) error {
+	userID, err := contextUserID(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Unauthorized"})
+	}
+
+	var req auth_models.ChangePasswordRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request format"})
+	}
+
+	if err := h.Validate.Struct(req); err != nil {
+		var validationErrors []string
+		for _, err := range err.(validator.ValidationErrors) {
+			validationErrors = append(validationErrors, err.Field()+" "+err.Tag())
+		}
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"errors": validationErrors,
+		})
+	}
+
+	currentToken := ""
+	if cookie, err := c.Cookie(auth_utils.RefreshCookieName()); err == nil {
+		currentToken = cookie.Value
+	}
+
+	dbConn := h.DB.GetDB()
+	email, err := auth_utils.ChangePassword(dbConn, userID, req.CurrentPassword, req.NewPassword, c.RealIP(), currentToken, req.RevokeOtherSessions)
+	if err != nil {
+		var lockedErr *auth_utils.LoginLockedError
+		if errors.As(err, &lockedErr) {
+			retryAfter := int(math.Ceil(lockedErr.RetryAfter.Seconds()))
+			c.Response().Header().Set("Retry-After", strconv.Itoa(retryAfter))
+			return c.JSON(http.StatusTooManyRequests, map[string]interface{}{
+				"error":       "Too many failed attempts, try again later",
+				"retry_after": retryAfter,
+			})
+		}
+		if body, ok := passwordPolicyError(err); ok {
+			return c.JSON(http.StatusBadRequest, body)
+		}
+		switch err {
+		case auth_utils.ErrInvalidCredentials:
+			return c.JSON(http.StatusForbidden, map[string]string{"error": "Incorrect current password"})
+		case auth_utils.ErrNoPassword:
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Your account doesn't have a password, sign in with your linked provider or an email link instead"})
+		case auth_utils.ErrPasswordUnchanged:
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "New password must be different from the current password"})
+		case auth_utils.ErrUserDoesntExist:
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "User not found"})
+		}
+		log.Printf("Failed to change password: %v", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to change password"})
+	}
+
+	details := map[string]interface{}{"revoked_other_sessions": req.RevokeOtherSessions}
+	if err := auth_utils.RecordAudit(dbConn, &userID, auth_utils.AuditUserPasswordChanged, auth_utils.AuditTargetUser, userID.String(), c.RealIP(), details); err != nil {
+		log.Printf("Failed to record password change of user %s: %v", userID, err)
+	}
+
+	if err := auth_utils.SendPasswordChangedEmail(h.Mailer, requestLocale(c), email); err != nil {
+		log.Printf("Failed to send password changed email: %v", err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "Password has been changed"})
+}
@@ -0,0 +1,138 @@
+package auth_handlers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_models"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_repositories"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_utils"
+	"github.com/go-playground/validator"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// GetMyPermissions returns the authenticated user's roles and the permissions they grant,
+// so the frontend can decide which controls to show.
+func (h *OAuthHandler) GetMyPermissions(c echo.Context) error {
+	userID, err := contextUserID(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Unauthorized"})
+	}
+
+	dbConn := h.DB.GetDB()
+	rbacRepo := auth_repositories.NewRBACRepository(dbConn)
+
+	roles, err := rbacRepo.GetRolesByUserID(userID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get roles"})
+	}
+
+	permissions, err := rbacRepo.GetPermissionsByUserID(userID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get permissions"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"roles":       roles,
+		"permissions": permissions,
+	})
+}
+
+// ListRoles returns every role with its permissions. Requires the users:manage permission.
+func (h *OAuthHandler) ListRoles(c echo.Context) error {
+	dbConn := h.DB.GetDB()
+	rbacRepo := auth_repositories.NewRBACRepository(dbConn)
+
+	roles, err := rbacRepo.GetRoles()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get roles"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{"roles": roles})
+}
+
+// GrantUserRole gives a user an additional role, e.g. MARKETING_OFFICER.
+// Requires the users:manage permission.
+func (h *OAuthHandler) GrantUserRole(c echo.Context) error {
+	adminID, err := contextUserID(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Unauthorized"})
+	}
+
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid user ID"})
+	}
+
+	var req auth_models.GrantRoleRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request format"})
+	}
+
+	if err := h.Validate.Struct(req); err != nil {
+		var validationErrors []string
+		for _, err := range err.(validator.ValidationErrors) {
+			validationErrors = append(validationErrors, err.Field()+" "+err.Tag())
+		}
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"errors": validationErrors,
+		})
+	}
+
+	dbConn := h.DB.GetDB()
+	userRepo := auth_repositories.NewUserRepository(dbConn)
+	if _, err := userRepo.GetByID(userID); err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "User not found"})
+	}
+
+	err = auth_utils.GrantRole(auth_repositories.NewRBACRepository(dbConn), userID, req.Role, adminID)
+	if err != nil {
+		switch err {
+		case auth_utils.ErrRoleNotFound:
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Role not found"})
+		case auth_utils.ErrPrimaryRole:
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "USER and ADMIN are set as the user's role and can't be granted"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to grant role"})
+	}
+
+	details := map[string]interface{}{"role": req.Role}
+	if err := auth_utils.RecordAudit(dbConn, &adminID, auth_utils.AuditUserRoleGranted, auth_utils.AuditTargetUser, userID.String(), c.RealIP(), details); err != nil {
+		log.Printf("Failed to record role grant for user %s: %v", userID, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "Role granted"})
+}
+
+// RevokeUserRole removes an additional role from a user. Requires the users:manage permission.
+func (h *OAuthHandler) RevokeUserRole(c echo.Context) error {
+	adminID, err := contextUserID(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Unauthorized"})
+	}
+
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid user ID"})
+	}
+
+	dbConn := h.DB.GetDB()
+	rbacRepo := auth_repositories.NewRBACRepository(dbConn)
+
+	role := c.Param("role")
+	revoked, err := rbacRepo.RevokeRole(userID, role)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to revoke role"})
+	}
+	if !revoked {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "User doesn't have this role"})
+	}
+
+	details := map[string]interface{}{"role": role}
+	if err := auth_utils.RecordAudit(dbConn, &adminID, auth_utils.AuditUserRoleRevoked, auth_utils.AuditTargetUser, userID.String(), c.RealIP(), details); err != nil {
+		log.Printf("Failed to record role revocation for user %s: %v", userID, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "Role revoked"})
+}
@@ -0,0 +1,141 @@
+package auth_handlers
+
+import (
+	"net/http"
+
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_repositories"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_utils"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// ListSessions returns the authenticated user's active sessions.
+//
+// Each session is one logged in device. The session belonging to the refresh token
+// cookie sent with the request is marked with "current": true.
+func (h *OAuthHandler) ListSessions(c echo.Context) error {
+	userIDStr, ok := c.Get("user_id").(string)
+	if !ok || userIDStr == "" {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Unauthorized"})
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid user ID"})
+	}
+
+	currentToken := ""
+	if cookie, err := c.Cookie(auth_utils.RefreshCookieName()); err == nil {
+		currentToken = cookie.Value
+	}
+
+	dbConn := h.DB.GetDB()
+	sessions, err := auth_utils.ListSessions(dbConn, userID, currentToken)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get sessions"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{"sessions": sessions})
+}
+
+// RevokeSession logs the authenticated user out of one of their sessions.
+//
+// If the revoked session is the current one, the refresh token cookie is cleared as well.
+func (h *OAuthHandler) RevokeSession(c echo.Context) error {
+	userIDStr, ok := c.Get("user_id").(string)
+	if !ok || userIDStr == "" {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Unauthorized"})
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid user ID"})
+	}
+
+	sessionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid session ID"})
+	}
+
+	dbConn := h.DB.GetDB()
+	refreshTokensRepo := auth_repositories.NewRefreshTokenRepository(dbConn)
+
+	isCurrent := false
+	if cookie, err := c.Cookie(auth_utils.RefreshCookieName()); err == nil && cookie.Value != "" {
+		if currentSession, err := refreshTokensRepo.GetByToken(cookie.Value); err == nil {
+			isCurrent = currentSession.FamilyID == sessionID
+		}
+	}
+
+	deleted, err := refreshTokensRepo.DeleteUserFamily(userID, sessionID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to revoke session"})
+	}
+	if !deleted {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Session not found"})
+	}
+
+	if isCurrent {
+		clearSessionCookies(c)
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "Session revoked successfully"})
+}
+
+// ListUserSessions returns the active sessions of any user. Requires the users:manage permission.
+func (h *OAuthHandler) ListUserSessions(c echo.Context) error {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid user ID"})
+	}
+
+	dbConn := h.DB.GetDB()
+	sessions, err := auth_utils.ListSessions(dbConn, userID, "")
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get sessions"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{"sessions": sessions})
+}
+
+// RevokeUserSession logs any user out of one of their sessions. Requires the users:manage permission.
+func (h *OAuthHandler) RevokeUserSession(c echo.Context) error {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid user ID"})
+	}
+
+	sessionID, err := uuid.Parse(c.Param("sessionId"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid session ID"})
+	}
+
+	dbConn := h.DB.GetDB()
+	refreshTokensRepo := auth_repositories.NewRefreshTokenRepository(dbConn)
+
+	deleted, err := refreshTokensRepo.DeleteUserFamily(userID, sessionID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to revoke session"})
+	}
+	if !deleted {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Session not found"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "Session revoked successfully"})
+}
+
+// setSessionCookies sets the refresh cookie of a new session along with its CSRF cookie,
+// and returns the CSRF token for the response body. Cookie authenticated requests must
+// send it back in the X-CSRF-Token header.
+func setSessionCookies(c echo.Context, refreshCookie *http.Cookie) string {
+	csrfCookie := auth_utils.CSRFCookie(refreshCookie)
+	c.SetCookie(refreshCookie)
+	c.SetCookie(csrfCookie)
+	return csrfCookie.Value
+}
+
+// clearSessionCookies removes the refresh and CSRF cookies from the browser
+func clearSessionCookies(c echo.Context) {
+	c.SetCookie(auth_utils.ClearRefreshCookie())
+	c.SetCookie(auth_utils.ClearCSRFCookie())
+}
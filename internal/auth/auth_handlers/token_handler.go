@@ -0,0 +1,102 @@
+package auth_handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_models"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_repositories"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_utils"
+	"github.com/go-playground/validator"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// CreatePersonalAccessToken issues a token for scripts and bots to use in place of an
+// access token. The token is only included in this response.
+func (h *OAuthHandler) CreatePersonalAccessToken(c echo.Context) error {
+	userID, err := contextUserID(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Unauthorized"})
+	}
+
+	var req auth_models.CreatePersonalAccessTokenRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request format"})
+	}
+
+	if err := h.Validate.Struct(req); err != nil {
+		var validationErrors []string
+		for _, err := range err.(validator.ValidationErrors) {
+			validationErrors = append(validationErrors, err.Field()+" "+err.Tag())
+		}
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"errors": validationErrors,
+		})
+	}
+
+	expiry := auth_utils.PersonalAccessTokenDefaultExpiry
+	if req.ExpiresInDays > 0 {
+		expiry = time.Duration(req.ExpiresInDays) * 24 * time.Hour
+	}
+	mfa, _ := c.Get("mfa").(bool)
+
+	dbConn := h.DB.GetDB()
+	patRepo := auth_repositories.NewPersonalAccessTokenRepository(dbConn)
+	rbacRepo := auth_repositories.NewRBACRepository(dbConn)
+	token, pat, err := auth_utils.CreatePersonalAccessToken(patRepo, rbacRepo, userID, req.Name, req.Scopes, expiry, mfa)
+	if err != nil {
+		switch err {
+		case auth_utils.ErrTooManyTokens:
+			return c.JSON(http.StatusConflict, map[string]string{"error": "Too many personal access tokens, revoke one first"})
+		case auth_utils.ErrInvalidScope:
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Scopes must be permissions you hold"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to create personal access token"})
+	}
+
+	return c.JSON(http.StatusCreated, auth_models.CreatePersonalAccessTokenResponse{
+		Token:               token,
+		PersonalAccessToken: pat,
+	})
+}
+
+// ListPersonalAccessTokens returns the authenticated user's tokens, without the tokens themselves
+func (h *OAuthHandler) ListPersonalAccessTokens(c echo.Context) error {
+	userID, err := contextUserID(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Unauthorized"})
+	}
+
+	patRepo := auth_repositories.NewPersonalAccessTokenRepository(h.DB.GetDB())
+	tokens, err := patRepo.GetByUserID(userID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get personal access tokens"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{"tokens": tokens})
+}
+
+// RevokePersonalAccessToken deletes one of the authenticated user's tokens
+func (h *OAuthHandler) RevokePersonalAccessToken(c echo.Context) error {
+	userID, err := contextUserID(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Unauthorized"})
+	}
+
+	tokenID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid token ID"})
+	}
+
+	patRepo := auth_repositories.NewPersonalAccessTokenRepository(h.DB.GetDB())
+	deleted, err := patRepo.Delete(tokenID, userID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to revoke personal access token"})
+	}
+	if !deleted {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Personal access token not found"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "Personal access token revoked"})
+}
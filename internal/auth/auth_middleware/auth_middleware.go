@@ -1,33 +1,233 @@
 package auth_middleware
 
 import (
+	"database/sql"
+	"errors"
 	"net/http"
+	"slices"
 	"strings"
 
-	"github.com/csusmGDSC/csusmgdsc-api/config"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_repositories"
 	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_utils"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/db"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/models"
+	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 )
 
-func AuthMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+func AuthMiddleware(dbConn db.DatabaseConnection) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			authHeader := c.Request().Header.Get("Authorization")
+			if authHeader == "" {
+				return echo.NewHTTPError(http.StatusUnauthorized, "missing authorization header")
+			}
+
+			// Extract token from "Bearer <token>"
+			tokenString := strings.TrimPrefix(authHeader, "Bearer ")
+			if auth_utils.IsPersonalAccessToken(tokenString) {
+				return authenticatePersonalAccessToken(c, next, dbConn.GetDB(), tokenString)
+			}
+
+			claims, err := auth_utils.ValidateJWT(tokenString, auth_utils.AccessTokenType)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusUnauthorized, "invalid token")
+			}
+
+			userID, err := uuid.Parse(claims.UserID)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusUnauthorized, "invalid token")
+			}
+			// Only safe methods are allowed so nothing can be changed on the user's behalf
+			if claims.Act != nil && !isSafeMethod(c.Request().Method) {
+				return echo.NewHTTPError(http.StatusForbidden, "impersonation tokens are read-only")
+			}
+			if err := checkAccountStatus(dbConn.GetDB(), userID); err != nil {
+				return err
+			}
+
+			// Add user info to context
+			c.Set("user_id", claims.UserID)
+			c.Set("user_role", claims.Role)
+			c.Set("mfa", claims.MFA)
+			if claims.AuthTime != nil {
+				c.Set("auth_time", claims.AuthTime.Time)
+			}
+
+			if claims.Act != nil {
+				return impersonatedRequest(c, next, dbConn.GetDB(), claims)
+			}
+
+			return next(c)
+		}
+	}
+}
+
+// impersonatedRequest lets an admin see the API as the impersonated user does. Every
+// request is written to the audit log before it's handled.
+func impersonatedRequest(c echo.Context, next echo.HandlerFunc, dbConn *sql.DB, claims *auth_utils.Claims) error {
+	impersonatorID, err := uuid.Parse(claims.Act.Subject)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "invalid token")
+	}
+	c.Set("impersonator_id", impersonatorID.String())
+
+	details := map[string]interface{}{
+		"method":   c.Request().Method,
+		"path":     c.Request().URL.Path,
+		"query":    c.Request().URL.RawQuery,
+		"token_id": claims.ID,
+	}
+	err = auth_utils.RecordAudit(dbConn, &impersonatorID, auth_utils.AuditImpersonatedRequest, auth_utils.AuditTargetUser, claims.UserID, c.RealIP(), details)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to record impersonated request")
+	}
+
+	return next(c)
+}
+
+func isSafeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+// authenticatePersonalAccessToken sets the same context values as a JWT would, plus
+// "token_scopes" which limits the permissions the request can use.
+func authenticatePersonalAccessToken(c echo.Context, next echo.HandlerFunc, dbConn *sql.DB, token string) error {
+	patRepo := auth_repositories.NewPersonalAccessTokenRepository(dbConn)
+	userRepo := auth_repositories.NewUserRepository(dbConn)
+
+	// The role isn't stored on the token, so role changes apply straight away
+	pat, user, err := auth_utils.AuthenticatePersonalAccessToken(patRepo, userRepo, token)
+	if err != nil {
+		var suspendedErr *auth_utils.AccountSuspendedError
+		if errors.As(err, &suspendedErr) {
+			return echo.NewHTTPError(http.StatusForbidden, "account suspended")
+		}
+		return echo.NewHTTPError(http.StatusUnauthorized, "invalid token")
+	}
+	role := "not set"
+	if user.Role != nil {
+		role = user.Role.String()
+	}
+
+	c.Set("user_id", pat.UserID.String())
+	c.Set("user_role", role)
+	c.Set("mfa", pat.MFAVerified)
+	c.Set("token_scopes", pat.Scopes)
+
+	return next(c)
+}
+
+// checkAccountStatus rejects suspended users, whose access tokens stay valid until they expire
+func checkAccountStatus(dbConn *sql.DB, userID uuid.UUID) error {
+	err := auth_utils.CheckAccountStatus(dbConn, userID)
+	if err != nil {
+		var suspendedErr *auth_utils.AccountSuspendedError
+		if errors.As(err, &suspendedErr) {
+			return echo.NewHTTPError(http.StatusForbidden, "account suspended")
+		}
+		return echo.NewHTTPError(http.StatusUnauthorized, "invalid token")
+	}
+	return nil
+}
+
+// RequireSession rejects requests authenticated with a personal access token or an
+// impersonation token. Used on account management routes so a leaked token can't be used
+// to take over the account. Must run after AuthMiddleware.
+func RequireSession(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
-		authHeader := c.Request().Header.Get("Authorization")
-		if authHeader == "" {
-			return echo.NewHTTPError(http.StatusUnauthorized, "missing authorization header")
+		if _, ok := c.Get("token_scopes").([]string); ok {
+			return echo.NewHTTPError(http.StatusForbidden, "personal access tokens can't be used for this action")
+		}
+		if _, ok := c.Get("impersonator_id").(string); ok {
+			return echo.NewHTTPError(http.StatusForbidden, "not available while impersonating a user")
 		}
 
-		cfg := config.LoadConfig()
-		// Extract token from "Bearer <token>"
-		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-		claims, err := auth_utils.ValidateJWT(tokenString, []byte(cfg.JWTAccessSecret))
-		if err != nil {
-			return echo.NewHTTPError(http.StatusUnauthorized, "invalid token")
+		return next(c)
+	}
+}
+
+// RequireCSRFToken protects routes authenticated by the refresh cookie. Browsers attach
+// cookies to cross-site requests, so the request must also carry the session's CSRF
+// token in the X-CSRF-Token header, which other sites can't read. Requests without a
+// refresh cookie are left for the handler to reject.
+func RequireCSRFToken(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		cookie, err := c.Cookie(auth_utils.RefreshCookieName())
+		if err != nil || cookie.Value == "" {
+			return next(c)
 		}
 
-		// Add user info to context
-		c.Set("user_id", claims.UserID)
-		c.Set("user_role", claims.Role)
+		if !auth_utils.ValidCSRFToken(cookie.Value, c.Request().Header.Get(auth_utils.CSRFHeaderName)) {
+			return echo.NewHTTPError(http.StatusForbidden, "invalid CSRF token")
+		}
 
 		return next(c)
 	}
 }
+
+// RequireAdminMFA rejects users holding any permission whose session didn't pass two-factor
+// authentication, when MFA_REQUIRED_FOR_ADMINS is enabled. Must run after AuthMiddleware.
+func RequireAdminMFA(dbConn db.DatabaseConnection) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if mfa, _ := c.Get("mfa").(bool); mfa {
+				return next(c)
+			}
+
+			userIDStr, _ := c.Get("user_id").(string)
+			userID, err := uuid.Parse(userIDStr)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusUnauthorized, "invalid token")
+			}
+
+			required, err := auth_utils.MFARequired(dbConn.GetDB(), userID)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusInternalServerError, "failed to check two-factor authentication")
+			}
+			if required {
+				return echo.NewHTTPError(http.StatusForbidden, "two-factor authentication required")
+			}
+
+			return next(c)
+		}
+	}
+}
+
+// RequirePermission rejects users who don't hold all of the permissions through their roles.
+// Requests made with a personal access token also need every permission in the token's scopes.
+// Must run after AuthMiddleware.
+func RequirePermission(dbConn db.DatabaseConnection, permissions ...models.Permission) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			userIDStr, _ := c.Get("user_id").(string)
+			userID, err := uuid.Parse(userIDStr)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusUnauthorized, "invalid token")
+			}
+
+			if scopes, ok := c.Get("token_scopes").([]string); ok && !hasScopes(scopes, permissions) {
+				return echo.NewHTTPError(http.StatusForbidden, "token is missing a required scope")
+			}
+
+			allowed, err := auth_utils.HasPermissions(auth_repositories.NewRBACRepository(dbConn.GetDB()), userID, permissions...)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusInternalServerError, "failed to check permissions")
+			}
+			if !allowed {
+				return echo.NewHTTPError(http.StatusForbidden, "insufficient permissions")
+			}
+
+			return next(c)
+		}
+	}
+}
+
+func hasScopes(scopes []string, permissions []models.Permission) bool {
+	for _, permission := range permissions {
+		if !slices.Contains(scopes, permission.String()) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,252 @@
+package auth_middleware_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_middleware"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_utils"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/mocks"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/models"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	_ "github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestMain(m *testing.M) {
+	os.Setenv("GO_ENV", "test")
+	os.Exit(m.Run())
+}
+
+// runMiddleware calls the middleware with the given context values and returns the
+// status it responded with, or 200 if it called the next handler
+func runMiddleware(t *testing.T, mw echo.MiddlewareFunc, values map[string]interface{}) int {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/admin/events", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	for key, value := range values {
+		c.Set(key, value)
+	}
+
+	err := mw(func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})(c)
+	if err != nil {
+		httpErr, ok := err.(*echo.HTTPError)
+		require.True(t, ok, "unexpected error %v", err)
+		return httpErr.Code
+	}
+	return rec.Code
+}
+
+// accountStatusDB is a database that answers every query with the given account status,
+// in the columns UserRepository.GetStatus reads
+func accountStatusDB(suspendedUntil *time.Time, bannedAt *time.Time) *sql.DB {
+	row := []driver.Value{nil, nil, "Broke the code of conduct"}
+	if suspendedUntil != nil {
+		row[0] = *suspendedUntil
+	}
+	if bannedAt != nil {
+		row[1] = *bannedAt
+	}
+	return sql.OpenDB(statusConnector{row: row})
+}
+
+type statusConnector struct {
+	row []driver.Value
+}
+
+func (c statusConnector) Connect(context.Context) (driver.Conn, error) { return c.Open("") }
+func (c statusConnector) Driver() driver.Driver                        { return c }
+func (c statusConnector) Open(string) (driver.Conn, error)             { return statusConn(c), nil }
+
+type statusConn statusConnector
+
+func (c statusConn) Prepare(string) (driver.Stmt, error) { return statusStmt(c), nil }
+func (c statusConn) Close() error                        { return nil }
+func (c statusConn) Begin() (driver.Tx, error)           { return nil, errors.New("not supported") }
+
+type statusStmt statusConnector
+
+func (s statusStmt) Close() error  { return nil }
+func (s statusStmt) NumInput() int { return -1 }
+func (s statusStmt) Exec([]driver.Value) (driver.Result, error) {
+	return nil, errors.New("not supported")
+}
+func (s statusStmt) Query([]driver.Value) (driver.Rows, error) {
+	return &statusRows{row: s.row}, nil
+}
+
+type statusRows struct {
+	row  []driver.Value
+	done bool
+}
+
+func (r *statusRows) Columns() []string {
+	return []string{"suspended_until", "banned_at", "suspension_reason"}
+}
+func (r *statusRows) Close() error { return nil }
+func (r *statusRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	copy(dest, r.row)
+	return nil
+}
+
+func TestRequirePermission(t *testing.T) {
+	t.Run("Unauthenticated", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mw := auth_middleware.RequirePermission(mocks.NewMockDatabaseConnection(ctrl), models.EventsWrite)
+
+		assert.Equal(t, http.StatusUnauthorized, runMiddleware(t, mw, nil))
+	})
+
+	t.Run("Personal access token without the scope", func(t *testing.T) {
+		// Rejected before the user's roles are looked up
+		ctrl := gomock.NewController(t)
+		mw := auth_middleware.RequirePermission(mocks.NewMockDatabaseConnection(ctrl), models.EventsWrite, models.MediaUpload)
+
+		status := runMiddleware(t, mw, map[string]interface{}{
+			"user_id":      uuid.NewString(),
+			"token_scopes": []string{"events:write"},
+		})
+		assert.Equal(t, http.StatusForbidden, status)
+	})
+
+	t.Run("Permission check fails", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		dbConn := mocks.NewMockDatabaseConnection(ctrl)
+
+		unreachable, err := sql.Open("postgres", "host=/nonexistent sslmode=disable")
+		require.NoError(t, err)
+		defer unreachable.Close()
+		dbConn.EXPECT().GetDB().Return(unreachable)
+
+		mw := auth_middleware.RequirePermission(dbConn, models.EventsWrite)
+		status := runMiddleware(t, mw, map[string]interface{}{
+			"user_id":      uuid.NewString(),
+			"token_scopes": []string{"events:write"},
+		})
+		assert.Equal(t, http.StatusInternalServerError, status)
+	})
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	run := func(t *testing.T, dbConn *mocks.MockDatabaseConnection, method string, authorization string) int {
+		e := echo.New()
+		req := httptest.NewRequest(method, "/users/me", nil)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		rec := httptest.NewRecorder()
+
+		err := auth_middleware.AuthMiddleware(dbConn)(func(c echo.Context) error {
+			return c.NoContent(http.StatusOK)
+		})(e.NewContext(req, rec))
+		if err != nil {
+			httpErr, ok := err.(*echo.HTTPError)
+			require.True(t, ok, "unexpected error %v", err)
+			return httpErr.Code
+		}
+		return rec.Code
+	}
+
+	t.Run("Missing authorization header", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		assert.Equal(t, http.StatusUnauthorized, run(t, mocks.NewMockDatabaseConnection(ctrl), http.MethodGet, ""))
+	})
+
+	t.Run("Invalid access token", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		assert.Equal(t, http.StatusUnauthorized, run(t, mocks.NewMockDatabaseConnection(ctrl), http.MethodGet, "Bearer not-a-jwt"))
+	})
+
+	t.Run("Personal access token that can't be checked", func(t *testing.T) {
+		// Looked up in the database rather than validated as a JWT, and rejected when
+		// the lookup fails
+		ctrl := gomock.NewController(t)
+		dbConn := mocks.NewMockDatabaseConnection(ctrl)
+
+		unreachable, err := sql.Open("postgres", "host=/nonexistent sslmode=disable")
+		require.NoError(t, err)
+		defer unreachable.Close()
+		dbConn.EXPECT().GetDB().Return(unreachable)
+
+		assert.Equal(t, http.StatusUnauthorized, run(t, dbConn, http.MethodGet, "Bearer "+auth_utils.PersonalAccessTokenPrefix+"secret"))
+	})
+
+	t.Run("Impersonation tokens are read-only", func(t *testing.T) {
+		// Rejected before anything is looked up or audited
+		role := models.UserRole
+		token, _, err := auth_utils.GenerateImpersonationJWT(&models.User{ID: uuid.New(), Role: &role}, uuid.New())
+		require.NoError(t, err)
+
+		for _, method := range []string{http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete} {
+			ctrl := gomock.NewController(t)
+			assert.Equal(t, http.StatusForbidden, run(t, mocks.NewMockDatabaseConnection(ctrl), method, "Bearer "+token), method)
+		}
+	})
+
+	t.Run("Impersonated reads go on to the account checks", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		dbConn := mocks.NewMockDatabaseConnection(ctrl)
+		role := models.UserRole
+		token, _, err := auth_utils.GenerateImpersonationJWT(&models.User{ID: uuid.New(), Role: &role}, uuid.New())
+		require.NoError(t, err)
+
+		unreachable, err := sql.Open("postgres", "host=/nonexistent sslmode=disable")
+		require.NoError(t, err)
+		defer unreachable.Close()
+		dbConn.EXPECT().GetDB().Return(unreachable)
+
+		assert.Equal(t, http.StatusUnauthorized, run(t, dbConn, http.MethodGet, "Bearer "+token))
+	})
+
+	t.Run("Account status", func(t *testing.T) {
+		// Access tokens stay valid until they expire, so the status is checked on every request
+		role := models.UserRole
+		token, err := auth_utils.GenerateJWT(uuid.New(), &role, false, time.Now(), auth_utils.AccessTokenExpiry)
+		require.NoError(t, err)
+
+		suspendedUntil := time.Now().Add(time.Hour)
+		suspensionEnded := time.Now().Add(-time.Minute)
+		bannedAt := time.Now().Add(-time.Hour)
+
+		for _, tt := range []struct {
+			name           string
+			suspendedUntil *time.Time
+			bannedAt       *time.Time
+			want           int
+		}{
+			{"Active user", nil, nil, http.StatusOK},
+			{"Suspended user", &suspendedUntil, nil, http.StatusForbidden},
+			{"Banned user", nil, &bannedAt, http.StatusForbidden},
+			{"Suspension has ended", &suspensionEnded, nil, http.StatusOK},
+		} {
+			t.Run(tt.name, func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				dbConn := mocks.NewMockDatabaseConnection(ctrl)
+
+				statusDB := accountStatusDB(tt.suspendedUntil, tt.bannedAt)
+				defer statusDB.Close()
+				dbConn.EXPECT().GetDB().Return(statusDB)
+
+				assert.Equal(t, tt.want, run(t, dbConn, http.MethodGet, "Bearer "+token))
+			})
+		}
+	})
+}
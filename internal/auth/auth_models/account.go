@@ -0,0 +1,24 @@
+package auth_models
+
+import (
+	"time"
+
+	"github.com/csusmGDSC/csusmgdsc-api/internal/models"
+)
+
+// AccountExport is everything the API stores about a user, returned on request
+type AccountExport struct {
+	ExportedAt      time.Time         `json:"exported_at"`
+	Profile         *models.User      `json:"profile"`
+	Roles           []string          `json:"roles"`
+	Identities      []*UserIdentity   `json:"identities"`
+	Comments        []*models.Comment `json:"comments"`
+	OrganizedEvents []models.Event    `json:"organized_events"`
+	Sessions        []*Session        `json:"sessions"`
+	PointsHistory   []*AuditLog       `json:"points_history"`
+}
+
+type AccountDeletionResponse struct {
+	Message     string    `json:"message"`
+	ScheduledAt time.Time `json:"deletion_scheduled_at"`
+}
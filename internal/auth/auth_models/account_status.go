@@ -0,0 +1,19 @@
+package auth_models
+
+import "time"
+
+// AccountStatus records whether an admin has suspended or banned a user
+type AccountStatus struct {
+	SuspendedUntil *time.Time `json:"suspended_until,omitempty" db:"suspended_until"`
+	BannedAt       *time.Time `json:"banned_at,omitempty" db:"banned_at"`
+	Reason         *string    `json:"reason,omitempty" db:"suspension_reason"`
+}
+
+type SuspendUserRequest struct {
+	Until  time.Time `json:"until" validate:"required"`
+	Reason string    `json:"reason" validate:"required,max=500"`
+}
+
+type BanUserRequest struct {
+	Reason string `json:"reason" validate:"required,max=500"`
+}
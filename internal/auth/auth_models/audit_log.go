@@ -0,0 +1,42 @@
+package auth_models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/csusmGDSC/csusmgdsc-api/internal/models"
+	"github.com/google/uuid"
+)
+
+type AuditLog struct {
+	ID         int64           `json:"id" db:"id"`
+	ActorID    *uuid.UUID      `json:"actor_id" db:"actor_id"`
+	Action     string          `json:"action" db:"action"`
+	TargetType string          `json:"target_type" db:"target_type"`
+	TargetID   string          `json:"target_id" db:"target_id"`
+	Details    json.RawMessage `json:"details" db:"details"`
+	IPAddress  *string         `json:"ip_address,omitempty" db:"ip_address"`
+	CreatedAt  time.Time       `json:"created_at" db:"created_at"`
+}
+
+// AuditLogFilter narrows down an audit log listing. Empty fields match everything.
+type AuditLogFilter struct {
+	Action     string
+	TargetType string
+	TargetID   string
+	ActorID    string
+	Limit      int
+	Offset     int
+}
+
+type AdminUpdateUserRequest struct {
+	Role        *string              `json:"role,omitempty"`
+	Position    *models.GDSCPosition `json:"position,omitempty"`
+	Branch      *models.GDSCBranch   `json:"branch,omitempty"`
+	TotalPoints *int                 `json:"total_points,omitempty" validate:"omitempty,min=0"`
+	Reason      string               `json:"reason,omitempty" validate:"max=500"`
+}
+
+type ImpersonateRequest struct {
+	Reason string `json:"reason" validate:"required,max=500"`
+}
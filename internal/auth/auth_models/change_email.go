@@ -0,0 +1,10 @@
+package auth_models
+
+type ChangeEmailRequest struct {
+	NewEmail string `json:"new_email" validate:"required,email"`
+	Password string `json:"password,omitempty"` // Required for accounts that sign in with a password
+}
+
+type ConfirmEmailChangeRequest struct {
+	Token string `json:"token" validate:"required"`
+}
@@ -0,0 +1,19 @@
+package auth_models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UserIdentity is a login from an OAuth provider linked to a user
+type UserIdentity struct {
+	ID             uuid.UUID  `json:"id" db:"id"`
+	UserID         uuid.UUID  `json:"user_id" db:"user_id"`
+	Provider       string     `json:"provider" db:"provider"`
+	ProviderUserID string     `json:"provider_user_id" db:"provider_user_id"`
+	Email          *string    `json:"email,omitempty" db:"email"`
+	EmailVerified  bool       `json:"email_verified" db:"email_verified"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+	LastUsedAt     *time.Time `json:"last_used_at,omitempty" db:"last_used_at"`
+}
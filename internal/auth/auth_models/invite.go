@@ -0,0 +1,36 @@
+package auth_models
+
+import (
+	"time"
+
+	"github.com/csusmGDSC/csusmgdsc-api/internal/models"
+	"github.com/google/uuid"
+)
+
+type Invite struct {
+	ID        uuid.UUID            `json:"id" db:"id"`
+	CodeHash  string               `json:"-" db:"code_hash"`
+	Email     *string              `json:"email" db:"email"`
+	Position  *models.GDSCPosition `json:"position" db:"position"`
+	Branch    *models.GDSCBranch   `json:"branch" db:"branch"`
+	MaxUses   int                  `json:"max_uses" db:"max_uses"`
+	Uses      int                  `json:"uses" db:"uses"`
+	ExpiresAt time.Time            `json:"expires_at" db:"expires_at"`
+	CreatedBy *uuid.UUID           `json:"created_by" db:"created_by"`
+	CreatedAt time.Time            `json:"created_at" db:"created_at"`
+}
+
+type CreateInviteRequest struct {
+	Email         *string              `json:"email,omitempty" validate:"omitempty,email"`
+	Position      *models.GDSCPosition `json:"position,omitempty" validate:"omitempty,min=1,max=6"`
+	Branch        *models.GDSCBranch   `json:"branch,omitempty" validate:"omitempty,min=1,max=3"`
+	MaxUses       int                  `json:"max_uses,omitempty" validate:"omitempty,min=1,max=1000"`
+	ExpiresInDays int                  `json:"expires_in_days,omitempty" validate:"omitempty,min=1,max=90"`
+}
+
+// CreateInviteResponse is the only time the invite code is returned
+type CreateInviteResponse struct {
+	Code string `json:"code"`
+	Link string `json:"link"`
+	*Invite
+}
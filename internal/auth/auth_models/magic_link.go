@@ -0,0 +1,11 @@
+package auth_models
+
+type MagicLinkRequest struct {
+	Email      string `json:"email" validate:"required,email"`
+	InviteCode string `json:"invite_code,omitempty"`
+}
+
+type ConsumeMagicLinkRequest struct {
+	Token      string `json:"token" validate:"required"`
+	InviteCode string `json:"invite_code,omitempty"` // Needed to register when the email has no account yet
+}
@@ -0,0 +1,29 @@
+package auth_models
+
+// TOTPSettings is a user's two-factor authentication state
+type TOTPSettings struct {
+	Secret       *string `json:"-" db:"totp_secret"`
+	Enabled      bool    `json:"enabled" db:"totp_enabled"`
+	LastUsedStep *int64  `json:"-" db:"totp_last_used_step"`
+}
+
+type TOTPEnrollResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURL string `json:"otpauth_url"`
+}
+
+type TOTPCodeRequest struct {
+	Code string `json:"code" validate:"required"`
+}
+
+// MFACodeRequest accepts either a code from the authenticator app or a recovery code
+type MFACodeRequest struct {
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+type MFAVerifyRequest struct {
+	MFAToken     string `json:"mfa_token" validate:"required"`
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+}
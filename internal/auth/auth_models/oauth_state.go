@@ -0,0 +1,18 @@
+package auth_models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type OAuthState struct {
+	State        string     `json:"state" db:"state"`
+	Provider     string     `json:"provider" db:"provider"`
+	CodeVerifier string     `json:"-" db:"code_verifier"`
+	Nonce        string     `json:"-" db:"nonce"`
+	LinkUserID   *uuid.UUID `json:"link_user_id,omitempty" db:"link_user_id"` // Set when a signed in user is linking this provider
+	InviteCode   string     `json:"-" db:"invite_code"`                       // Invite to register with, if the login creates an account
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+	ExpiresAt    time.Time  `json:"expires_at" db:"expires_at"`
+}
@@ -0,0 +1,16 @@
+package auth_models
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword     string `json:"current_password" validate:"required"`
+	NewPassword         string `json:"new_password" validate:"required"`
+	RevokeOtherSessions bool   `json:"revoke_other_sessions"`
+}
@@ -0,0 +1,32 @@
+package auth_models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type PersonalAccessToken struct {
+	ID          uuid.UUID  `json:"id" db:"id"`
+	UserID      uuid.UUID  `json:"-" db:"user_id"`
+	Name        string     `json:"name" db:"name"`
+	TokenHash   string     `json:"-" db:"token_hash"`
+	TokenPrefix string     `json:"token_prefix" db:"token_prefix"`
+	Scopes      []string   `json:"scopes" db:"scopes"`
+	MFAVerified bool       `json:"-" db:"mfa_verified"` // Created from a session that passed two-factor authentication
+	ExpiresAt   time.Time  `json:"expires_at" db:"expires_at"`
+	LastUsedAt  *time.Time `json:"last_used_at" db:"last_used_at"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+}
+
+type CreatePersonalAccessTokenRequest struct {
+	Name          string   `json:"name" validate:"required,max=100"`
+	Scopes        []string `json:"scopes"`
+	ExpiresInDays int      `json:"expires_in_days,omitempty" validate:"omitempty,min=1,max=365"`
+}
+
+// CreatePersonalAccessTokenResponse is the only time the token itself is returned
+type CreatePersonalAccessTokenResponse struct {
+	Token string `json:"token"`
+	*PersonalAccessToken
+}
@@ -7,18 +7,23 @@ import (
 )
 
 type RefreshToken struct {
-	ID        int       `json:"id" db:"id"`
-	UserID    uuid.UUID `json:"user_id" db:"user_id"`
-	Token     string    `json:"token" db:"token"`
-	IssuedAt  time.Time `json:"issued_at" db:"issued_at"`
-	ExpiresAt time.Time `json:"expires_at" db:"expires_at"`
-	IPAddress string    `json:"ip_address,omitempty" db:"ip_address"`
-	UserAgent string    `json:"user_agent,omitempty" db:"user_agent"`
+	ID        int        `json:"id" db:"id"`
+	UserID    uuid.UUID  `json:"user_id" db:"user_id"`
+	TokenHash string     `json:"-" db:"token_hash"`
+	FamilyID  uuid.UUID  `json:"family_id" db:"family_id"`
+	ParentID  *int       `json:"parent_id,omitempty" db:"parent_id"`
+	IssuedAt  time.Time  `json:"issued_at" db:"issued_at"`
+	ExpiresAt time.Time  `json:"expires_at" db:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+	IPAddress string     `json:"ip_address,omitempty" db:"ip_address"`
+	UserAgent string     `json:"user_agent,omitempty" db:"user_agent"`
 }
 
 type CreateSessionRequest struct {
 	UserID    uuid.UUID `json:"user_id" db:"user_id"`
 	Token     string    `json:"token" db:"token"`
+	FamilyID  uuid.UUID `json:"family_id" db:"family_id"`
+	ParentID  *int      `json:"parent_id,omitempty" db:"parent_id"`
 	IssuedAt  time.Time `json:"issued_at" db:"issued_at"`
 	ExpiresAt time.Time `json:"expires_at" db:"expires_at"`
 	IPAddress string    `json:"ip_address,omitempty" db:"ip_address"`
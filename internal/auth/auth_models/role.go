@@ -0,0 +1,11 @@
+package auth_models
+
+type Role struct {
+	Name        string   `json:"name" db:"name"`
+	Description string   `json:"description" db:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+type GrantRoleRequest struct {
+	Role string `json:"role" validate:"required"`
+}
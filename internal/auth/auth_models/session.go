@@ -0,0 +1,25 @@
+package auth_models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Session is a login on one device, represented by the active refresh token of a token family
+type Session struct {
+	ID         uuid.UUID  `json:"id"`
+	IPAddress  string     `json:"ip_address,omitempty"`
+	UserAgent  string     `json:"user_agent,omitempty"`
+	Device     DeviceInfo `json:"device"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt time.Time  `json:"last_used_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	Current    bool       `json:"current"`
+}
+
+type DeviceInfo struct {
+	Browser string `json:"browser"`
+	OS      string `json:"os"`
+	Type    string `json:"type"`
+}
@@ -7,16 +7,19 @@ import (
 )
 
 type CreateUserTraditionalAuthRequest struct {
-	Email    *string `json:"email" validate:"required,email"`
-	Password *string `json:"password,omitempty" validate:"required"`
+	Email      *string `json:"email" validate:"required,email"`
+	Password   *string `json:"password,omitempty" validate:"required"`
+	InviteCode *string `json:"invite_code,omitempty"`
 }
 
 type CreateUserOAuthRequest struct {
-	Email    *string `json:"email,omitempty"`
-	Provider *string `json:"provider,omitempty"`
-	AuthID   *string `json:"auth_id,omitempty"`
-	Image    *string `json:"image,omitempty"`
-	Name     *string `json:"name,omitempty"`
+	Email         *string `json:"email,omitempty"`
+	EmailVerified bool    `json:"email_verified,omitempty"`
+	Provider      *string `json:"provider,omitempty"`
+	AuthID        *string `json:"auth_id,omitempty"`
+	Image         *string `json:"image,omitempty"`
+	Name          *string `json:"name,omitempty"`
+	InviteCode    *string `json:"invite_code,omitempty"`
 }
 
 type LoginRequest struct {
@@ -0,0 +1,93 @@
+package auth_repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_models"
+)
+
+type AuditLogRepository struct {
+	db *sql.DB
+}
+
+func NewAuditLogRepository(db *sql.DB) *AuditLogRepository {
+	return &AuditLogRepository{db: db}
+}
+
+func (r *AuditLogRepository) Create(entry *auth_models.AuditLog) error {
+	query := `
+		INSERT INTO audit_logs (actor_id, action, target_type, target_id, details, ip_address)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`
+	return r.db.QueryRow(query,
+		entry.ActorID,
+		entry.Action,
+		entry.TargetType,
+		entry.TargetID,
+		[]byte(entry.Details),
+		entry.IPAddress,
+	).Scan(&entry.ID, &entry.CreatedAt)
+}
+
+// List returns matching entries, newest first
+func (r *AuditLogRepository) List(filter auth_models.AuditLogFilter) ([]*auth_models.AuditLog, error) {
+	var conditions []string
+	var args []interface{}
+
+	addCondition := func(column string, value string) {
+		if value != "" {
+			args = append(args, value)
+			conditions = append(conditions, fmt.Sprintf("%s = $%d", column, len(args)))
+		}
+	}
+	addCondition("action", filter.Action)
+	addCondition("target_type", filter.TargetType)
+	addCondition("target_id", filter.TargetID)
+	addCondition("actor_id::text", filter.ActorID)
+
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	args = append(args, filter.Limit, filter.Offset)
+	query := fmt.Sprintf(`
+		SELECT id, actor_id, action, target_type, target_id, details, ip_address, created_at
+		FROM audit_logs
+		%s
+		ORDER BY created_at DESC, id DESC
+		LIMIT $%d OFFSET $%d
+	`, where, len(args)-1, len(args))
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []*auth_models.AuditLog{}
+	for rows.Next() {
+		entry := &auth_models.AuditLog{}
+		var details []byte
+		err := rows.Scan(
+			&entry.ID,
+			&entry.ActorID,
+			&entry.Action,
+			&entry.TargetType,
+			&entry.TargetID,
+			&details,
+			&entry.IPAddress,
+			&entry.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		entry.Details = details
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
@@ -0,0 +1,47 @@
+package auth_repositories
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type EmailChangeRepository struct {
+	db *sql.DB
+}
+
+func NewEmailChangeRepository(db *sql.DB) *EmailChangeRepository {
+	return &EmailChangeRepository{db: db}
+}
+
+// SetPending records the ID of the email change link just sent to the user, replacing
+// any earlier one
+func (r *EmailChangeRepository) SetPending(userID uuid.UUID, jti uuid.UUID, expiresAt time.Time) error {
+	query := `
+		INSERT INTO pending_email_changes (user_id, jti, expires_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO UPDATE SET jti = EXCLUDED.jti, expires_at = EXCLUDED.expires_at
+	`
+	_, err := r.db.Exec(query, userID, jti, expiresAt)
+	return err
+}
+
+// ClearPending removes the user's pending change if it's the one with the given ID.
+// Returns false if the user has since asked for another change, or none at all.
+func (r *EmailChangeRepository) ClearPending(userID uuid.UUID, jti uuid.UUID) (bool, error) {
+	query := `
+		DELETE FROM pending_email_changes WHERE user_id = $1 AND jti = $2
+	`
+	result, err := r.db.Exec(query, userID, jti)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rowsAffected > 0, nil
+}
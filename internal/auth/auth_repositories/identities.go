@@ -0,0 +1,128 @@
+package auth_repositories
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_models"
+	"github.com/google/uuid"
+)
+
+type IdentityRepository struct {
+	db *sql.DB
+}
+
+func NewIdentityRepository(db *sql.DB) *IdentityRepository {
+	return &IdentityRepository{db: db}
+}
+
+const createIdentityQuery = `
+	INSERT INTO user_identities (
+		id, user_id, provider, provider_user_id, email, email_verified, created_at, last_used_at
+	) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+`
+
+func createIdentityArgs(identity *auth_models.UserIdentity) []interface{} {
+	return []interface{}{
+		identity.ID,
+		identity.UserID,
+		identity.Provider,
+		identity.ProviderUserID,
+		identity.Email,
+		identity.EmailVerified,
+		identity.CreatedAt,
+		identity.LastUsedAt,
+	}
+}
+
+func (r *IdentityRepository) Create(identity *auth_models.UserIdentity) error {
+	_, err := r.db.Exec(createIdentityQuery, createIdentityArgs(identity)...)
+	return err
+}
+
+func (r *IdentityRepository) GetByProviderUserID(provider string, providerUserID string) (*auth_models.UserIdentity, error) {
+	identity := &auth_models.UserIdentity{}
+	query := `
+		SELECT id, user_id, provider, provider_user_id, email, email_verified, created_at, last_used_at
+		FROM user_identities
+		WHERE provider = $1 AND provider_user_id = $2
+	`
+	err := r.db.QueryRow(query, provider, providerUserID).Scan(
+		&identity.ID,
+		&identity.UserID,
+		&identity.Provider,
+		&identity.ProviderUserID,
+		&identity.Email,
+		&identity.EmailVerified,
+		&identity.CreatedAt,
+		&identity.LastUsedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return identity, nil
+}
+
+func (r *IdentityRepository) GetByUserID(userID uuid.UUID) ([]*auth_models.UserIdentity, error) {
+	query := `
+		SELECT id, user_id, provider, provider_user_id, email, email_verified, created_at, last_used_at
+		FROM user_identities
+		WHERE user_id = $1
+		ORDER BY created_at
+	`
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	identities := []*auth_models.UserIdentity{}
+	for rows.Next() {
+		identity := &auth_models.UserIdentity{}
+		err := rows.Scan(
+			&identity.ID,
+			&identity.UserID,
+			&identity.Provider,
+			&identity.ProviderUserID,
+			&identity.Email,
+			&identity.EmailVerified,
+			&identity.CreatedAt,
+			&identity.LastUsedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		identities = append(identities, identity)
+	}
+
+	return identities, rows.Err()
+}
+
+// UpdateLastUsed records a login with the identity and refreshes the email the provider reports
+func (r *IdentityRepository) UpdateLastUsed(id uuid.UUID, email *string, emailVerified bool) error {
+	query := `
+		UPDATE user_identities SET last_used_at = $1, email = $2, email_verified = $3 WHERE id = $4
+	`
+	_, err := r.db.Exec(query, time.Now(), email, emailVerified, id)
+	return err
+}
+
+// DeleteUserIdentity unlinks an identity, scoped to its owner.
+// Returns false if the identity doesn't exist or belongs to another user.
+func (r *IdentityRepository) DeleteUserIdentity(userID uuid.UUID, id uuid.UUID) (bool, error) {
+	query := `
+		DELETE FROM user_identities WHERE id = $1 AND user_id = $2
+	`
+	result, err := r.db.Exec(query, id, userID)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rowsAffected > 0, nil
+}
@@ -0,0 +1,108 @@
+package auth_repositories
+
+import (
+	"time"
+
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_models"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/models"
+	"github.com/google/uuid"
+)
+
+// RefreshTokenStore is the subset of RefreshTokenRepository used to rotate, list and revoke refresh tokens
+type RefreshTokenStore interface {
+	Create(refresh_token *auth_models.CreateSessionRequest) error
+	GetByToken(cookieToken string) (*auth_models.RefreshToken, error)
+	GetActiveSessionsByUserID(userID uuid.UUID) ([]*auth_models.Session, error)
+	Retire(id int) (bool, error)
+	DeleteByFamilyID(familyID uuid.UUID) error
+	DeleteAllByUserID(userID string) error
+}
+
+// OAuthStateStore is the subset of OAuthStateRepository used to start and finish OAuth logins
+type OAuthStateStore interface {
+	Create(oauthState *auth_models.OAuthState) error
+	Consume(state string, provider string) (*auth_models.OAuthState, error)
+	DeleteExpired() error
+}
+
+// UserStore is the subset of UserRepository used to sign users in and register them
+type UserStore interface {
+	Create(user *models.User) error
+	Update(userID string, req auth_models.UpdateUserRequest) error
+	GetByID(id uuid.UUID) (*models.User, error)
+	GetByEmail(email string) (*models.User, error)
+	EmailExists(email string) (bool, error)
+	GetStatus(userID uuid.UUID) (*auth_models.AccountStatus, error)
+	ClaimUnverifiedAccount(userID uuid.UUID) error
+}
+
+// PermissionStore is the subset of RBACRepository used to check and grant permissions
+type PermissionStore interface {
+	HasPermissions(userID uuid.UUID, permissions []string) (bool, error)
+	RoleExists(role string) (bool, error)
+	GrantRole(userID uuid.UUID, role string, grantedBy uuid.UUID) error
+	GetPermissionsByUserID(userID uuid.UUID) ([]string, error)
+	GetRolesByUserID(userID uuid.UUID) ([]string, error)
+}
+
+// IdentityStore is the subset of IdentityRepository used to match OAuth logins to users
+type IdentityStore interface {
+	Create(identity *auth_models.UserIdentity) error
+	GetByProviderUserID(provider string, providerUserID string) (*auth_models.UserIdentity, error)
+	GetByUserID(userID uuid.UUID) ([]*auth_models.UserIdentity, error)
+	UpdateLastUsed(id uuid.UUID, email *string, emailVerified bool) error
+}
+
+// PersonalAccessTokenStore is the subset of PersonalAccessTokenRepository used to issue and check tokens
+type PersonalAccessTokenStore interface {
+	Create(token *auth_models.PersonalAccessToken) error
+	GetByHash(tokenHash string) (*auth_models.PersonalAccessToken, error)
+	CountByUserID(userID uuid.UUID) (int, error)
+	UpdateLastUsed(id uuid.UUID) error
+	DeleteAllByUserID(userID uuid.UUID) error
+}
+
+// LoginThrottleStore is the subset of LoginThrottleRepository used to count failed logins
+type LoginThrottleStore interface {
+	Get(scope string, key string) (*auth_models.LoginThrottle, error)
+	RecordFailure(scope string, key string, window time.Duration) (*auth_models.LoginThrottle, error)
+	Lock(scope string, key string, until time.Time) error
+}
+
+// AuditLogStore is the subset of AuditLogRepository used to record and look up actions
+type AuditLogStore interface {
+	Create(entry *auth_models.AuditLog) error
+	List(filter auth_models.AuditLogFilter) ([]*auth_models.AuditLog, error)
+}
+
+// UsedTokenStore is the subset of UsedTokenRepository used to make purpose tokens single-use
+type UsedTokenStore interface {
+	MarkUsed(jti uuid.UUID, purpose string, userID *uuid.UUID, expiresAt time.Time) (bool, error)
+	DeleteExpired() error
+}
+
+// InviteStore is the subset of InviteRepository used to redeem invites when registering
+type InviteStore interface {
+	GetByCodeHash(codeHash string) (*auth_models.Invite, error)
+	Redeem(id uuid.UUID) error
+	Release(id uuid.UUID) error
+}
+
+// AccountDeletionStore is the subset of UserRepository used to schedule, cancel and carry out account deletions
+type AccountDeletionStore interface {
+	GetDeletionScheduledAt(userID uuid.UUID) (*time.Time, error)
+	ScheduleDeletion(userID uuid.UUID, at time.Time) error
+	CancelDeletion(userID uuid.UUID) (bool, error)
+	GetDueForDeletion(now time.Time) ([]uuid.UUID, error)
+	Purge(userID uuid.UUID) error
+}
+
+// CommentStore is the subset of repositories.CommentRepository used to export a user's comments
+type CommentStore interface {
+	GetCommentsByUserId(userId uuid.UUID) ([]*models.Comment, error)
+}
+
+// OrganizedEventStore is the subset of repositories.EventOrganizerRepository used to export the events a user organizes
+type OrganizedEventStore interface {
+	GetEventsByUserID(userID uuid.UUID) ([]models.Event, error)
+}
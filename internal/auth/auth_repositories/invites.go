@@ -0,0 +1,111 @@
+package auth_repositories
+
+import (
+	"database/sql"
+
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_models"
+	"github.com/google/uuid"
+)
+
+type InviteRepository struct {
+	db *sql.DB
+}
+
+func NewInviteRepository(db *sql.DB) *InviteRepository {
+	return &InviteRepository{db: db}
+}
+
+const inviteColumns = `id, code_hash, email, position, branch, max_uses, uses, expires_at, created_by, created_at`
+
+func scanInvite(row interface{ Scan(...any) error }) (*auth_models.Invite, error) {
+	invite := &auth_models.Invite{}
+	err := row.Scan(
+		&invite.ID,
+		&invite.CodeHash,
+		&invite.Email,
+		&invite.Position,
+		&invite.Branch,
+		&invite.MaxUses,
+		&invite.Uses,
+		&invite.ExpiresAt,
+		&invite.CreatedBy,
+		&invite.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return invite, nil
+}
+
+func (r *InviteRepository) Create(invite *auth_models.Invite) error {
+	query := `
+		INSERT INTO invites (` + inviteColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`
+	_, err := r.db.Exec(query,
+		invite.ID,
+		invite.CodeHash,
+		invite.Email,
+		invite.Position,
+		invite.Branch,
+		invite.MaxUses,
+		invite.Uses,
+		invite.ExpiresAt,
+		invite.CreatedBy,
+		invite.CreatedAt,
+	)
+	return err
+}
+
+func (r *InviteRepository) GetByCodeHash(codeHash string) (*auth_models.Invite, error) {
+	query := `SELECT ` + inviteColumns + ` FROM invites WHERE code_hash = $1`
+	return scanInvite(r.db.QueryRow(query, codeHash))
+}
+
+func (r *InviteRepository) GetAll() ([]*auth_models.Invite, error) {
+	rows, err := r.db.Query(`SELECT ` + inviteColumns + ` FROM invites ORDER BY created_at DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	invites := []*auth_models.Invite{}
+	for rows.Next() {
+		invite, err := scanInvite(rows)
+		if err != nil {
+			return nil, err
+		}
+		invites = append(invites, invite)
+	}
+
+	return invites, rows.Err()
+}
+
+// Redeem uses up one use of an unexpired invite. Returns sql.ErrNoRows if the
+// invite has expired or has no uses left, so two sign ups can't race for the last use.
+func (r *InviteRepository) Redeem(id uuid.UUID) error {
+	query := `
+		UPDATE invites SET uses = uses + 1
+		WHERE id = $1 AND uses < max_uses AND expires_at > NOW()
+		RETURNING id
+	`
+	return r.db.QueryRow(query, id).Scan(&id)
+}
+
+// Release gives back a use taken by Redeem when the sign up fails afterwards
+func (r *InviteRepository) Release(id uuid.UUID) error {
+	_, err := r.db.Exec(`UPDATE invites SET uses = uses - 1 WHERE id = $1 AND uses > 0`, id)
+	return err
+}
+
+func (r *InviteRepository) Delete(id uuid.UUID) (bool, error) {
+	result, err := r.db.Exec(`DELETE FROM invites WHERE id = $1`, id)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
@@ -0,0 +1,117 @@
+package auth_repositories
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_models"
+)
+
+type LoginThrottleRepository struct {
+	db *sql.DB
+}
+
+func NewLoginThrottleRepository(db *sql.DB) *LoginThrottleRepository {
+	return &LoginThrottleRepository{db: db}
+}
+
+func (r *LoginThrottleRepository) Get(scope string, key string) (*auth_models.LoginThrottle, error) {
+	throttle := &auth_models.LoginThrottle{}
+	query := `
+		SELECT scope, key, failures, last_failure_at, locked_until
+		FROM login_throttles
+		WHERE scope = $1 AND key = $2
+	`
+	err := r.db.QueryRow(query, scope, key).Scan(
+		&throttle.Scope,
+		&throttle.Key,
+		&throttle.Failures,
+		&throttle.LastFailureAt,
+		&throttle.LockedUntil,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return throttle, nil
+}
+
+// RecordFailure counts a failed attempt and returns the updated row. The count starts
+// over if the previous failure is older than window.
+func (r *LoginThrottleRepository) RecordFailure(scope string, key string, window time.Duration) (*auth_models.LoginThrottle, error) {
+	throttle := &auth_models.LoginThrottle{}
+	query := `
+		INSERT INTO login_throttles (scope, key, failures, last_failure_at)
+		VALUES ($1, $2, 1, NOW())
+		ON CONFLICT (scope, key) DO UPDATE SET
+			failures = CASE
+				WHEN login_throttles.last_failure_at < NOW() - make_interval(secs => $3) THEN 1
+				ELSE login_throttles.failures + 1
+			END,
+			last_failure_at = NOW()
+		RETURNING scope, key, failures, last_failure_at, locked_until
+	`
+	err := r.db.QueryRow(query, scope, key, window.Seconds()).Scan(
+		&throttle.Scope,
+		&throttle.Key,
+		&throttle.Failures,
+		&throttle.LastFailureAt,
+		&throttle.LockedUntil,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return throttle, nil
+}
+
+func (r *LoginThrottleRepository) Lock(scope string, key string, until time.Time) error {
+	_, err := r.db.Exec(`UPDATE login_throttles SET locked_until = $3 WHERE scope = $1 AND key = $2`, scope, key, until)
+	return err
+}
+
+// Delete clears the failures and any lockout, reporting whether there was anything to clear
+func (r *LoginThrottleRepository) Delete(scope string, key string) (bool, error) {
+	result, err := r.db.Exec(`DELETE FROM login_throttles WHERE scope = $1 AND key = $2`, scope, key)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+// GetLocked returns every row that is currently locked out
+func (r *LoginThrottleRepository) GetLocked() ([]*auth_models.LoginThrottle, error) {
+	query := `
+		SELECT scope, key, failures, last_failure_at, locked_until
+		FROM login_throttles
+		WHERE locked_until > NOW()
+		ORDER BY locked_until DESC
+	`
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	throttles := []*auth_models.LoginThrottle{}
+	for rows.Next() {
+		throttle := &auth_models.LoginThrottle{}
+		err := rows.Scan(
+			&throttle.Scope,
+			&throttle.Key,
+			&throttle.Failures,
+			&throttle.LastFailureAt,
+			&throttle.LockedUntil,
+		)
+		if err != nil {
+			return nil, err
+		}
+		throttles = append(throttles, throttle)
+	}
+
+	return throttles, rows.Err()
+}
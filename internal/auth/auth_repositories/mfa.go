@@ -0,0 +1,179 @@
+package auth_repositories
+
+import (
+	"database/sql"
+
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_models"
+	"github.com/google/uuid"
+)
+
+type MFARepository struct {
+	db *sql.DB
+}
+
+func NewMFARepository(db *sql.DB) *MFARepository {
+	return &MFARepository{db: db}
+}
+
+func (r *MFARepository) GetTOTP(userID uuid.UUID) (*auth_models.TOTPSettings, error) {
+	settings := &auth_models.TOTPSettings{}
+	query := `
+		SELECT totp_secret, totp_enabled, totp_last_used_step FROM users WHERE id = $1
+	`
+	err := r.db.QueryRow(query, userID).Scan(
+		&settings.Secret,
+		&settings.Enabled,
+		&settings.LastUsedStep,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return settings, nil
+}
+
+// SetPendingTOTPSecret stores a new secret for a user who hasn't enabled TOTP yet.
+// Returns false if TOTP is already enabled.
+func (r *MFARepository) SetPendingTOTPSecret(userID uuid.UUID, secret string) (bool, error) {
+	query := `
+		UPDATE users SET totp_secret = $1, totp_last_used_step = NULL
+		WHERE id = $2 AND totp_enabled = FALSE
+	`
+	result, err := r.db.Exec(query, secret, userID)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rowsAffected == 1, nil
+}
+
+// EnableTOTP turns on TOTP for the user and stores their recovery codes
+func (r *MFARepository) EnableTOTP(userID uuid.UUID, recoveryCodeHashes []string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE users SET totp_enabled = TRUE WHERE id = $1 AND totp_secret IS NOT NULL
+	`
+	result, err := tx.Exec(query, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	if err := replaceRecoveryCodes(tx, userID, recoveryCodeHashes); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// DisableTOTP removes the user's secret and recovery codes
+func (r *MFARepository) DisableTOTP(userID uuid.UUID) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE users SET totp_secret = NULL, totp_enabled = FALSE, totp_last_used_step = NULL
+		WHERE id = $1
+	`
+	if _, err := tx.Exec(query, userID); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM mfa_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// UseTOTPStep records the time step of an accepted code.
+// Returns false if a code from this step or a later one was already used.
+func (r *MFARepository) UseTOTPStep(userID uuid.UUID, step int64) (bool, error) {
+	query := `
+		UPDATE users SET totp_last_used_step = $1
+		WHERE id = $2 AND (totp_last_used_step IS NULL OR totp_last_used_step < $1)
+	`
+	result, err := r.db.Exec(query, step, userID)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rowsAffected == 1, nil
+}
+
+// ReplaceRecoveryCodes invalidates the user's recovery codes and stores new ones
+func (r *MFARepository) ReplaceRecoveryCodes(userID uuid.UUID, recoveryCodeHashes []string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := replaceRecoveryCodes(tx, userID, recoveryCodeHashes); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// UseRecoveryCode marks an unused recovery code as used.
+// Returns false if the code doesn't exist or was already used.
+func (r *MFARepository) UseRecoveryCode(userID uuid.UUID, codeHash string) (bool, error) {
+	query := `
+		UPDATE mfa_recovery_codes SET used_at = NOW()
+		WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL
+	`
+	result, err := r.db.Exec(query, userID, codeHash)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rowsAffected > 0, nil
+}
+
+func replaceRecoveryCodes(tx *sql.Tx, userID uuid.UUID, recoveryCodeHashes []string) error {
+	if _, err := tx.Exec(`DELETE FROM mfa_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+
+	for _, codeHash := range recoveryCodeHashes {
+		query := `
+			INSERT INTO mfa_recovery_codes (user_id, code_hash) VALUES ($1, $2)
+		`
+		if _, err := tx.Exec(query, userID, codeHash); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
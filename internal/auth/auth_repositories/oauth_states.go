@@ -0,0 +1,68 @@
+package auth_repositories
+
+import (
+	"database/sql"
+
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_models"
+)
+
+type OAuthStateRepository struct {
+	db *sql.DB
+}
+
+func NewOAuthStateRepository(db *sql.DB) *OAuthStateRepository {
+	return &OAuthStateRepository{db: db}
+}
+
+func (r *OAuthStateRepository) Create(oauthState *auth_models.OAuthState) error {
+	query := `
+		INSERT INTO oauth_states (
+			state, provider, code_verifier, nonce, link_user_id, invite_code, created_at, expires_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+	_, err := r.db.Exec(query,
+		oauthState.State,
+		oauthState.Provider,
+		oauthState.CodeVerifier,
+		oauthState.Nonce,
+		oauthState.LinkUserID,
+		oauthState.InviteCode,
+		oauthState.CreatedAt,
+		oauthState.ExpiresAt,
+	)
+	return err
+}
+
+// Consume deletes the state row and returns it, so the same state can never be used twice.
+// Returns sql.ErrNoRows if the state doesn't exist for the provider or was already used.
+func (r *OAuthStateRepository) Consume(state string, provider string) (*auth_models.OAuthState, error) {
+	oauthState := &auth_models.OAuthState{}
+	query := `
+		DELETE FROM oauth_states
+		WHERE state = $1 AND provider = $2
+		RETURNING state, provider, code_verifier, nonce, link_user_id, invite_code, created_at, expires_at
+	`
+	err := r.db.QueryRow(query, state, provider).Scan(
+		&oauthState.State,
+		&oauthState.Provider,
+		&oauthState.CodeVerifier,
+		&oauthState.Nonce,
+		&oauthState.LinkUserID,
+		&oauthState.InviteCode,
+		&oauthState.CreatedAt,
+		&oauthState.ExpiresAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return oauthState, nil
+}
+
+func (r *OAuthStateRepository) DeleteExpired() error {
+	query := `
+		DELETE FROM oauth_states WHERE expires_at < NOW()
+	`
+	_, err := r.db.Exec(query)
+	return err
+}
@@ -0,0 +1,136 @@
+package auth_repositories
+
+import (
+	"database/sql"
+
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_models"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type PersonalAccessTokenRepository struct {
+	db *sql.DB
+}
+
+func NewPersonalAccessTokenRepository(db *sql.DB) *PersonalAccessTokenRepository {
+	return &PersonalAccessTokenRepository{db: db}
+}
+
+func (r *PersonalAccessTokenRepository) Create(token *auth_models.PersonalAccessToken) error {
+	query := `
+		INSERT INTO personal_access_tokens (
+			id, user_id, name, token_hash, token_prefix, scopes, mfa_verified, expires_at, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+	_, err := r.db.Exec(query,
+		token.ID,
+		token.UserID,
+		token.Name,
+		token.TokenHash,
+		token.TokenPrefix,
+		pq.Array(token.Scopes),
+		token.MFAVerified,
+		token.ExpiresAt,
+		token.CreatedAt,
+	)
+	return err
+}
+
+func (r *PersonalAccessTokenRepository) GetByHash(tokenHash string) (*auth_models.PersonalAccessToken, error) {
+	token := &auth_models.PersonalAccessToken{}
+	query := `
+		SELECT id, user_id, name, token_hash, token_prefix, scopes, mfa_verified, expires_at, last_used_at, created_at
+		FROM personal_access_tokens
+		WHERE token_hash = $1
+	`
+	err := r.db.QueryRow(query, tokenHash).Scan(
+		&token.ID,
+		&token.UserID,
+		&token.Name,
+		&token.TokenHash,
+		&token.TokenPrefix,
+		pq.Array(&token.Scopes),
+		&token.MFAVerified,
+		&token.ExpiresAt,
+		&token.LastUsedAt,
+		&token.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return token, nil
+}
+
+func (r *PersonalAccessTokenRepository) GetByUserID(userID uuid.UUID) ([]*auth_models.PersonalAccessToken, error) {
+	query := `
+		SELECT id, user_id, name, token_hash, token_prefix, scopes, mfa_verified, expires_at, last_used_at, created_at
+		FROM personal_access_tokens
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+	`
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tokens := []*auth_models.PersonalAccessToken{}
+	for rows.Next() {
+		token := &auth_models.PersonalAccessToken{}
+		err := rows.Scan(
+			&token.ID,
+			&token.UserID,
+			&token.Name,
+			&token.TokenHash,
+			&token.TokenPrefix,
+			pq.Array(&token.Scopes),
+			&token.MFAVerified,
+			&token.ExpiresAt,
+			&token.LastUsedAt,
+			&token.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, token)
+	}
+
+	return tokens, rows.Err()
+}
+
+func (r *PersonalAccessTokenRepository) CountByUserID(userID uuid.UUID) (int, error) {
+	var count int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM personal_access_tokens WHERE user_id = $1`, userID).Scan(&count)
+	return count, err
+}
+
+// UpdateLastUsed records a use of the token. Writes are skipped if the token was
+// already used in the last minute so busy scripts don't update the row on every request.
+func (r *PersonalAccessTokenRepository) UpdateLastUsed(id uuid.UUID) error {
+	query := `
+		UPDATE personal_access_tokens SET last_used_at = NOW()
+		WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < NOW() - INTERVAL '1 minute')
+	`
+	_, err := r.db.Exec(query, id)
+	return err
+}
+
+// Delete removes one of the user's tokens and reports whether it existed
+func (r *PersonalAccessTokenRepository) Delete(id uuid.UUID, userID uuid.UUID) (bool, error) {
+	result, err := r.db.Exec(`DELETE FROM personal_access_tokens WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+// DeleteAllByUserID revokes every token of the user
+func (r *PersonalAccessTokenRepository) DeleteAllByUserID(userID uuid.UUID) error {
+	_, err := r.db.Exec(`DELETE FROM personal_access_tokens WHERE user_id = $1`, userID)
+	return err
+}
@@ -0,0 +1,135 @@
+package auth_repositories
+
+import (
+	"database/sql"
+
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_models"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type RBACRepository struct {
+	db *sql.DB
+}
+
+func NewRBACRepository(db *sql.DB) *RBACRepository {
+	return &RBACRepository{db: db}
+}
+
+// userRolesQuery selects every role a user holds: their primary role and any granted roles
+const userRolesQuery = `
+	SELECT role::text FROM users WHERE id = $1 AND role IS NOT NULL
+	UNION
+	SELECT role FROM user_roles WHERE user_id = $1
+`
+
+// HasPermissions reports whether the user holds every one of the permissions
+func (r *RBACRepository) HasPermissions(userID uuid.UUID, permissions []string) (bool, error) {
+	var count int
+	query := `
+		SELECT COUNT(DISTINCT permission) FROM role_permissions
+		WHERE permission = ANY($2) AND role IN (` + userRolesQuery + `)
+	`
+	err := r.db.QueryRow(query, userID, pq.Array(permissions)).Scan(&count)
+	if err != nil {
+		return false, err
+	}
+
+	return count == len(permissions), nil
+}
+
+func (r *RBACRepository) GetPermissionsByUserID(userID uuid.UUID) ([]string, error) {
+	query := `
+		SELECT DISTINCT permission FROM role_permissions
+		WHERE role IN (` + userRolesQuery + `)
+		ORDER BY permission
+	`
+	return r.queryStrings(query, userID)
+}
+
+func (r *RBACRepository) GetRolesByUserID(userID uuid.UUID) ([]string, error) {
+	return r.queryStrings(userRolesQuery+` ORDER BY 1`, userID)
+}
+
+func (r *RBACRepository) GetRoles() ([]*auth_models.Role, error) {
+	query := `
+		SELECT r.name, r.description,
+			COALESCE(array_agg(rp.permission ORDER BY rp.permission) FILTER (WHERE rp.permission IS NOT NULL), '{}')
+		FROM roles r
+		LEFT JOIN role_permissions rp ON rp.role = r.name
+		GROUP BY r.name, r.description
+		ORDER BY r.name
+	`
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	roles := []*auth_models.Role{}
+	for rows.Next() {
+		role := &auth_models.Role{}
+		var permissions pq.StringArray
+		if err := rows.Scan(&role.Name, &role.Description, &permissions); err != nil {
+			return nil, err
+		}
+		role.Permissions = permissions
+		roles = append(roles, role)
+	}
+
+	return roles, rows.Err()
+}
+
+func (r *RBACRepository) RoleExists(role string) (bool, error) {
+	var exists bool
+	query := `SELECT EXISTS(SELECT 1 FROM roles WHERE name = $1)`
+	err := r.db.QueryRow(query, role).Scan(&exists)
+	return exists, err
+}
+
+// GrantRole gives the user an additional role. Granting a role the user already has is a no-op.
+func (r *RBACRepository) GrantRole(userID uuid.UUID, role string, grantedBy uuid.UUID) error {
+	query := `
+		INSERT INTO user_roles (user_id, role, granted_by) VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, role) DO NOTHING
+	`
+	_, err := r.db.Exec(query, userID, role, grantedBy)
+	return err
+}
+
+// RevokeRole removes an additional role. Returns false if the user didn't have it.
+func (r *RBACRepository) RevokeRole(userID uuid.UUID, role string) (bool, error) {
+	query := `
+		DELETE FROM user_roles WHERE user_id = $1 AND role = $2
+	`
+	result, err := r.db.Exec(query, userID, role)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rowsAffected > 0, nil
+}
+
+func (r *RBACRepository) queryStrings(query string, args ...interface{}) ([]string, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	values := []string{}
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+
+	return values, rows.Err()
+}
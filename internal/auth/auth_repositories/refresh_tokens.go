@@ -4,6 +4,7 @@ import (
 	"database/sql"
 
 	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_models"
+	"github.com/google/uuid"
 )
 
 type RefreshTokenRepository struct {
@@ -17,12 +18,14 @@ func NewRefreshTokenRepository(db *sql.DB) *RefreshTokenRepository {
 func (r *RefreshTokenRepository) Create(refresh_token *auth_models.CreateSessionRequest) error {
 	query := `
 	    INSERT INTO refresh_tokens(
-		user_id, token, issued_at, expires_at, ip_address, user_agent
-		) VALUES ($1, $2, $3, $4, $5, $6)
+		user_id, token_hash, family_id, parent_id, issued_at, expires_at, ip_address, user_agent
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 	`
 	_, err := r.db.Exec(query,
 		refresh_token.UserID,
-		refresh_token.Token,
+		HashToken(refresh_token.Token),
+		refresh_token.FamilyID,
+		refresh_token.ParentID,
 		refresh_token.IssuedAt,
 		refresh_token.ExpiresAt,
 		refresh_token.IPAddress,
@@ -34,12 +37,17 @@ func (r *RefreshTokenRepository) Create(refresh_token *auth_models.CreateSession
 func (r *RefreshTokenRepository) GetByToken(cookieToken string) (*auth_models.RefreshToken, error) {
 	refreshToken := &auth_models.RefreshToken{}
 	query := `
-	    SELECT token, user_id, expires_at FROM refresh_tokens WHERE token = $1
+	    SELECT id, token_hash, user_id, family_id, parent_id, expires_at, revoked_at
+	    FROM refresh_tokens WHERE token_hash = $1
 	`
-	err := r.db.QueryRow(query, cookieToken).Scan(
-		&refreshToken.Token,
+	err := r.db.QueryRow(query, HashToken(cookieToken)).Scan(
+		&refreshToken.ID,
+		&refreshToken.TokenHash,
 		&refreshToken.UserID,
+		&refreshToken.FamilyID,
+		&refreshToken.ParentID,
 		&refreshToken.ExpiresAt,
+		&refreshToken.RevokedAt,
 	)
 	if err != nil {
 		return nil, err
@@ -48,17 +56,121 @@ func (r *RefreshTokenRepository) GetByToken(cookieToken string) (*auth_models.Re
 	return refreshToken, nil
 }
 
+// Retire marks a refresh token as used so it can't be exchanged again.
+// Returns false if the token was already retired, e.g. by a concurrent refresh.
+func (r *RefreshTokenRepository) Retire(id int) (bool, error) {
+	query := `
+		UPDATE refresh_tokens SET revoked_at = NOW() WHERE id = $1 AND revoked_at IS NULL
+	`
+	result, err := r.db.Exec(query, id)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rowsAffected == 1, nil
+}
+
+// DeleteByToken ends the session the token belongs to, including the retired
+// tokens earlier in its rotation family.
 func (r *RefreshTokenRepository) DeleteByToken(cookieToken string) error {
 	query := `
-		DELETE FROM refresh_tokens WHERE token = $1
+		DELETE FROM refresh_tokens
+		WHERE family_id = (SELECT family_id FROM refresh_tokens WHERE token_hash = $1)
 	`
-	_, err := r.db.Exec(query, cookieToken)
+	_, err := r.db.Exec(query, HashToken(cookieToken))
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
+// GetActiveSessionsByUserID returns one row per token family that still has an unexpired,
+// unretired refresh token, most recently used first.
+func (r *RefreshTokenRepository) GetActiveSessionsByUserID(userID uuid.UUID) ([]*auth_models.Session, error) {
+	query := `
+		SELECT rt.family_id,
+			COALESCE(rt.ip_address, ''),
+			COALESCE(rt.user_agent, ''),
+			(SELECT MIN(f.issued_at) FROM refresh_tokens f WHERE f.family_id = rt.family_id),
+			rt.issued_at,
+			rt.expires_at
+		FROM refresh_tokens rt
+		WHERE rt.user_id = $1 AND rt.revoked_at IS NULL AND rt.expires_at > NOW()
+		ORDER BY rt.issued_at DESC
+	`
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []*auth_models.Session{}
+	for rows.Next() {
+		var session auth_models.Session
+		err := rows.Scan(
+			&session.ID,
+			&session.IPAddress,
+			&session.UserAgent,
+			&session.CreatedAt,
+			&session.LastUsedAt,
+			&session.ExpiresAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, &session)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+// DeleteUserFamily revokes a session only if it belongs to the user.
+// Returns false if no such session exists for the user.
+func (r *RefreshTokenRepository) DeleteUserFamily(userID uuid.UUID, familyID uuid.UUID) (bool, error) {
+	query := `
+		DELETE FROM refresh_tokens WHERE user_id = $1 AND family_id = $2
+	`
+	result, err := r.db.Exec(query, userID, familyID)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rowsAffected > 0, nil
+}
+
+func (r *RefreshTokenRepository) DeleteByFamilyID(familyID uuid.UUID) error {
+	query := `
+		DELETE FROM refresh_tokens WHERE family_id = $1
+	`
+	_, err := r.db.Exec(query, familyID)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// DeleteAllByUserIDExceptFamily revokes every session of the user other than the given one
+func (r *RefreshTokenRepository) DeleteAllByUserIDExceptFamily(userID uuid.UUID, familyID uuid.UUID) error {
+	query := `
+		DELETE FROM refresh_tokens WHERE user_id = $1 AND family_id <> $2
+	`
+	_, err := r.db.Exec(query, userID, familyID)
+	return err
+}
+
 func (r *RefreshTokenRepository) DeleteAllByUserID(userID string) error {
 	query := `
 		DELETE FROM refresh_tokens WHERE user_id = $1
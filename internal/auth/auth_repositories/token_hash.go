@@ -0,0 +1,14 @@
+package auth_repositories
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// HashToken returns the hex encoded SHA-256 of a token so that only the hash is stored.
+// Tokens are high entropy random values, so an unsalted fast hash is enough to make
+// a leaked table useless without making lookups by token impossible.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,47 @@
+package auth_repositories
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type UsedTokenRepository struct {
+	db *sql.DB
+}
+
+func NewUsedTokenRepository(db *sql.DB) *UsedTokenRepository {
+	return &UsedTokenRepository{db: db}
+}
+
+// MarkUsed records that a single-use token was consumed. userID is nil for tokens
+// that aren't tied to an account yet, like sign-up links.
+// Returns false if the token was already used.
+func (r *UsedTokenRepository) MarkUsed(jti uuid.UUID, purpose string, userID *uuid.UUID, expiresAt time.Time) (bool, error) {
+	query := `
+		INSERT INTO used_tokens (jti, purpose, user_id, expires_at, used_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (jti) DO NOTHING
+	`
+	result, err := r.db.Exec(query, jti, purpose, userID, expiresAt, time.Now())
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rowsAffected == 1, nil
+}
+
+// DeleteExpired removes tokens that can no longer be replayed anyway
+func (r *UsedTokenRepository) DeleteExpired() error {
+	query := `
+		DELETE FROM used_tokens WHERE expires_at < NOW()
+	`
+	_, err := r.db.Exec(query)
+	return err
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_models"
 	"github.com/csusmGDSC/csusmgdsc-api/internal/models"
@@ -21,14 +22,14 @@ func NewUserRepository(db *sql.DB) *UserRepository {
 	return &UserRepository{db: db}
 }
 
-func (r *UserRepository) Create(user *models.User) error {
-	query := `
-		INSERT INTO users (
-			id, full_name, email, password, provider, auth_id, created_at, updated_at, is_onboarded, email_verified
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
-	`
+const createUserQuery = `
+	INSERT INTO users (
+		id, full_name, email, password, provider, auth_id, created_at, updated_at, is_onboarded, email_verified
+	) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+`
 
-	_, err := r.db.Exec(query,
+func createUserArgs(user *models.User) []interface{} {
+	return []interface{}{
 		user.ID,
 		user.FullName,
 		user.Email,
@@ -39,11 +40,33 @@ func (r *UserRepository) Create(user *models.User) error {
 		user.UpdatedAt,
 		user.IsOnboarded,
 		user.EmailVerified,
-	)
+	}
+}
 
+func (r *UserRepository) Create(user *models.User) error {
+	_, err := r.db.Exec(createUserQuery, createUserArgs(user)...)
 	return err
 }
 
+// CreateWithIdentity creates a user together with the OAuth identity they signed up with,
+// in one transaction so a failure can't leave behind a user nobody can sign in as
+func (r *UserRepository) CreateWithIdentity(user *models.User, identity *auth_models.UserIdentity) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(createUserQuery, createUserArgs(user)...); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(createIdentityQuery, createIdentityArgs(identity)...); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
 func (r *UserRepository) Update(userID string, req auth_models.UpdateUserRequest) error {
 	var updates []string
 	var args []interface{}
@@ -193,13 +216,248 @@ func (r *UserRepository) Update(userID string, req auth_models.UpdateUserRequest
 	return nil
 }
 
+func (r *UserRepository) UpdatePassword(userID uuid.UUID, hashedPassword string) error {
+	query := `
+		UPDATE users SET password = $1, updated_at = $2 WHERE id = $3
+	`
+	result, err := r.db.Exec(query, hashedPassword, time.Now(), userID)
+	if err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// UpdateEmail changes the user's login email to an address they have just proven they own
+func (r *UserRepository) UpdateEmail(userID uuid.UUID, email string) error {
+	query := `
+		UPDATE users SET email = $1, email_verified = TRUE, updated_at = $2 WHERE id = $3
+	`
+	result, err := r.db.Exec(query, email, time.Now(), userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// ClaimUnverifiedAccount marks the email verified for someone who has just proven they
+// own it, and removes every way into the account that whoever created it may have set up:
+// the password, two-factor authentication, sessions, personal access tokens and identities
+// whose provider didn't verify the email
+func (r *UserRepository) ClaimUnverifiedAccount(userID uuid.UUID) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	queries := []string{
+		`UPDATE users SET email_verified = TRUE, password = NULL, totp_secret = NULL, totp_enabled = FALSE,
+			totp_last_used_step = NULL, updated_at = NOW() WHERE id = $1`,
+		`DELETE FROM mfa_recovery_codes WHERE user_id = $1`,
+		`DELETE FROM refresh_tokens WHERE user_id = $1`,
+		`DELETE FROM personal_access_tokens WHERE user_id = $1`,
+		`DELETE FROM user_identities WHERE user_id = $1 AND email_verified = FALSE`,
+	}
+	for _, query := range queries {
+		if _, err := tx.Exec(query, userID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetStatus returns whether the user is suspended or banned
+func (r *UserRepository) GetStatus(userID uuid.UUID) (*auth_models.AccountStatus, error) {
+	status := &auth_models.AccountStatus{}
+	query := `
+		SELECT suspended_until, banned_at, suspension_reason FROM users WHERE id = $1
+	`
+	err := r.db.QueryRow(query, userID).Scan(&status.SuspendedUntil, &status.BannedAt, &status.Reason)
+	if err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+// SetStatus suspends, bans or reinstates the user. Passing nil for both
+// suspendedUntil and bannedAt reinstates them.
+func (r *UserRepository) SetStatus(userID uuid.UUID, suspendedUntil *time.Time, bannedAt *time.Time, reason *string) error {
+	query := `
+		UPDATE users SET suspended_until = $1, banned_at = $2, suspension_reason = $3, updated_at = $4
+		WHERE id = $5
+	`
+	result, err := r.db.Exec(query, suspendedUntil, bannedAt, reason, time.Now(), userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// ScheduleDeletion marks the user's account to be purged at the given time
+func (r *UserRepository) ScheduleDeletion(userID uuid.UUID, at time.Time) error {
+	query := `
+		UPDATE users SET deletion_scheduled_at = $1, updated_at = $2 WHERE id = $3
+	`
+	result, err := r.db.Exec(query, at, time.Now(), userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// CancelDeletion clears a scheduled deletion. Returns false if none was scheduled.
+func (r *UserRepository) CancelDeletion(userID uuid.UUID) (bool, error) {
+	query := `
+		UPDATE users SET deletion_scheduled_at = NULL, updated_at = $1
+		WHERE id = $2 AND deletion_scheduled_at IS NOT NULL
+	`
+	result, err := r.db.Exec(query, time.Now(), userID)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// GetDeletionScheduledAt returns when the user's account will be purged, or nil
+func (r *UserRepository) GetDeletionScheduledAt(userID uuid.UUID) (*time.Time, error) {
+	var scheduledAt *time.Time
+	query := `SELECT deletion_scheduled_at FROM users WHERE id = $1`
+	err := r.db.QueryRow(query, userID).Scan(&scheduledAt)
+	return scheduledAt, err
+}
+
+// GetDueForDeletion returns the users whose grace period has ended
+func (r *UserRepository) GetDueForDeletion(now time.Time) ([]uuid.UUID, error) {
+	query := `
+		SELECT id FROM users WHERE deletion_scheduled_at <= $1
+	`
+	rows, err := r.db.Query(query, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var userIDs []uuid.UUID
+	for rows.Next() {
+		var userID uuid.UUID
+		if err := rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+		userIDs = append(userIDs, userID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return userIDs, nil
+}
+
+// Purge deletes the user along with everything that identifies them. Their comments
+// and the events they created are kept without an author, and the audit log entries
+// about them are kept without the emails they had. Login throttles and the lockout
+// entries that were keyed by their email are cleared too.
+func (r *UserRepository) Purge(userID uuid.UUID) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Normalized the same way as login throttle keys
+	var email string
+	err = tx.QueryRow(`SELECT LOWER(TRIM(email)) FROM users WHERE id = $1`, userID).Scan(&email)
+	if err == sql.ErrNoRows {
+		return nil // Already purged
+	}
+	if err != nil {
+		return err
+	}
+
+	emailQueries := []string{
+		`UPDATE audit_logs SET target_id = '[redacted]', details = details - 'key'
+			WHERE target_type = 'email' AND target_id = $1`,
+		`DELETE FROM login_throttles WHERE scope = 'email' AND key = $1`,
+	}
+	for _, query := range emailQueries {
+		if _, err := tx.Exec(query, email); err != nil {
+			return err
+		}
+	}
+
+	queries := []string{
+		`UPDATE comments SET user_id = NULL WHERE user_id = $1`,
+		`UPDATE comments SET pinned_by = NULL WHERE pinned_by = $1`,
+		`UPDATE events SET created_by = NULL WHERE created_by = $1`,
+		`DELETE FROM event_organizers WHERE user_id = $1`,
+		`UPDATE audit_logs SET details = details - 'email' - 'old_email' - 'new_email' - 'key'
+			WHERE target_type = 'user' AND target_id = $1::text`,
+		`DELETE FROM login_throttles WHERE scope = 'mfa' AND key = $1::text`,
+		`DELETE FROM users WHERE id = $1`,
+	}
+	for _, query := range queries {
+		if _, err := tx.Exec(query, userID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
 func (r *UserRepository) GetByEmail(email string) (*models.User, error) {
 	user := &models.User{}
 	query := `
 		SELECT id, full_name, first_name, last_name, email, password,
 		 	role, position, branch, image, github,
 			linkedin, instagram, discord, bio, tags, website,
-			graduation_date, created_at, updated_at, provider, auth_id, email_verified
+			graduation_date, created_at, updated_at, provider, auth_id, email_verified,
+			totp_enabled
 		FROM users
 		WHERE email = $1
 	`
@@ -228,6 +486,7 @@ func (r *UserRepository) GetByEmail(email string) (*models.User, error) {
 		&user.Provider,
 		&user.AuthID,
 		&user.EmailVerified,
+		&user.TOTPEnabled,
 	)
 
 	if err != nil {
@@ -243,7 +502,8 @@ func (r *UserRepository) GetByAuthID(authID string) (*models.User, error) {
 		SELECT id, full_name, first_name, last_name, email, password,
 		 	role, position, branch, image, github,
 			linkedin, instagram, discord, bio, tags, website,
-			graduation_date, created_at, updated_at, provider, auth_id, is_onboarded
+			graduation_date, created_at, updated_at, provider, auth_id, is_onboarded,
+			totp_enabled
 		FROM users
 		WHERE auth_id = $1
 	`
@@ -272,6 +532,7 @@ func (r *UserRepository) GetByAuthID(authID string) (*models.User, error) {
 		&user.Provider,
 		&user.AuthID,
 		&user.IsOnboarded,
+		&user.TOTPEnabled,
 	)
 
 	if err != nil {
@@ -323,6 +584,7 @@ func (r *UserRepository) GetByID(id uuid.UUID) (*models.User, error) {
 			auth_id,
 			is_onboarded,
 			email_verified,
+			totp_enabled
 		FROM users
 		WHERE id = $1
 	`
@@ -353,6 +615,7 @@ func (r *UserRepository) GetByID(id uuid.UUID) (*models.User, error) {
 		&user.AuthID,
 		&user.IsOnboarded,
 		&user.EmailVerified,
+		&user.TOTPEnabled,
 	)
 
 	if err != nil {
@@ -384,6 +647,7 @@ func (r *UserRepository) DeleteByID(userID string) error {
 	return nil
 }
 
+// GetAll lists users page by page, leaving out accounts scheduled for deletion
 func (r *UserRepository) GetAll(pageStr string, limitStr string) (*auth_models.AllUsersResponse, error) {
 	// Convrt string parameters to integers with default values
 	page, err := strconv.Atoi(pageStr)
@@ -424,8 +688,10 @@ func (r *UserRepository) GetAll(pageStr string, limitStr string) (*auth_models.A
 			provider,
 			auth_id,
 			is_onboarded,
-			email_verified
+			email_verified,
+			totp_enabled
 		FROM users
+		WHERE deletion_scheduled_at IS NULL
 		ORDER BY created_at DESC
 		LIMIT $1 OFFSET $2
 	`
@@ -465,6 +731,7 @@ func (r *UserRepository) GetAll(pageStr string, limitStr string) (*auth_models.A
 			&user.AuthID,
 			&user.IsOnboarded,
 			&user.EmailVerified,
+			&user.TOTPEnabled,
 		)
 		if err != nil {
 			return nil, err
@@ -488,9 +755,10 @@ func (r *UserRepository) GetAll(pageStr string, limitStr string) (*auth_models.A
 	}, nil
 }
 
+// GetTotalCount counts the users GetAll lists
 func (r *UserRepository) GetTotalCount() (int, error) {
 	var count int
-	err := r.db.QueryRow("SELECT COUNT(*) FROM users").Scan(&count)
+	err := r.db.QueryRow("SELECT COUNT(*) FROM users WHERE deletion_scheduled_at IS NULL").Scan(&count)
 	if err != nil {
 		return 0, err
 	}
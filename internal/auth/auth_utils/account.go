@@ -0,0 +1,166 @@
+package auth_utils
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_models"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_repositories"
+	"github.com/google/uuid"
+)
+
+var (
+	AccountDeletionGracePeriod = time.Hour * 24 * 14 // Time to change your mind after asking to delete your account
+	AccountPurgeInterval       = time.Hour           // How often accounts past their grace period are purged
+)
+
+const maxExportedPointsChanges = 1000
+
+// ExportAccount collects everything stored about the user: their profile, roles, linked
+// identities, comments, the events they organize, active sessions and points history.
+func ExportAccount(userRepo auth_repositories.UserStore, rbacRepo auth_repositories.PermissionStore, identityRepo auth_repositories.IdentityStore, refreshTokenRepo auth_repositories.RefreshTokenStore, auditRepo auth_repositories.AuditLogStore, commentRepo auth_repositories.CommentStore, eventOrganizerRepo auth_repositories.OrganizedEventStore, userID uuid.UUID) (*auth_models.AccountExport, error) {
+	user, err := userRepo.GetByID(userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrUserDoesntExist
+		}
+		return nil, err
+	}
+	user.Password = nil
+
+	export := &auth_models.AccountExport{
+		ExportedAt: time.Now(),
+		Profile:    user,
+	}
+
+	if export.Roles, err = rbacRepo.GetRolesByUserID(userID); err != nil {
+		return nil, err
+	}
+
+	if export.Identities, err = identityRepo.GetByUserID(userID); err != nil {
+		return nil, err
+	}
+
+	if export.Comments, err = commentRepo.GetCommentsByUserId(userID); err != nil {
+		return nil, err
+	}
+
+	if export.OrganizedEvents, err = eventOrganizerRepo.GetEventsByUserID(userID); err != nil {
+		return nil, err
+	}
+
+	if export.Sessions, err = listSessions(refreshTokenRepo, userID, ""); err != nil {
+		return nil, err
+	}
+
+	export.PointsHistory, err = auditRepo.List(auth_models.AuditLogFilter{
+		Action:     AuditUserPointsChanged,
+		TargetType: AuditTargetUser,
+		TargetID:   userID.String(),
+		Limit:      maxExportedPointsChanges,
+	})
+	if err != nil {
+		return nil, err
+	}
+	// The IP address is the admin's, not the user's
+	for _, entry := range export.PointsHistory {
+		entry.IPAddress = nil
+	}
+
+	return export, nil
+}
+
+// ScheduleAccountDeletion marks the account to be purged once the grace period ends,
+// signs the user out everywhere and revokes their personal access tokens. Until then the
+// account is hidden from other users, and signing back in cancels the deletion.
+func ScheduleAccountDeletion(deletionRepo auth_repositories.AccountDeletionStore, refreshTokenRepo auth_repositories.RefreshTokenStore, patRepo auth_repositories.PersonalAccessTokenStore, userID uuid.UUID) (time.Time, error) {
+	scheduledAt, err := deletionRepo.GetDeletionScheduledAt(userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return time.Time{}, ErrUserDoesntExist
+		}
+		return time.Time{}, err
+	}
+
+	// Asking again doesn't push the deletion back
+	if scheduledAt == nil {
+		at := time.Now().Add(AccountDeletionGracePeriod)
+		if err := deletionRepo.ScheduleDeletion(userID, at); err != nil {
+			return time.Time{}, err
+		}
+		scheduledAt = &at
+	}
+
+	if err := refreshTokenRepo.DeleteAllByUserID(userID.String()); err != nil {
+		return time.Time{}, err
+	}
+
+	if err := patRepo.DeleteAllByUserID(userID); err != nil {
+		return time.Time{}, err
+	}
+
+	return *scheduledAt, nil
+}
+
+// CancelAccountDeletion keeps an account that was scheduled for deletion.
+// Returns ErrNoDeletionScheduled if there was nothing to cancel.
+func CancelAccountDeletion(deletionRepo auth_repositories.AccountDeletionStore, userID uuid.UUID) error {
+	cancelled, err := deletionRepo.CancelDeletion(userID)
+	if err != nil {
+		return err
+	}
+	if !cancelled {
+		return ErrNoDeletionScheduled
+	}
+	return nil
+}
+
+// PurgeUser deletes the user for good. Comments are kept without an author so replies
+// to them aren't lost, and their email is removed from the audit log and login throttles.
+func PurgeUser(db *sql.DB, userID uuid.UUID) error {
+	userRepo := auth_repositories.NewUserRepository(db)
+	return userRepo.Purge(userID)
+}
+
+// PurgeDeletedAccounts purges every account whose grace period has ended
+func PurgeDeletedAccounts(deletionRepo auth_repositories.AccountDeletionStore, auditRepo auth_repositories.AuditLogStore) error {
+	userIDs, err := deletionRepo.GetDueForDeletion(time.Now())
+	if err != nil {
+		return err
+	}
+
+	for _, userID := range userIDs {
+		if err := deletionRepo.Purge(userID); err != nil {
+			log.Printf("Failed to purge deleted account %s: %v", userID, err)
+			continue
+		}
+		if err := recordAudit(auditRepo, nil, AuditUserPurged, AuditTargetUser, userID.String(), "", nil); err != nil {
+			log.Printf("Failed to record purge of account %s: %v", userID, err)
+		}
+	}
+
+	return nil
+}
+
+// StartAccountPurger purges deleted accounts every AccountPurgeInterval until ctx is done
+func StartAccountPurger(ctx context.Context, db *sql.DB) {
+	go func() {
+		ticker := time.NewTicker(AccountPurgeInterval)
+		defer ticker.Stop()
+
+		for {
+			if err := PurgeDeletedAccounts(auth_repositories.NewUserRepository(db), auth_repositories.NewAuditLogRepository(db)); err != nil {
+				log.Printf("Failed to purge deleted accounts: %v", err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
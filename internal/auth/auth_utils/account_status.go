@@ -0,0 +1,79 @@
+package auth_utils
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_repositories"
+	"github.com/google/uuid"
+)
+
+// AccountSuspendedError is returned when a suspended or banned user tries to sign in
+// or use the API. Until is nil for a ban.
+type AccountSuspendedError struct {
+	Until  *time.Time
+	Reason string
+}
+
+func (e *AccountSuspendedError) Error() string {
+	if e.Until == nil {
+		return "account is banned"
+	}
+	return "account is suspended until " + e.Until.Format(time.RFC3339)
+}
+
+// CheckAccountStatus returns an *AccountSuspendedError if the user is banned or their
+// suspension hasn't ended yet
+func CheckAccountStatus(db *sql.DB, userID uuid.UUID) error {
+	return checkAccountStatus(auth_repositories.NewUserRepository(db), userID)
+}
+
+func checkAccountStatus(userRepo auth_repositories.UserStore, userID uuid.UUID) error {
+	status, err := userRepo.GetStatus(userID)
+	if err != nil {
+		return err
+	}
+
+	reason := ""
+	if status.Reason != nil {
+		reason = *status.Reason
+	}
+
+	if status.BannedAt != nil {
+		return &AccountSuspendedError{Reason: reason}
+	}
+	if status.SuspendedUntil != nil && status.SuspendedUntil.After(time.Now()) {
+		return &AccountSuspendedError{Until: status.SuspendedUntil, Reason: reason}
+	}
+
+	return nil
+}
+
+// SuspendUser blocks the user until the given time and signs them out everywhere
+func SuspendUser(db *sql.DB, userID uuid.UUID, until time.Time, reason string) error {
+	userRepo := auth_repositories.NewUserRepository(db)
+	if err := userRepo.SetStatus(userID, &until, nil, &reason); err != nil {
+		return err
+	}
+
+	refreshTokenRepo := auth_repositories.NewRefreshTokenRepository(db)
+	return refreshTokenRepo.DeleteAllByUserID(userID.String())
+}
+
+// BanUser blocks the user indefinitely and signs them out everywhere
+func BanUser(db *sql.DB, userID uuid.UUID, reason string) error {
+	now := time.Now()
+	userRepo := auth_repositories.NewUserRepository(db)
+	if err := userRepo.SetStatus(userID, nil, &now, &reason); err != nil {
+		return err
+	}
+
+	refreshTokenRepo := auth_repositories.NewRefreshTokenRepository(db)
+	return refreshTokenRepo.DeleteAllByUserID(userID.String())
+}
+
+// ReinstateUser lifts a suspension or ban
+func ReinstateUser(db *sql.DB, userID uuid.UUID) error {
+	userRepo := auth_repositories.NewUserRepository(db)
+	return userRepo.SetStatus(userID, nil, nil, nil)
+}
@@ -0,0 +1,284 @@
+package auth_utils_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_models"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_utils"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/mocks"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestExportAccount(t *testing.T) {
+	userID := uuid.New()
+
+	t.Run("Collects everything stored about the user", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		users := mocks.NewMockUserStore(ctrl)
+		rbac := mocks.NewMockPermissionStore(ctrl)
+		identities := mocks.NewMockIdentityStore(ctrl)
+		refreshTokens := mocks.NewMockRefreshTokenStore(ctrl)
+		audit := mocks.NewMockAuditLogStore(ctrl)
+		comments := mocks.NewMockCommentStore(ctrl)
+		organizedEvents := mocks.NewMockOrganizedEventStore(ctrl)
+
+		users.EXPECT().GetByID(userID).Return(&models.User{ID: userID, Password: ptr("hash")}, nil)
+		rbac.EXPECT().GetRolesByUserID(userID).Return([]string{"MARKETING_OFFICER"}, nil)
+		identities.EXPECT().GetByUserID(userID).Return([]*auth_models.UserIdentity{{Provider: "github"}}, nil)
+		comments.EXPECT().GetCommentsByUserId(userID).Return([]*models.Comment{{}}, nil)
+		organizedEvents.EXPECT().GetEventsByUserID(userID).Return([]models.Event{{}}, nil)
+		refreshTokens.EXPECT().GetActiveSessionsByUserID(userID).Return([]*auth_models.Session{{UserAgent: "Mozilla/5.0 (X11; Linux x86_64) Firefox/128.0"}}, nil)
+		audit.EXPECT().List(gomock.Any()).DoAndReturn(func(filter auth_models.AuditLogFilter) ([]*auth_models.AuditLog, error) {
+			assert.Equal(t, auth_utils.AuditUserPointsChanged, filter.Action)
+			assert.Equal(t, userID.String(), filter.TargetID)
+			return []*auth_models.AuditLog{{Action: auth_utils.AuditUserPointsChanged, IPAddress: ptr("203.0.113.7")}}, nil
+		})
+
+		export, err := auth_utils.ExportAccount(users, rbac, identities, refreshTokens, audit, comments, organizedEvents, userID)
+		require.NoError(t, err)
+		assert.Nil(t, export.Profile.Password)
+		assert.Equal(t, []string{"MARKETING_OFFICER"}, export.Roles)
+		assert.Len(t, export.Identities, 1)
+		assert.Len(t, export.Comments, 1)
+		assert.Len(t, export.OrganizedEvents, 1)
+		require.Len(t, export.Sessions, 1)
+		assert.False(t, export.Sessions[0].Current)
+		require.Len(t, export.PointsHistory, 1)
+		assert.Nil(t, export.PointsHistory[0].IPAddress, "the IP address is the admin's")
+	})
+
+	t.Run("Unknown user", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		users := mocks.NewMockUserStore(ctrl)
+
+		users.EXPECT().GetByID(userID).Return(nil, sql.ErrNoRows)
+
+		_, err := auth_utils.ExportAccount(users, mocks.NewMockPermissionStore(ctrl), mocks.NewMockIdentityStore(ctrl), mocks.NewMockRefreshTokenStore(ctrl),
+			mocks.NewMockAuditLogStore(ctrl), mocks.NewMockCommentStore(ctrl), mocks.NewMockOrganizedEventStore(ctrl), userID)
+		assert.ErrorIs(t, err, auth_utils.ErrUserDoesntExist)
+	})
+}
+
+func TestScheduleAccountDeletion(t *testing.T) {
+	userID := uuid.New()
+
+	expectSignedOut := func(refreshTokens *mocks.MockRefreshTokenStore, tokens *mocks.MockPersonalAccessTokenStore) {
+		refreshTokens.EXPECT().DeleteAllByUserID(userID.String()).Return(nil)
+		tokens.EXPECT().DeleteAllByUserID(userID).Return(nil)
+	}
+
+	t.Run("Schedules the deletion after the grace period and signs the user out", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		deletions := mocks.NewMockAccountDeletionStore(ctrl)
+		refreshTokens := mocks.NewMockRefreshTokenStore(ctrl)
+		tokens := mocks.NewMockPersonalAccessTokenStore(ctrl)
+
+		deletions.EXPECT().GetDeletionScheduledAt(userID).Return(nil, nil)
+		deletions.EXPECT().ScheduleDeletion(userID, gomock.Any()).Return(nil)
+		expectSignedOut(refreshTokens, tokens)
+
+		scheduledAt, err := auth_utils.ScheduleAccountDeletion(deletions, refreshTokens, tokens, userID)
+		require.NoError(t, err)
+		assert.WithinDuration(t, time.Now().Add(auth_utils.AccountDeletionGracePeriod), scheduledAt, time.Minute)
+	})
+
+	t.Run("Asking again keeps the original date", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		deletions := mocks.NewMockAccountDeletionStore(ctrl)
+		refreshTokens := mocks.NewMockRefreshTokenStore(ctrl)
+		tokens := mocks.NewMockPersonalAccessTokenStore(ctrl)
+		original := time.Now().Add(time.Hour)
+
+		deletions.EXPECT().GetDeletionScheduledAt(userID).Return(&original, nil)
+		expectSignedOut(refreshTokens, tokens)
+
+		scheduledAt, err := auth_utils.ScheduleAccountDeletion(deletions, refreshTokens, tokens, userID)
+		require.NoError(t, err)
+		assert.Equal(t, original, scheduledAt)
+	})
+
+	t.Run("Unknown user", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		deletions := mocks.NewMockAccountDeletionStore(ctrl)
+
+		deletions.EXPECT().GetDeletionScheduledAt(userID).Return(nil, sql.ErrNoRows)
+
+		_, err := auth_utils.ScheduleAccountDeletion(deletions, mocks.NewMockRefreshTokenStore(ctrl), mocks.NewMockPersonalAccessTokenStore(ctrl), userID)
+		assert.ErrorIs(t, err, auth_utils.ErrUserDoesntExist)
+	})
+}
+
+func TestCancelAccountDeletion(t *testing.T) {
+	userID := uuid.New()
+
+	t.Run("Cancels a scheduled deletion", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		deletions := mocks.NewMockAccountDeletionStore(ctrl)
+
+		deletions.EXPECT().CancelDeletion(userID).Return(true, nil)
+
+		assert.NoError(t, auth_utils.CancelAccountDeletion(deletions, userID))
+	})
+
+	t.Run("Nothing scheduled", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		deletions := mocks.NewMockAccountDeletionStore(ctrl)
+
+		deletions.EXPECT().CancelDeletion(userID).Return(false, nil)
+
+		assert.ErrorIs(t, auth_utils.CancelAccountDeletion(deletions, userID), auth_utils.ErrNoDeletionScheduled)
+	})
+}
+
+func TestPurgeDeletedAccounts(t *testing.T) {
+	t.Run("Purges accounts past their grace period", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		deletions := mocks.NewMockAccountDeletionStore(ctrl)
+		audit := mocks.NewMockAuditLogStore(ctrl)
+		userIDs := []uuid.UUID{uuid.New(), uuid.New()}
+
+		deletions.EXPECT().GetDueForDeletion(gomock.Any()).DoAndReturn(func(now time.Time) ([]uuid.UUID, error) {
+			assert.WithinDuration(t, time.Now(), now, time.Minute)
+			return userIDs, nil
+		})
+		for _, userID := range userIDs {
+			deletions.EXPECT().Purge(userID).Return(nil)
+			audit.EXPECT().Create(gomock.Any()).DoAndReturn(func(entry *auth_models.AuditLog) error {
+				assert.Nil(t, entry.ActorID)
+				assert.Equal(t, auth_utils.AuditUserPurged, entry.Action)
+				assert.Equal(t, userID.String(), entry.TargetID)
+				assert.JSONEq(t, `{}`, string(entry.Details))
+				return nil
+			})
+		}
+
+		assert.NoError(t, auth_utils.PurgeDeletedAccounts(deletions, audit))
+	})
+
+	t.Run("Carries on after a failed purge", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		deletions := mocks.NewMockAccountDeletionStore(ctrl)
+		audit := mocks.NewMockAuditLogStore(ctrl)
+		failed, purged := uuid.New(), uuid.New()
+
+		deletions.EXPECT().GetDueForDeletion(gomock.Any()).Return([]uuid.UUID{failed, purged}, nil)
+		deletions.EXPECT().Purge(failed).Return(sql.ErrConnDone)
+		deletions.EXPECT().Purge(purged).Return(nil)
+		audit.EXPECT().Create(gomock.Any()).DoAndReturn(func(entry *auth_models.AuditLog) error {
+			assert.Equal(t, purged.String(), entry.TargetID, "only the purged account is recorded")
+			return nil
+		})
+
+		assert.NoError(t, auth_utils.PurgeDeletedAccounts(deletions, audit))
+	})
+}
+
+func TestPurgeUser(t *testing.T) {
+	userID := uuid.New()
+	recorder := &purgeRecorder{email: "student@csusm.edu"}
+	db := sql.OpenDB(recorder)
+	defer db.Close()
+
+	require.NoError(t, auth_utils.PurgeUser(db, userID))
+	require.True(t, recorder.committed)
+
+	execFor := func(fragment string) purgeExec {
+		for _, exec := range recorder.execs {
+			if strings.Contains(exec.query, fragment) {
+				return exec
+			}
+		}
+		t.Fatalf("no statement containing %q", fragment)
+		return purgeExec{}
+	}
+
+	t.Run("Clears the login throttles for the email and user", func(t *testing.T) {
+		assert.Equal(t, []driver.Value{"student@csusm.edu"}, execFor("DELETE FROM login_throttles WHERE scope = 'email'").args)
+		assert.Equal(t, []driver.Value{userID.String()}, execFor("DELETE FROM login_throttles WHERE scope = 'mfa'").args)
+	})
+
+	t.Run("Redacts lockout entries keyed by the email", func(t *testing.T) {
+		exec := execFor("WHERE target_type = 'email' AND target_id = $1")
+		assert.Contains(t, exec.query, "target_id = '[redacted]'")
+		assert.Contains(t, exec.query, "details - 'key'")
+		assert.Equal(t, []driver.Value{"student@csusm.edu"}, exec.args)
+	})
+
+	t.Run("Strips the email from entries about the user", func(t *testing.T) {
+		exec := execFor("WHERE target_type = 'user'")
+		for _, key := range []string{"'email'", "'old_email'", "'new_email'", "'key'"} {
+			assert.Contains(t, exec.query, "- "+key)
+		}
+	})
+
+	t.Run("Deletes the user last", func(t *testing.T) {
+		last := recorder.execs[len(recorder.execs)-1]
+		assert.Contains(t, last.query, "DELETE FROM users")
+	})
+}
+
+// purgeRecorder is a database that records every statement run against it and
+// answers every query with the email
+type purgeRecorder struct {
+	email     string
+	execs     []purgeExec
+	committed bool
+}
+
+type purgeExec struct {
+	query string
+	args  []driver.Value
+}
+
+func (r *purgeRecorder) Connect(context.Context) (driver.Conn, error) { return r.Open("") }
+func (r *purgeRecorder) Driver() driver.Driver                        { return r }
+func (r *purgeRecorder) Open(string) (driver.Conn, error)             { return r, nil }
+func (r *purgeRecorder) Close() error                                 { return nil }
+func (r *purgeRecorder) Begin() (driver.Tx, error)                    { return r, nil }
+func (r *purgeRecorder) Commit() error                                { r.committed = true; return nil }
+func (r *purgeRecorder) Rollback() error                              { return nil }
+
+func (r *purgeRecorder) Prepare(query string) (driver.Stmt, error) {
+	return purgeStmt{recorder: r, query: query}, nil
+}
+
+type purgeStmt struct {
+	recorder *purgeRecorder
+	query    string
+}
+
+func (s purgeStmt) Close() error  { return nil }
+func (s purgeStmt) NumInput() int { return -1 }
+func (s purgeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.recorder.execs = append(s.recorder.execs, purgeExec{query: s.query, args: args})
+	return driver.RowsAffected(1), nil
+}
+func (s purgeStmt) Query([]driver.Value) (driver.Rows, error) {
+	return &purgeRows{email: s.recorder.email}, nil
+}
+
+type purgeRows struct {
+	email string
+	done  bool
+}
+
+func (r *purgeRows) Columns() []string { return []string{"email"} }
+func (r *purgeRows) Close() error      { return nil }
+func (r *purgeRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0] = r.email
+	return nil
+}
@@ -0,0 +1,71 @@
+package auth_utils
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_models"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_repositories"
+	"github.com/google/uuid"
+)
+
+// Audit log actions
+const (
+	AuditUserRoleChanged       = "user.role_changed"
+	AuditUserRoleGranted       = "user.role_granted"
+	AuditUserRoleRevoked       = "user.role_revoked"
+	AuditUserPointsChanged     = "user.points_changed"
+	AuditLoginLocked           = "auth.login_locked"
+	AuditLoginUnlocked         = "auth.login_unlocked"
+	AuditInviteCreated         = "invite.created"
+	AuditInviteRevoked         = "invite.revoked"
+	AuditImpersonationStarted  = "impersonation.started"
+	AuditImpersonatedRequest   = "impersonation.request"
+	AuditUserEmailChanged      = "user.email_changed"
+	AuditUserPositionChanged   = "user.position_changed"
+	AuditUserBranchChanged     = "user.branch_changed"
+	AuditUserSuspended         = "user.suspended"
+	AuditUserBanned            = "user.banned"
+	AuditUserReinstated        = "user.reinstated"
+	AuditUserLoggedOut         = "user.logged_out"
+	AuditUserDeleted           = "user.deleted"
+	AuditUserDeletionRequested = "user.deletion_requested"
+	AuditUserDeletionCancelled = "user.deletion_cancelled"
+	AuditUserPasswordChanged   = "user.password_changed"
+	AuditUserPurged            = "user.purged"
+)
+
+// Audit log target types
+const (
+	AuditTargetUser   = "user"
+	AuditTargetInvite = "invite"
+)
+
+// RecordAudit appends an entry to the audit log. actorID is nil for actions
+// the system takes on its own.
+func RecordAudit(db *sql.DB, actorID *uuid.UUID, action string, targetType string, targetID string, ipAddress string, details map[string]interface{}) error {
+	return recordAudit(auth_repositories.NewAuditLogRepository(db), actorID, action, targetType, targetID, ipAddress, details)
+}
+
+func recordAudit(auditRepo auth_repositories.AuditLogStore, actorID *uuid.UUID, action string, targetType string, targetID string, ipAddress string, details map[string]interface{}) error {
+	if details == nil {
+		details = map[string]interface{}{}
+	}
+	detailsJSON, err := json.Marshal(details)
+	if err != nil {
+		return err
+	}
+
+	entry := &auth_models.AuditLog{
+		ActorID:    actorID,
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Details:    detailsJSON,
+	}
+	if ipAddress != "" {
+		entry.IPAddress = &ipAddress
+	}
+
+	return auditRepo.Create(entry)
+}
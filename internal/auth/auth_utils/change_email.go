@@ -0,0 +1,144 @@
+package auth_utils
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_repositories"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/mailer"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/models"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+var (
+	EmailChangeTokenExpiry = time.Minute * 60 // Expiry time of an email change confirmation link
+	EmailChangeReauthAge   = time.Minute * 10 // How recently accounts without a password must have signed in to change their email
+)
+
+// RequestEmailChange emails a confirmation link to the new address and a notice to the
+// current one. Nothing changes until the link is followed, and only the most recent link
+// works. Accounts with a password must enter it, and accounts without one must have signed
+// in within EmailChangeReauthAge of authTime, through their provider, a magic link or
+// two-factor authentication. Otherwise ErrReauthRequired is returned, so a stolen session
+// alone can't be used to take over the account.
+func RequestEmailChange(db *sql.DB, m mailer.Mailer, locale string, userID uuid.UUID, newEmail string, password string, authTime time.Time) error {
+	userRepo := auth_repositories.NewUserRepository(db)
+	emailChangeRepo := auth_repositories.NewEmailChangeRepository(db)
+
+	user, err := userRepo.GetByID(userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrUserDoesntExist
+		}
+		return err
+	}
+
+	if user.Password != nil {
+		if err := ComparePasswords(*user.Password, password); err != nil {
+			return ErrInvalidCredentials
+		}
+	} else if time.Since(authTime) > EmailChangeReauthAge {
+		return ErrReauthRequired
+	}
+
+	newEmail = strings.TrimSpace(newEmail)
+	if strings.EqualFold(newEmail, user.Email) {
+		return ErrEmailUnchanged
+	}
+
+	exists, err := userRepo.EmailExists(newEmail)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return ErrEmailInUse
+	}
+
+	token, claims, err := generatePurposeToken(user.ID, PurposeChangeEmail, newEmail, EmailChangeTokenExpiry)
+	if err != nil {
+		return err
+	}
+
+	// Replaces the link of any earlier request
+	jti, err := uuid.Parse(claims.ID)
+	if err != nil {
+		return err
+	}
+	if err := emailChangeRepo.SetPending(user.ID, jti, claims.ExpiresAt.Time); err != nil {
+		return err
+	}
+
+	if err := SendEmailChangeEmail(m, locale, user.Email, newEmail, token); err != nil {
+		return err
+	}
+
+	return SendEmailChangeNoticeEmail(m, locale, user.Email, newEmail)
+}
+
+// ConfirmEmailChange consumes a confirmation link and moves the user to the new address,
+// which is marked verified. Links from before the user's latest request are rejected.
+// Every session except the one identified by currentToken is revoked. Returns
+// ErrEmailInUse if another account took the address in the meantime, along with the
+// address the user had before.
+func ConfirmEmailChange(db *sql.DB, token string, currentToken string) (user *models.User, oldEmail string, err error) {
+	claims, err := ConsumePurposeToken(db, token, PurposeChangeEmail)
+	if err != nil {
+		if err == ErrTokenAlreadyUsed {
+			return nil, "", ErrInvalidToken
+		}
+		return nil, "", err
+	}
+
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil || claims.Email == "" {
+		return nil, "", ErrInvalidToken
+	}
+
+	jti, err := uuid.Parse(claims.ID)
+	if err != nil {
+		return nil, "", ErrInvalidToken
+	}
+
+	emailChangeRepo := auth_repositories.NewEmailChangeRepository(db)
+	latest, err := emailChangeRepo.ClearPending(userID, jti)
+	if err != nil {
+		return nil, "", err
+	}
+	if !latest {
+		return nil, "", ErrInvalidToken
+	}
+
+	userRepo := auth_repositories.NewUserRepository(db)
+	user, err = userRepo.GetByID(userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, "", ErrInvalidToken
+		}
+		return nil, "", err
+	}
+
+	exists, err := userRepo.EmailExists(claims.Email)
+	if err != nil {
+		return nil, "", err
+	}
+	if exists {
+		return nil, "", ErrEmailInUse
+	}
+
+	oldEmail = user.Email
+	if err := userRepo.UpdateEmail(userID, claims.Email); err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return nil, "", ErrEmailInUse
+		}
+		return nil, "", err
+	}
+	user.Email = claims.Email
+	user.EmailVerified = true
+	user.Password = nil
+
+	return user, oldEmail, RevokeOtherSessions(db, userID, currentToken)
+}
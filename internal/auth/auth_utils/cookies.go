@@ -0,0 +1,170 @@
+package auth_utils
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/csusmGDSC/csusmgdsc-api/config"
+)
+
+const (
+	refreshCookieBaseName    = "refresh_token"
+	csrfCookieBaseName       = "csrf_token"
+	oauthStateCookieBaseName = "oauth_state"
+	hostCookiePrefix         = "__Host-"
+
+	// CSRFHeaderName is the header cookie authenticated requests repeat the CSRF token in
+	CSRFHeaderName = "X-CSRF-Token"
+)
+
+// CookiePolicy holds the attributes of the session cookies
+type CookiePolicy struct {
+	Secure     bool
+	Domain     string
+	Path       string
+	SameSite   http.SameSite
+	HostPrefix bool // Adds the __Host- prefix, which requires Secure, Path=/ and no Domain
+}
+
+// NewCookiePolicy reads the cookie policy from the config. The path defaults to /auth, the
+// only routes that read the refresh cookie, or to / with the __Host- prefix. Returns an
+// error for combinations browsers would reject.
+func NewCookiePolicy(cfg *config.Config) (*CookiePolicy, error) {
+	policy := &CookiePolicy{
+		Secure:     cfg.CookieSecure,
+		Domain:     cfg.CookieDomain,
+		Path:       cfg.CookiePath,
+		HostPrefix: cfg.CookieHostPrefix,
+	}
+
+	switch strings.ToLower(cfg.CookieSameSite) {
+	case "", "strict":
+		policy.SameSite = http.SameSiteStrictMode
+	case "lax":
+		policy.SameSite = http.SameSiteLaxMode
+	case "none":
+		policy.SameSite = http.SameSiteNoneMode
+	default:
+		return nil, fmt.Errorf("invalid COOKIE_SAMESITE %q, must be strict, lax or none", cfg.CookieSameSite)
+	}
+
+	if policy.Path == "" {
+		policy.Path = "/auth"
+		if policy.HostPrefix {
+			policy.Path = "/"
+		}
+	}
+
+	if policy.SameSite == http.SameSiteNoneMode && !policy.Secure {
+		return nil, errors.New("COOKIE_SAMESITE=none requires COOKIE_SECURE=true")
+	}
+	if policy.HostPrefix && (!policy.Secure || policy.Domain != "" || policy.Path != "/") {
+		return nil, errors.New("COOKIE_HOST_PREFIX requires COOKIE_SECURE=true, no COOKIE_DOMAIN and COOKIE_PATH=/")
+	}
+
+	return policy, nil
+}
+
+var (
+	cookiePolicyOnce  sync.Once
+	cookiePolicyValue *CookiePolicy
+	cookiePolicyErr   error
+)
+
+// InitCookiePolicy checks the cookie policy at startup so a misconfiguration fails fast
+func InitCookiePolicy() error {
+	_, err := loadCookiePolicy()
+	return err
+}
+
+func loadCookiePolicy() (*CookiePolicy, error) {
+	cookiePolicyOnce.Do(func() {
+		cookiePolicyValue, cookiePolicyErr = NewCookiePolicy(config.LoadConfig())
+	})
+	return cookiePolicyValue, cookiePolicyErr
+}
+
+// cookiePolicy returns the configured policy, or the strictest one if the config is invalid
+func cookiePolicy() *CookiePolicy {
+	policy, err := loadCookiePolicy()
+	if err != nil {
+		log.Printf("Invalid cookie policy, using the strictest one: %v", err)
+		return &CookiePolicy{Secure: true, Path: "/auth", SameSite: http.SameSiteStrictMode}
+	}
+	return policy
+}
+
+func (p *CookiePolicy) name(baseName string) string {
+	if p.HostPrefix {
+		return hostCookiePrefix + baseName
+	}
+	return baseName
+}
+
+func (p *CookiePolicy) cookie(baseName string, value string, expires time.Time, httpOnly bool) *http.Cookie {
+	return &http.Cookie{
+		Name:     p.name(baseName),
+		Value:    value,
+		Domain:   p.Domain,
+		Path:     p.Path,
+		Expires:  expires,
+		HttpOnly: httpOnly,
+		Secure:   p.Secure,
+		SameSite: p.SameSite,
+	}
+}
+
+// RefreshCookieName is the name of the cookie holding the refresh token
+func RefreshCookieName() string {
+	return cookiePolicy().name(refreshCookieBaseName)
+}
+
+// CSRFCookieName is the name of the cookie holding the CSRF token
+func CSRFCookieName() string {
+	return cookiePolicy().name(csrfCookieBaseName)
+}
+
+func newRefreshCookie(refreshToken string, expiresAt time.Time) *http.Cookie {
+	return cookiePolicy().cookie(refreshCookieBaseName, refreshToken, expiresAt, true)
+}
+
+// ClearRefreshCookie returns a cookie that removes the refresh token from the browser
+func ClearRefreshCookie() *http.Cookie {
+	return cookiePolicy().cookie(refreshCookieBaseName, "", time.Unix(0, 0), true)
+}
+
+// CSRFToken derives the CSRF token of a session from its refresh token. Knowing the CSRF
+// token doesn't reveal the refresh token, and a site that can't read the refresh cookie
+// can't produce it.
+func CSRFToken(refreshToken string) string {
+	sum := sha256.Sum256([]byte("csrf:" + refreshToken))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// CSRFCookie returns a cookie holding the CSRF token for a refresh cookie. Unlike the
+// refresh cookie it's readable from JavaScript, so a frontend on the API's site can copy
+// it into the X-CSRF-Token header. Other frontends use the token from the response body.
+func CSRFCookie(refreshCookie *http.Cookie) *http.Cookie {
+	return cookiePolicy().cookie(csrfCookieBaseName, CSRFToken(refreshCookie.Value), refreshCookie.Expires, false)
+}
+
+// ClearCSRFCookie returns a cookie that removes the CSRF token from the browser
+func ClearCSRFCookie() *http.Cookie {
+	return cookiePolicy().cookie(csrfCookieBaseName, "", time.Unix(0, 0), false)
+}
+
+// ValidCSRFToken checks the token sent in the X-CSRF-Token header against the refresh token
+func ValidCSRFToken(refreshToken string, headerToken string) bool {
+	if refreshToken == "" || headerToken == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(CSRFToken(refreshToken)), []byte(headerToken)) == 1
+}
@@ -0,0 +1,52 @@
+package auth_utils_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/csusmGDSC/csusmgdsc-api/config"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewCookiePolicy(t *testing.T) {
+	t.Run("Defaults to strict cookies scoped to /auth", func(t *testing.T) {
+		policy, err := auth_utils.NewCookiePolicy(&config.Config{})
+		require.NoError(t, err)
+		assert.Equal(t, "/auth", policy.Path)
+		assert.Equal(t, http.SameSiteStrictMode, policy.SameSite)
+	})
+
+	t.Run("Host prefix defaults the path to /", func(t *testing.T) {
+		policy, err := auth_utils.NewCookiePolicy(&config.Config{CookieSecure: true, CookieHostPrefix: true})
+		require.NoError(t, err)
+		assert.Equal(t, "/", policy.Path)
+	})
+
+	t.Run("Rejects settings browsers would ignore", func(t *testing.T) {
+		invalid := []*config.Config{
+			{CookieSameSite: "none"},
+			{CookieSameSite: "sometimes"},
+			{CookieHostPrefix: true},
+			{CookieSecure: true, CookieHostPrefix: true, CookieDomain: "gdsc-csusm.com"},
+			{CookieSecure: true, CookieHostPrefix: true, CookiePath: "/auth"},
+		}
+		for _, cfg := range invalid {
+			_, err := auth_utils.NewCookiePolicy(cfg)
+			assert.Error(t, err)
+		}
+	})
+}
+
+func TestCSRFToken(t *testing.T) {
+	refreshCookie := &http.Cookie{Name: auth_utils.RefreshCookieName(), Value: "refresh-token", Expires: time.Now().Add(time.Hour)}
+	csrfCookie := auth_utils.CSRFCookie(refreshCookie)
+
+	assert.False(t, csrfCookie.HttpOnly)
+	assert.NotContains(t, csrfCookie.Value, "refresh-token")
+	assert.True(t, auth_utils.ValidCSRFToken("refresh-token", csrfCookie.Value))
+	assert.False(t, auth_utils.ValidCSRFToken("another-refresh-token", csrfCookie.Value))
+	assert.False(t, auth_utils.ValidCSRFToken("refresh-token", ""))
+}
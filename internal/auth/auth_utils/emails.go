@@ -0,0 +1,102 @@
+package auth_utils
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/csusmGDSC/csusmgdsc-api/config"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/mailer"
+)
+
+// frontendURL builds a link to a frontend page, optionally carrying a token
+func frontendURL(path string, token string) string {
+	cfg := config.LoadConfig()
+	link := strings.TrimRight(cfg.FrontendURL, "/") + path
+	if token != "" {
+		link += "?" + url.Values{"token": {token}}.Encode()
+	}
+	return link
+}
+
+func SendVerificationEmail(m mailer.Mailer, locale string, userEmail string, verificationToken string) error {
+	msg, err := mailer.Render(mailer.VerificationTemplate, locale, []string{userEmail}, map[string]interface{}{
+		"Email":          userEmail,
+		"ActionURL":      frontendURL("/verify", verificationToken),
+		"ExpiresInHours": int(VerificationTokenExpiry.Hours()),
+	})
+	if err != nil {
+		return err
+	}
+	return m.Send(msg)
+}
+
+func SendPasswordResetEmail(m mailer.Mailer, locale string, userEmail string, resetToken string) error {
+	msg, err := mailer.Render(mailer.PasswordResetTemplate, locale, []string{userEmail}, map[string]interface{}{
+		"Email":            userEmail,
+		"ActionURL":        frontendURL("/reset-password", resetToken),
+		"ExpiresInMinutes": int(PasswordResetTokenExpiry.Minutes()),
+	})
+	if err != nil {
+		return err
+	}
+	return m.Send(msg)
+}
+
+func SendWelcomeEmail(m mailer.Mailer, locale string, userEmail string, name string) error {
+	msg, err := mailer.Render(mailer.WelcomeTemplate, locale, []string{userEmail}, map[string]interface{}{
+		"Name":      name,
+		"ActionURL": frontendURL("/onboarding", ""),
+	})
+	if err != nil {
+		return err
+	}
+	return m.Send(msg)
+}
+
+func SendMagicLinkEmail(m mailer.Mailer, locale string, userEmail string, token string) error {
+	msg, err := mailer.Render(mailer.MagicLinkTemplate, locale, []string{userEmail}, map[string]interface{}{
+		"Email":            userEmail,
+		"ActionURL":        frontendURL("/magic-link", token),
+		"ExpiresInMinutes": int(MagicLinkTokenExpiry.Minutes()),
+	})
+	if err != nil {
+		return err
+	}
+	return m.Send(msg)
+}
+
+func SendEmailChangeEmail(m mailer.Mailer, locale string, oldEmail string, newEmail string, token string) error {
+	msg, err := mailer.Render(mailer.EmailChangeTemplate, locale, []string{newEmail}, map[string]interface{}{
+		"OldEmail":         oldEmail,
+		"NewEmail":         newEmail,
+		"ActionURL":        frontendURL("/confirm-email", token),
+		"ExpiresInMinutes": int(EmailChangeTokenExpiry.Minutes()),
+	})
+	if err != nil {
+		return err
+	}
+	return m.Send(msg)
+}
+
+func SendEmailChangeNoticeEmail(m mailer.Mailer, locale string, oldEmail string, newEmail string) error {
+	msg, err := mailer.Render(mailer.EmailChangeNoticeTemplate, locale, []string{oldEmail}, map[string]interface{}{
+		"OldEmail":  oldEmail,
+		"NewEmail":  newEmail,
+		"ActionURL": frontendURL("/forgot-password", ""),
+	})
+	if err != nil {
+		return err
+	}
+	return m.Send(msg)
+}
+
+func SendPasswordChangedEmail(m mailer.Mailer, locale string, userEmail string) error {
+	msg, err := mailer.Render(mailer.PasswordChangedTemplate, locale, []string{userEmail}, map[string]interface{}{
+		"Email":     userEmail,
+		"ActionURL": frontendURL("/forgot-password", ""),
+	})
+	if err != nil {
+		return err
+	}
+	return m.Send(msg)
+}
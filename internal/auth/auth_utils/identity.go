@@ -0,0 +1,163 @@
+package auth_utils
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_models"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_repositories"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/models"
+	"github.com/google/uuid"
+)
+
+// ResolveOAuthUser finds the user an OAuth login belongs to, registering a new user if needed.
+// inviteCode is used if a new user is registered.
+func ResolveOAuthUser(db *sql.DB, userData *auth.OAuthUserData, inviteCode string) (*models.User, error) {
+	userRepo := auth_repositories.NewUserRepository(db)
+	identityRepo := auth_repositories.NewIdentityRepository(db)
+
+	user, err := FindOAuthUser(userRepo, identityRepo, userData)
+	if err != ErrUserDoesntExist {
+		return user, err
+	}
+
+	name := userData.Name
+	return RegisterUserOAuthToDatabase(db, auth_models.CreateUserOAuthRequest{
+		Email:         userData.Email,
+		EmailVerified: userData.EmailVerified,
+		Provider:      &userData.Provider,
+		AuthID:        &userData.ID,
+		Image:         userData.AvatarURL,
+		Name:          &name,
+		InviteCode:    &inviteCode,
+	})
+}
+
+// FindOAuthUser finds the user an OAuth login belongs to. Returns ErrUserDoesntExist if
+// a new user should be registered.
+//
+// Logins are matched by linked identity. An unknown identity is automatically linked to an
+// existing account with the same email only if both the provider and the account have
+// verified the email. Otherwise someone could create an account with a victim's email
+// ahead of time, with a password or an identity of their own, and share the account once
+// the victim signs in with a provider. Returns ErrUserExists when the email belongs to an
+// account we can't safely link to; the owner has to sign in and link the provider instead.
+func FindOAuthUser(userRepo auth_repositories.UserStore, identityRepo auth_repositories.IdentityStore, userData *auth.OAuthUserData) (*models.User, error) {
+	if userData.ID == "" {
+		return nil, ErrInvalidCredentials
+	}
+
+	identity, err := identityRepo.GetByProviderUserID(userData.Provider, userData.ID)
+	if err == nil {
+		if err := identityRepo.UpdateLastUsed(identity.ID, userData.Email, userData.EmailVerified); err != nil {
+			return nil, err
+		}
+		return userRepo.GetByID(identity.UserID)
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	if userData.Email == nil || !userData.EmailVerified {
+		return nil, ErrUserDoesntExist
+	}
+
+	user, err := userRepo.GetByEmail(*userData.Email)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrUserDoesntExist
+		}
+		return nil, err
+	}
+
+	if !user.EmailVerified {
+		return nil, ErrUserExists
+	}
+
+	if err := createIdentity(identityRepo, user.ID, userData); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// LinkIdentity links an OAuth login to a signed in user.
+// Returns ErrIdentityInUse if the identity already belongs to another account.
+func LinkIdentity(db *sql.DB, userID uuid.UUID, userData *auth.OAuthUserData) error {
+	identityRepo := auth_repositories.NewIdentityRepository(db)
+
+	if userData.ID == "" {
+		return ErrInvalidCredentials
+	}
+
+	identity, err := identityRepo.GetByProviderUserID(userData.Provider, userData.ID)
+	if err == nil {
+		if identity.UserID != userID {
+			return ErrIdentityInUse
+		}
+		// Already linked to this user
+		return identityRepo.UpdateLastUsed(identity.ID, userData.Email, userData.EmailVerified)
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	return createIdentity(identityRepo, userID, userData)
+}
+
+// UnlinkIdentity removes an identity from the user, unless it's their only way to sign in
+func UnlinkIdentity(db *sql.DB, userID uuid.UUID, identityID uuid.UUID) error {
+	userRepo := auth_repositories.NewUserRepository(db)
+	identityRepo := auth_repositories.NewIdentityRepository(db)
+
+	user, err := userRepo.GetByID(userID)
+	if err != nil {
+		return err
+	}
+
+	identities, err := identityRepo.GetByUserID(userID)
+	if err != nil {
+		return err
+	}
+
+	found := false
+	for _, identity := range identities {
+		if identity.ID == identityID {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return ErrIdentityNotFound
+	}
+
+	if user.Password == nil && len(identities) == 1 {
+		return ErrLastLoginMethod
+	}
+
+	deleted, err := identityRepo.DeleteUserIdentity(userID, identityID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrIdentityNotFound
+	}
+
+	return nil
+}
+
+func createIdentity(identityRepo auth_repositories.IdentityStore, userID uuid.UUID, userData *auth.OAuthUserData) error {
+	now := time.Now()
+	return identityRepo.Create(&auth_models.UserIdentity{
+		ID:             uuid.New(),
+		UserID:         userID,
+		Provider:       userData.Provider,
+		ProviderUserID: userData.ID,
+		Email:          userData.Email,
+		EmailVerified:  userData.EmailVerified,
+		CreatedAt:      now,
+		LastUsedAt:     &now,
+	})
+}
@@ -0,0 +1,127 @@
+package auth_utils_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_models"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_utils"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/mocks"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func newOAuthUserData(email string, emailVerified bool) *auth.OAuthUserData {
+	return &auth.OAuthUserData{
+		ID:            "provider-user-1",
+		Name:          "Test User",
+		Email:         &email,
+		EmailVerified: emailVerified,
+		Provider:      "google",
+	}
+}
+
+func TestFindOAuthUser(t *testing.T) {
+	const email = "student@csusm.edu"
+
+	t.Run("Signs in with a linked identity", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		users := mocks.NewMockUserStore(ctrl)
+		identities := mocks.NewMockIdentityStore(ctrl)
+		userData := newOAuthUserData(email, true)
+		user := &models.User{ID: uuid.New(), Email: email, EmailVerified: true}
+		identity := &auth_models.UserIdentity{ID: uuid.New(), UserID: user.ID}
+
+		identities.EXPECT().GetByProviderUserID("google", userData.ID).Return(identity, nil)
+		identities.EXPECT().UpdateLastUsed(identity.ID, userData.Email, true).Return(nil)
+		users.EXPECT().GetByID(user.ID).Return(user, nil)
+
+		found, err := auth_utils.FindOAuthUser(users, identities, userData)
+		require.NoError(t, err)
+		assert.Equal(t, user.ID, found.ID)
+	})
+
+	t.Run("Links to a verified account with the same email", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		users := mocks.NewMockUserStore(ctrl)
+		identities := mocks.NewMockIdentityStore(ctrl)
+		userData := newOAuthUserData(email, true)
+		user := &models.User{ID: uuid.New(), Email: email, EmailVerified: true}
+
+		identities.EXPECT().GetByProviderUserID("google", userData.ID).Return(nil, sql.ErrNoRows)
+		users.EXPECT().GetByEmail(email).Return(user, nil)
+		identities.EXPECT().Create(gomock.Any()).DoAndReturn(func(identity *auth_models.UserIdentity) error {
+			assert.Equal(t, user.ID, identity.UserID)
+			assert.Equal(t, "google", identity.Provider)
+			assert.Equal(t, userData.ID, identity.ProviderUserID)
+			return nil
+		})
+
+		found, err := auth_utils.FindOAuthUser(users, identities, userData)
+		require.NoError(t, err)
+		assert.Equal(t, user.ID, found.ID)
+	})
+
+	t.Run("Refuses to link to an unverified account", func(t *testing.T) {
+		// The account may have been created by someone else with the victim's email,
+		// with a password or with a provider that doesn't verify emails
+		for name, password := range map[string]*string{"with password": ptr("attacker-knows-this"), "without password": nil} {
+			t.Run(name, func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				users := mocks.NewMockUserStore(ctrl)
+				identities := mocks.NewMockIdentityStore(ctrl)
+				userData := newOAuthUserData(email, true)
+				user := &models.User{ID: uuid.New(), Email: email, Password: password, EmailVerified: false}
+
+				identities.EXPECT().GetByProviderUserID("google", userData.ID).Return(nil, sql.ErrNoRows)
+				users.EXPECT().GetByEmail(email).Return(user, nil)
+
+				_, err := auth_utils.FindOAuthUser(users, identities, userData)
+				assert.ErrorIs(t, err, auth_utils.ErrUserExists)
+			})
+		}
+	})
+
+	t.Run("Doesn't link when the provider didn't verify the email", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		users := mocks.NewMockUserStore(ctrl)
+		identities := mocks.NewMockIdentityStore(ctrl)
+		userData := newOAuthUserData(email, false)
+		userData.Provider = "github"
+
+		identities.EXPECT().GetByProviderUserID("github", userData.ID).Return(nil, sql.ErrNoRows)
+
+		_, err := auth_utils.FindOAuthUser(users, identities, userData)
+		assert.ErrorIs(t, err, auth_utils.ErrUserDoesntExist)
+	})
+
+	t.Run("Registers a new user when nothing matches", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		users := mocks.NewMockUserStore(ctrl)
+		identities := mocks.NewMockIdentityStore(ctrl)
+		userData := newOAuthUserData(email, true)
+
+		identities.EXPECT().GetByProviderUserID("google", userData.ID).Return(nil, sql.ErrNoRows)
+		users.EXPECT().GetByEmail(email).Return(nil, sql.ErrNoRows)
+
+		_, err := auth_utils.FindOAuthUser(users, identities, userData)
+		assert.ErrorIs(t, err, auth_utils.ErrUserDoesntExist)
+	})
+
+	t.Run("Missing provider user ID", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		userData := newOAuthUserData(email, true)
+		userData.ID = ""
+
+		_, err := auth_utils.FindOAuthUser(mocks.NewMockUserStore(ctrl), mocks.NewMockIdentityStore(ctrl), userData)
+		assert.ErrorIs(t, err, auth_utils.ErrInvalidCredentials)
+	})
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
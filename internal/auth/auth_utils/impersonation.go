@@ -0,0 +1,53 @@
+package auth_utils
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_repositories"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/models"
+	"github.com/google/uuid"
+)
+
+// StartImpersonation issues an impersonation token for the admin to see the API as the user
+// does, and records why in the audit log. Admins and users who can manage users can't be
+// impersonated, so impersonation can't be used to act with more permissions than the
+// admin already has. Returns ErrCannotImpersonate for them and for the admin themselves.
+func StartImpersonation(userRepo auth_repositories.UserStore, rbacRepo auth_repositories.PermissionStore, auditRepo auth_repositories.AuditLogStore, adminID uuid.UUID, userID uuid.UUID, reason string, ipAddress string) (string, time.Time, *models.User, error) {
+	if userID == adminID {
+		return "", time.Time{}, nil, ErrCannotImpersonate
+	}
+
+	user, err := userRepo.GetByID(userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", time.Time{}, nil, ErrUserDoesntExist
+		}
+		return "", time.Time{}, nil, err
+	}
+
+	if user.Role != nil && *user.Role == models.AdminRole {
+		return "", time.Time{}, nil, ErrCannotImpersonate
+	}
+	canManage, err := HasPermissions(rbacRepo, userID, models.UsersManage)
+	if err != nil {
+		return "", time.Time{}, nil, err
+	}
+	if canManage {
+		return "", time.Time{}, nil, ErrCannotImpersonate
+	}
+
+	token, expiresAt, err := GenerateImpersonationJWT(user, adminID)
+	if err != nil {
+		return "", time.Time{}, nil, err
+	}
+
+	details := map[string]interface{}{"reason": reason, "expires_at": expiresAt}
+	if err := recordAudit(auditRepo, &adminID, AuditImpersonationStarted, AuditTargetUser, userID.String(), ipAddress, details); err != nil {
+		return "", time.Time{}, nil, err
+	}
+
+	user.Password = nil
+	return token, expiresAt, user, nil
+}
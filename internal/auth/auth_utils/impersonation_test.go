@@ -0,0 +1,115 @@
+package auth_utils_test
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_models"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_utils"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/mocks"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestStartImpersonation(t *testing.T) {
+	adminID := uuid.New()
+
+	t.Run("Issues a token marked with the admin and audits the reason", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		users := mocks.NewMockUserStore(ctrl)
+		rbac := mocks.NewMockPermissionStore(ctrl)
+		audit := mocks.NewMockAuditLogStore(ctrl)
+		role := models.UserRole
+		user := &models.User{ID: uuid.New(), Role: &role, Password: ptr("hash")}
+
+		users.EXPECT().GetByID(user.ID).Return(user, nil)
+		rbac.EXPECT().HasPermissions(user.ID, []string{"users:manage"}).Return(false, nil)
+		audit.EXPECT().Create(gomock.Any()).DoAndReturn(func(entry *auth_models.AuditLog) error {
+			assert.Equal(t, adminID, *entry.ActorID)
+			assert.Equal(t, auth_utils.AuditImpersonationStarted, entry.Action)
+			assert.Equal(t, user.ID.String(), entry.TargetID)
+
+			var details map[string]interface{}
+			require.NoError(t, json.Unmarshal(entry.Details, &details))
+			assert.Equal(t, "Reproducing a bug report", details["reason"])
+			return nil
+		})
+
+		token, _, impersonated, err := auth_utils.StartImpersonation(users, rbac, audit, adminID, user.ID, "Reproducing a bug report", "203.0.113.7")
+		require.NoError(t, err)
+		assert.Nil(t, impersonated.Password)
+
+		claims, err := auth_utils.ValidateJWT(token, auth_utils.AccessTokenType)
+		require.NoError(t, err)
+		assert.Equal(t, user.ID.String(), claims.UserID)
+		require.NotNil(t, claims.Act)
+		assert.Equal(t, adminID.String(), claims.Act.Subject)
+	})
+
+	t.Run("Admins can't be impersonated", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		users := mocks.NewMockUserStore(ctrl)
+		role := models.AdminRole
+		user := &models.User{ID: uuid.New(), Role: &role}
+
+		users.EXPECT().GetByID(user.ID).Return(user, nil)
+
+		_, _, _, err := auth_utils.StartImpersonation(users, mocks.NewMockPermissionStore(ctrl), mocks.NewMockAuditLogStore(ctrl), adminID, user.ID, "reason", "")
+		assert.ErrorIs(t, err, auth_utils.ErrCannotImpersonate)
+	})
+
+	t.Run("Users who can manage users can't be impersonated", func(t *testing.T) {
+		// An officer granted users:manage would otherwise let an admin act with their roles
+		ctrl := gomock.NewController(t)
+		users := mocks.NewMockUserStore(ctrl)
+		rbac := mocks.NewMockPermissionStore(ctrl)
+		role := models.UserRole
+		user := &models.User{ID: uuid.New(), Role: &role}
+
+		users.EXPECT().GetByID(user.ID).Return(user, nil)
+		rbac.EXPECT().HasPermissions(user.ID, []string{"users:manage"}).Return(true, nil)
+
+		_, _, _, err := auth_utils.StartImpersonation(users, rbac, mocks.NewMockAuditLogStore(ctrl), adminID, user.ID, "reason", "")
+		assert.ErrorIs(t, err, auth_utils.ErrCannotImpersonate)
+	})
+
+	t.Run("Admins can't impersonate themselves", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		_, _, _, err := auth_utils.StartImpersonation(mocks.NewMockUserStore(ctrl), mocks.NewMockPermissionStore(ctrl), mocks.NewMockAuditLogStore(ctrl), adminID, adminID, "reason", "")
+		assert.ErrorIs(t, err, auth_utils.ErrCannotImpersonate)
+	})
+
+	t.Run("Unknown user", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		users := mocks.NewMockUserStore(ctrl)
+		userID := uuid.New()
+
+		users.EXPECT().GetByID(userID).Return(nil, sql.ErrNoRows)
+
+		_, _, _, err := auth_utils.StartImpersonation(users, mocks.NewMockPermissionStore(ctrl), mocks.NewMockAuditLogStore(ctrl), adminID, userID, "reason", "")
+		assert.ErrorIs(t, err, auth_utils.ErrUserDoesntExist)
+	})
+
+	t.Run("No token without an audit entry", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		users := mocks.NewMockUserStore(ctrl)
+		rbac := mocks.NewMockPermissionStore(ctrl)
+		audit := mocks.NewMockAuditLogStore(ctrl)
+		user := &models.User{ID: uuid.New()}
+		auditErr := errors.New("connection refused")
+
+		users.EXPECT().GetByID(user.ID).Return(user, nil)
+		rbac.EXPECT().HasPermissions(user.ID, gomock.Any()).Return(false, nil)
+		audit.EXPECT().Create(gomock.Any()).Return(auditErr)
+
+		token, _, _, err := auth_utils.StartImpersonation(users, rbac, audit, adminID, user.ID, "reason", "")
+		assert.ErrorIs(t, err, auditErr)
+		assert.Empty(t, token)
+	})
+}
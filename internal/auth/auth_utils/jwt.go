@@ -1,12 +1,9 @@
 package auth_utils
 
 import (
-	"database/sql"
 	"time"
 
 	"github.com/csusmGDSC/csusmgdsc-api/config"
-	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_models"
-	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_repositories"
 	"github.com/csusmGDSC/csusmgdsc-api/internal/models"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
@@ -16,21 +13,64 @@ var (
 	AccessTokenExpiry       = time.Minute * 15   // Short-lived access token
 	RefreshTokenExpiry      = time.Hour * 24 * 7 // Long-lived refresh token
 	VerificationTokenExpiry = time.Hour * 2      // Expiry time of Verification token
+	ImpersonationExpiry     = time.Minute * 10   // Lifetime of an admin's "view as member" token
 )
 
 // Custom claims to set on JWT https://pkg.go.dev/github.com/golang-jwt/jwt/v4#NewWithClaims
 type Claims struct {
-	UserID string `json:"user_id"`
-	Role   string `json:"role"`
+	UserID   string           `json:"user_id"`
+	Role     string           `json:"role"`
+	MFA      bool             `json:"mfa,omitempty"`       // Session passed two-factor authentication
+	AuthTime *jwt.NumericDate `json:"auth_time,omitempty"` // When the user signed in to start the session
+	Act      *Actor           `json:"act,omitempty"`       // Set when an admin is impersonating the user
 	jwt.RegisteredClaims
 }
 
-func GenerateJWT(userID uuid.UUID, role *models.Role, expiry time.Duration) (string, error) {
+// Actor is the party really making requests with a token, as in the "act" claim of RFC 8693
+type Actor struct {
+	Subject string `json:"sub"`
+}
+
+// TokenType is the kind of session token. The two are issued with different audiences
+// so a refresh token is never accepted as an access token or the other way round.
+type TokenType string
+
+const (
+	AccessTokenType  TokenType = "access"
+	RefreshTokenType TokenType = "refresh"
+)
+
+// RefreshTokenAudience is the "aud" claim of refresh tokens. Refresh tokens are only
+// ever presented back to this API.
+const RefreshTokenAudience = "gdsc-csusm:refresh"
+
+// Audience is the "aud" claim a token of this type must carry
+func (t TokenType) Audience() string {
+	if t == RefreshTokenType {
+		return RefreshTokenAudience
+	}
+	return config.LoadConfig().JWTAudience
+}
+
+// GenerateJWT issues an access token. authTime is when the user signed in, zero if unknown.
+func GenerateJWT(userID uuid.UUID, role *models.Role, mfa bool, authTime time.Time, expiry time.Duration) (string, error) {
+	keys, err := signingKeys()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
 	claims := &Claims{
-		UserID: userID.String(),
-		Role:   "not set",
+		UserID:   userID.String(),
+		Role:     "not set",
+		MFA:      mfa,
+		AuthTime: authNumericDate(authTime),
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiry)),
+			Issuer:    config.LoadConfig().JWTIssuer,
+			Subject:   userID.String(),
+			Audience:  jwt.ClaimStrings{AccessTokenType.Audience()},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(expiry)),
 		},
 	}
 
@@ -38,20 +78,28 @@ func GenerateJWT(userID uuid.UUID, role *models.Role, expiry time.Duration) (str
 		claims.Role = role.String()
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	cfg := config.LoadConfig()
-	signedString, err := token.SignedString([]byte(cfg.JWTAccessSecret))
-	return signedString, err
+	return keys.Sign(claims)
 }
 
-func GenerateRefreshToken(userID uuid.UUID, role *models.Role, expiry time.Duration) (string, time.Time, time.Time, error) {
+func GenerateRefreshToken(userID uuid.UUID, role *models.Role, mfa bool, authTime time.Time, expiry time.Duration) (string, time.Time, time.Time, error) {
+	keys, err := signingKeys()
+	if err != nil {
+		return "", time.Time{}, time.Time{}, err
+	}
+
 	issuedAt := jwt.NewNumericDate(time.Now())
 	expiresAt := jwt.NewNumericDate(time.Now().Add(expiry))
 
 	claims := &Claims{
-		UserID: userID.String(),
-		Role:   "not set",
+		UserID:   userID.String(),
+		Role:     "not set",
+		MFA:      mfa,
+		AuthTime: authNumericDate(authTime),
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(), // Keeps rotated tokens unique even when issued in the same second
+			Issuer:    config.LoadConfig().JWTIssuer,
+			Subject:   userID.String(),
+			Audience:  jwt.ClaimStrings{RefreshTokenType.Audience()},
 			IssuedAt:  issuedAt,
 			ExpiresAt: expiresAt,
 		},
@@ -61,28 +109,66 @@ func GenerateRefreshToken(userID uuid.UUID, role *models.Role, expiry time.Durat
 		claims.Role = role.String()
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	cfg := config.LoadConfig()
-	signedString, err := token.SignedString([]byte(cfg.JWTRefreshSecret))
+	signedString, err := keys.Sign(claims)
 	return signedString, issuedAt.Time, expiresAt.Time, err
 }
 
-func CreateSession(db *sql.DB, req auth_models.CreateSessionRequest) error {
-	refreshTokenRepo := auth_repositories.NewRefreshTokenRepository(db)
-	err := refreshTokenRepo.Create(&req)
+func authNumericDate(authTime time.Time) *jwt.NumericDate {
+	if authTime.IsZero() {
+		return nil
+	}
+	return jwt.NewNumericDate(authTime)
+}
+
+// GenerateImpersonationJWT issues a short-lived access token for the user marked with the
+// admin's ID in the act claim. There is no refresh token, so the admin has to ask for a
+// new one once it expires.
+func GenerateImpersonationJWT(user *models.User, impersonatorID uuid.UUID) (string, time.Time, error) {
+	keys, err := signingKeys()
 	if err != nil {
-		return err
+		return "", time.Time{}, err
+	}
 
+	now := time.Now()
+	expiresAt := now.Add(ImpersonationExpiry)
+	claims := &Claims{
+		UserID: user.ID.String(),
+		Role:   "not set",
+		Act:    &Actor{Subject: impersonatorID.String()},
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			Issuer:    config.LoadConfig().JWTIssuer,
+			Subject:   user.ID.String(),
+			Audience:  jwt.ClaimStrings{AccessTokenType.Audience()},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+
+	if user.Role != nil {
+		claims.Role = user.Role.String()
 	}
 
-	return nil
+	signedString, err := keys.Sign(claims)
+	return signedString, expiresAt, err
 }
 
-func ValidateJWT(tokenString string, secret []byte) (*Claims, error) {
+// ValidateJWT validates an access or refresh token, picking the verification key by the
+// token's kid header. Tokens of any other type, including purpose tokens, carry a
+// different audience and are rejected, so a verification or reset link can never be
+// used as a bearer token.
+func ValidateJWT(tokenString string, tokenType TokenType) (*Claims, error) {
+	keys, err := signingKeys()
+	if err != nil {
+		return nil, err
+	}
 
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		return secret, nil
-	})
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, keys.Keyfunc,
+		jwt.WithValidMethods([]string{jwt.SigningMethodEdDSA.Alg()}),
+		jwt.WithIssuer(config.LoadConfig().JWTIssuer),
+		jwt.WithAudience(tokenType.Audience()),
+		jwt.WithExpirationRequired(),
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,46 @@
+package auth_utils_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_utils"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImpersonationJWT(t *testing.T) {
+	role := models.UserRole
+	user := &models.User{ID: uuid.New(), Role: &role}
+	adminID := uuid.New()
+
+	token, expiresAt, err := auth_utils.GenerateImpersonationJWT(user, adminID)
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(auth_utils.ImpersonationExpiry), expiresAt, time.Minute)
+
+	t.Run("Valid access token marked with the admin", func(t *testing.T) {
+		claims, err := auth_utils.ValidateJWT(token, auth_utils.AccessTokenType)
+		require.NoError(t, err)
+		assert.Equal(t, user.ID.String(), claims.UserID)
+		assert.Equal(t, "USER", claims.Role)
+		assert.False(t, claims.MFA)
+		require.NotNil(t, claims.Act)
+		assert.Equal(t, adminID.String(), claims.Act.Subject)
+	})
+
+	t.Run("Not a refresh token", func(t *testing.T) {
+		_, err := auth_utils.ValidateJWT(token, auth_utils.RefreshTokenType)
+		assert.Error(t, err)
+	})
+
+	t.Run("Regular access tokens have no actor", func(t *testing.T) {
+		accessToken, err := auth_utils.GenerateJWT(user.ID, &role, false, time.Now(), auth_utils.AccessTokenExpiry)
+		require.NoError(t, err)
+
+		claims, err := auth_utils.ValidateJWT(accessToken, auth_utils.AccessTokenType)
+		require.NoError(t, err)
+		assert.Nil(t, claims.Act)
+	})
+}
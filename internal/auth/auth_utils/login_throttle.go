@@ -0,0 +1,197 @@
+package auth_utils
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_repositories"
+	"github.com/google/uuid"
+)
+
+// Login throttle scopes
+const (
+	ThrottleScopeEmail = "email"
+	ThrottleScopeIP    = "ip"
+	ThrottleScopeMFA   = "mfa" // Wrong two-factor codes, keyed by user ID
+)
+
+const (
+	// Failed logins allowed before an account or IP address is locked out.
+	// IP addresses get more room since several students can share one campus address.
+	AccountLockoutThreshold = 5
+	IPLockoutThreshold      = 20
+	MFALockoutThreshold     = 5
+
+	// The first lockout lasts LockoutBaseDuration and doubles with every further
+	// failure, up to LockoutMaxDuration
+	LockoutBaseDuration = time.Minute
+	LockoutMaxDuration  = time.Hour
+
+	// Failures are forgotten once there hasn't been one for this long
+	LoginFailureWindow = 24 * time.Hour
+)
+
+// LoginLockedError is returned when an account or IP address is locked out after too many
+// failed logins, or a user after too many wrong two-factor codes
+type LoginLockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginLockedError) Error() string {
+	return fmt.Sprintf("too many failed login attempts, try again in %s", e.RetryAfter.Round(time.Second))
+}
+
+// LockoutDuration returns how long to lock out after the given number of consecutive failures
+func LockoutDuration(failures int, threshold int) time.Duration {
+	if failures < threshold {
+		return 0
+	}
+
+	exponent := failures - threshold
+	if exponent > 16 {
+		exponent = 16
+	}
+	lockout := LockoutBaseDuration * time.Duration(math.Pow(2, float64(exponent)))
+	if lockout > LockoutMaxDuration {
+		lockout = LockoutMaxDuration
+	}
+	return lockout
+}
+
+// NormalizeLoginEmail gives every spelling of an email address the same throttle key
+func NormalizeLoginEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// CheckLoginThrottle returns a *LoginLockedError if the email or IP address is locked out
+func CheckLoginThrottle(throttleRepo auth_repositories.LoginThrottleStore, email string, ipAddress string) error {
+	var retryAfter time.Duration
+	for _, key := range throttleKeys(email, ipAddress) {
+		throttle, err := throttleRepo.Get(key.scope, key.key)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if throttle.LockedUntil != nil {
+			if remaining := time.Until(*throttle.LockedUntil); remaining > retryAfter {
+				retryAfter = remaining
+			}
+		}
+	}
+
+	if retryAfter > 0 {
+		return &LoginLockedError{RetryAfter: retryAfter}
+	}
+	return nil
+}
+
+// RecordLoginFailure counts a failed login against the email and IP address, locking
+// them out once they pass their threshold. Each new lockout is written to the audit log.
+// userID is the account the email belongs to, or nil if there isn't one.
+func RecordLoginFailure(throttleRepo auth_repositories.LoginThrottleStore, auditRepo auth_repositories.AuditLogStore, email string, ipAddress string, userID *uuid.UUID) error {
+	for _, key := range throttleKeys(email, ipAddress) {
+		targetType, targetID := key.scope, key.key
+		if key.scope == ThrottleScopeEmail && userID != nil {
+			targetType, targetID = AuditTargetUser, userID.String()
+		}
+		if err := recordThrottleFailure(throttleRepo, auditRepo, key, ipAddress, targetType, targetID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ResetLoginFailures clears the failures counted against an email after a successful login.
+// IP addresses aren't reset, otherwise an attacker could clear their own count by
+// signing in to an account they control between guesses.
+func ResetLoginFailures(db *sql.DB, email string) error {
+	throttleRepo := auth_repositories.NewLoginThrottleRepository(db)
+	_, err := throttleRepo.Delete(ThrottleScopeEmail, NormalizeLoginEmail(email))
+	return err
+}
+
+// CheckMFAThrottle returns a *LoginLockedError if the user is locked out of entering
+// two-factor codes
+func CheckMFAThrottle(throttleRepo auth_repositories.LoginThrottleStore, userID uuid.UUID) error {
+	throttle, err := throttleRepo.Get(ThrottleScopeMFA, userID.String())
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if throttle.LockedUntil != nil {
+		if remaining := time.Until(*throttle.LockedUntil); remaining > 0 {
+			return &LoginLockedError{RetryAfter: remaining}
+		}
+	}
+	return nil
+}
+
+// RecordMFAFailure counts a wrong two-factor code against the user, locking them out of
+// entering codes once they pass MFALockoutThreshold. The count is kept apart from the
+// password failures for their email, so signing in with the right password doesn't reset it.
+func RecordMFAFailure(throttleRepo auth_repositories.LoginThrottleStore, auditRepo auth_repositories.AuditLogStore, userID uuid.UUID) error {
+	key := throttleKey{ThrottleScopeMFA, userID.String(), MFALockoutThreshold}
+	return recordThrottleFailure(throttleRepo, auditRepo, key, "", AuditTargetUser, userID.String())
+}
+
+// ResetMFAFailures clears the wrong two-factor codes counted against the user once they enter a right one
+func ResetMFAFailures(db *sql.DB, userID uuid.UUID) error {
+	throttleRepo := auth_repositories.NewLoginThrottleRepository(db)
+	_, err := throttleRepo.Delete(ThrottleScopeMFA, userID.String())
+	return err
+}
+
+// recordThrottleFailure counts a failure against the key and locks it out once it passes
+// its threshold. Each new lockout is written to the audit log against the given target.
+func recordThrottleFailure(throttleRepo auth_repositories.LoginThrottleStore, auditRepo auth_repositories.AuditLogStore, key throttleKey, ipAddress string, targetType string, targetID string) error {
+	throttle, err := throttleRepo.RecordFailure(key.scope, key.key, LoginFailureWindow)
+	if err != nil {
+		return err
+	}
+
+	lockout := LockoutDuration(throttle.Failures, key.threshold)
+	if lockout == 0 {
+		return nil
+	}
+
+	lockedUntil := time.Now().Add(lockout)
+	if err := throttleRepo.Lock(key.scope, key.key, lockedUntil); err != nil {
+		return err
+	}
+
+	details := map[string]interface{}{
+		"scope":        key.scope,
+		"key":          key.key,
+		"failures":     throttle.Failures,
+		"locked_until": lockedUntil,
+	}
+	if err := recordAudit(auditRepo, nil, AuditLoginLocked, targetType, targetID, ipAddress, details); err != nil {
+		log.Printf("Failed to record login lockout for %s %s: %v", key.scope, key.key, err)
+	}
+
+	return nil
+}
+
+type throttleKey struct {
+	scope     string
+	key       string
+	threshold int
+}
+
+func throttleKeys(email string, ipAddress string) []throttleKey {
+	keys := []throttleKey{{ThrottleScopeEmail, NormalizeLoginEmail(email), AccountLockoutThreshold}}
+	if ipAddress != "" {
+		keys = append(keys, throttleKey{ThrottleScopeIP, ipAddress, IPLockoutThreshold})
+	}
+	return keys
+}
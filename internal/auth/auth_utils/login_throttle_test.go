@@ -0,0 +1,257 @@
+package auth_utils_test
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_models"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_utils"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/mocks"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestLockoutDuration(t *testing.T) {
+	threshold := auth_utils.AccountLockoutThreshold
+
+	assert.Zero(t, auth_utils.LockoutDuration(threshold-1, threshold))
+	assert.Equal(t, auth_utils.LockoutBaseDuration, auth_utils.LockoutDuration(threshold, threshold))
+	assert.Equal(t, 2*auth_utils.LockoutBaseDuration, auth_utils.LockoutDuration(threshold+1, threshold))
+	assert.Equal(t, 8*auth_utils.LockoutBaseDuration, auth_utils.LockoutDuration(threshold+3, threshold))
+	assert.Equal(t, auth_utils.LockoutMaxDuration, auth_utils.LockoutDuration(threshold+100, threshold))
+	assert.LessOrEqual(t, auth_utils.LockoutDuration(threshold+10, threshold), time.Hour)
+}
+
+func TestCheckLoginThrottle(t *testing.T) {
+	const ip = "203.0.113.7"
+
+	t.Run("No failures", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		throttles := mocks.NewMockLoginThrottleStore(ctrl)
+
+		throttles.EXPECT().Get(auth_utils.ThrottleScopeEmail, "student@csusm.edu").Return(nil, sql.ErrNoRows)
+		throttles.EXPECT().Get(auth_utils.ThrottleScopeIP, ip).Return(nil, sql.ErrNoRows)
+
+		assert.NoError(t, auth_utils.CheckLoginThrottle(throttles, " Student@CSUSM.edu ", ip))
+	})
+
+	t.Run("Uses the longest lockout", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		throttles := mocks.NewMockLoginThrottleStore(ctrl)
+		emailLocked := time.Now().Add(time.Minute)
+		ipLocked := time.Now().Add(time.Hour)
+
+		throttles.EXPECT().Get(auth_utils.ThrottleScopeEmail, "student@csusm.edu").Return(&auth_models.LoginThrottle{Failures: 5, LockedUntil: &emailLocked}, nil)
+		throttles.EXPECT().Get(auth_utils.ThrottleScopeIP, ip).Return(&auth_models.LoginThrottle{Failures: 20, LockedUntil: &ipLocked}, nil)
+
+		err := auth_utils.CheckLoginThrottle(throttles, "student@csusm.edu", ip)
+		var lockedErr *auth_utils.LoginLockedError
+		require.ErrorAs(t, err, &lockedErr)
+		assert.InDelta(t, time.Hour.Seconds(), lockedErr.RetryAfter.Seconds(), 5)
+	})
+
+	t.Run("Lockout has ended", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		throttles := mocks.NewMockLoginThrottleStore(ctrl)
+		lockedUntil := time.Now().Add(-time.Second)
+
+		throttles.EXPECT().Get(auth_utils.ThrottleScopeEmail, "student@csusm.edu").Return(&auth_models.LoginThrottle{Failures: 5, LockedUntil: &lockedUntil}, nil)
+		throttles.EXPECT().Get(auth_utils.ThrottleScopeIP, ip).Return(nil, sql.ErrNoRows)
+
+		assert.NoError(t, auth_utils.CheckLoginThrottle(throttles, "student@csusm.edu", ip))
+	})
+
+	t.Run("Without an IP address", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		throttles := mocks.NewMockLoginThrottleStore(ctrl)
+
+		throttles.EXPECT().Get(auth_utils.ThrottleScopeEmail, "student@csusm.edu").Return(nil, sql.ErrNoRows)
+
+		assert.NoError(t, auth_utils.CheckLoginThrottle(throttles, "student@csusm.edu", ""))
+	})
+
+	t.Run("Store error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		throttles := mocks.NewMockLoginThrottleStore(ctrl)
+		storeErr := errors.New("connection refused")
+
+		throttles.EXPECT().Get(auth_utils.ThrottleScopeEmail, "student@csusm.edu").Return(nil, storeErr)
+
+		assert.ErrorIs(t, auth_utils.CheckLoginThrottle(throttles, "student@csusm.edu", ip), storeErr)
+	})
+}
+
+func TestRecordLoginFailure(t *testing.T) {
+	const ip = "203.0.113.7"
+
+	failures := func(scope string, key string, count int) *auth_models.LoginThrottle {
+		return &auth_models.LoginThrottle{Scope: scope, Key: key, Failures: count, LastFailureAt: time.Now()}
+	}
+
+	t.Run("Below the thresholds", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		throttles := mocks.NewMockLoginThrottleStore(ctrl)
+
+		throttles.EXPECT().RecordFailure(auth_utils.ThrottleScopeEmail, "student@csusm.edu", auth_utils.LoginFailureWindow).
+			Return(failures(auth_utils.ThrottleScopeEmail, "student@csusm.edu", auth_utils.AccountLockoutThreshold-1), nil)
+		throttles.EXPECT().RecordFailure(auth_utils.ThrottleScopeIP, ip, auth_utils.LoginFailureWindow).
+			Return(failures(auth_utils.ThrottleScopeIP, ip, auth_utils.AccountLockoutThreshold), nil)
+
+		err := auth_utils.RecordLoginFailure(throttles, mocks.NewMockAuditLogStore(ctrl), "Student@csusm.edu", ip, nil)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Locks the account and audits it against the user", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		throttles := mocks.NewMockLoginThrottleStore(ctrl)
+		audit := mocks.NewMockAuditLogStore(ctrl)
+		userID := uuid.New()
+
+		throttles.EXPECT().RecordFailure(auth_utils.ThrottleScopeEmail, "student@csusm.edu", auth_utils.LoginFailureWindow).
+			Return(failures(auth_utils.ThrottleScopeEmail, "student@csusm.edu", auth_utils.AccountLockoutThreshold), nil)
+		throttles.EXPECT().RecordFailure(auth_utils.ThrottleScopeIP, ip, auth_utils.LoginFailureWindow).
+			Return(failures(auth_utils.ThrottleScopeIP, ip, 1), nil)
+		throttles.EXPECT().Lock(auth_utils.ThrottleScopeEmail, "student@csusm.edu", gomock.Any()).DoAndReturn(func(scope string, key string, until time.Time) error {
+			assert.WithinDuration(t, time.Now().Add(auth_utils.LockoutBaseDuration), until, 5*time.Second)
+			return nil
+		})
+		audit.EXPECT().Create(gomock.Any()).DoAndReturn(func(entry *auth_models.AuditLog) error {
+			assert.Nil(t, entry.ActorID)
+			assert.Equal(t, auth_utils.AuditLoginLocked, entry.Action)
+			assert.Equal(t, auth_utils.AuditTargetUser, entry.TargetType)
+			assert.Equal(t, userID.String(), entry.TargetID)
+
+			var details map[string]interface{}
+			require.NoError(t, json.Unmarshal(entry.Details, &details))
+			assert.Equal(t, auth_utils.ThrottleScopeEmail, details["scope"])
+			return nil
+		})
+
+		err := auth_utils.RecordLoginFailure(throttles, audit, "student@csusm.edu", ip, &userID)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Locks the IP address", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		throttles := mocks.NewMockLoginThrottleStore(ctrl)
+		audit := mocks.NewMockAuditLogStore(ctrl)
+
+		throttles.EXPECT().RecordFailure(auth_utils.ThrottleScopeEmail, "unknown@csusm.edu", auth_utils.LoginFailureWindow).
+			Return(failures(auth_utils.ThrottleScopeEmail, "unknown@csusm.edu", 1), nil)
+		throttles.EXPECT().RecordFailure(auth_utils.ThrottleScopeIP, ip, auth_utils.LoginFailureWindow).
+			Return(failures(auth_utils.ThrottleScopeIP, ip, auth_utils.IPLockoutThreshold), nil)
+		throttles.EXPECT().Lock(auth_utils.ThrottleScopeIP, ip, gomock.Any()).Return(nil)
+		audit.EXPECT().Create(gomock.Any()).DoAndReturn(func(entry *auth_models.AuditLog) error {
+			assert.Equal(t, auth_utils.ThrottleScopeIP, entry.TargetType)
+			assert.Equal(t, ip, entry.TargetID)
+			return nil
+		})
+
+		err := auth_utils.RecordLoginFailure(throttles, audit, "unknown@csusm.edu", ip, nil)
+		assert.NoError(t, err)
+	})
+
+	t.Run("A failed audit doesn't fail the login", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		throttles := mocks.NewMockLoginThrottleStore(ctrl)
+		audit := mocks.NewMockAuditLogStore(ctrl)
+
+		throttles.EXPECT().RecordFailure(auth_utils.ThrottleScopeEmail, "student@csusm.edu", auth_utils.LoginFailureWindow).
+			Return(failures(auth_utils.ThrottleScopeEmail, "student@csusm.edu", auth_utils.AccountLockoutThreshold+1), nil)
+		throttles.EXPECT().Lock(auth_utils.ThrottleScopeEmail, "student@csusm.edu", gomock.Any()).Return(nil)
+		audit.EXPECT().Create(gomock.Any()).Return(errors.New("connection refused"))
+
+		err := auth_utils.RecordLoginFailure(throttles, audit, "student@csusm.edu", "", nil)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Store error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		throttles := mocks.NewMockLoginThrottleStore(ctrl)
+		storeErr := errors.New("connection refused")
+
+		throttles.EXPECT().RecordFailure(auth_utils.ThrottleScopeEmail, "student@csusm.edu", auth_utils.LoginFailureWindow).Return(nil, storeErr)
+
+		err := auth_utils.RecordLoginFailure(throttles, mocks.NewMockAuditLogStore(ctrl), "student@csusm.edu", ip, nil)
+		assert.ErrorIs(t, err, storeErr)
+	})
+}
+
+func TestCheckMFAThrottle(t *testing.T) {
+	userID := uuid.New()
+
+	t.Run("No wrong codes", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		throttles := mocks.NewMockLoginThrottleStore(ctrl)
+
+		throttles.EXPECT().Get(auth_utils.ThrottleScopeMFA, userID.String()).Return(nil, sql.ErrNoRows)
+
+		assert.NoError(t, auth_utils.CheckMFAThrottle(throttles, userID))
+	})
+
+	t.Run("Locked out", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		throttles := mocks.NewMockLoginThrottleStore(ctrl)
+		lockedUntil := time.Now().Add(time.Minute)
+
+		throttles.EXPECT().Get(auth_utils.ThrottleScopeMFA, userID.String()).Return(&auth_models.LoginThrottle{Failures: 5, LockedUntil: &lockedUntil}, nil)
+
+		var lockedErr *auth_utils.LoginLockedError
+		require.ErrorAs(t, auth_utils.CheckMFAThrottle(throttles, userID), &lockedErr)
+		assert.InDelta(t, time.Minute.Seconds(), lockedErr.RetryAfter.Seconds(), 5)
+	})
+
+	t.Run("Lockout has ended", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		throttles := mocks.NewMockLoginThrottleStore(ctrl)
+		lockedUntil := time.Now().Add(-time.Second)
+
+		throttles.EXPECT().Get(auth_utils.ThrottleScopeMFA, userID.String()).Return(&auth_models.LoginThrottle{Failures: 5, LockedUntil: &lockedUntil}, nil)
+
+		assert.NoError(t, auth_utils.CheckMFAThrottle(throttles, userID))
+	})
+}
+
+func TestRecordMFAFailure(t *testing.T) {
+	userID := uuid.New()
+
+	t.Run("Below the threshold", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		throttles := mocks.NewMockLoginThrottleStore(ctrl)
+
+		throttles.EXPECT().RecordFailure(auth_utils.ThrottleScopeMFA, userID.String(), auth_utils.LoginFailureWindow).
+			Return(&auth_models.LoginThrottle{Failures: auth_utils.MFALockoutThreshold - 1}, nil)
+
+		assert.NoError(t, auth_utils.RecordMFAFailure(throttles, mocks.NewMockAuditLogStore(ctrl), userID))
+	})
+
+	t.Run("Locks the user out and audits it", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		throttles := mocks.NewMockLoginThrottleStore(ctrl)
+		audit := mocks.NewMockAuditLogStore(ctrl)
+
+		throttles.EXPECT().RecordFailure(auth_utils.ThrottleScopeMFA, userID.String(), auth_utils.LoginFailureWindow).
+			Return(&auth_models.LoginThrottle{Failures: auth_utils.MFALockoutThreshold}, nil)
+		throttles.EXPECT().Lock(auth_utils.ThrottleScopeMFA, userID.String(), gomock.Any()).DoAndReturn(func(scope string, key string, until time.Time) error {
+			assert.WithinDuration(t, time.Now().Add(auth_utils.LockoutBaseDuration), until, 5*time.Second)
+			return nil
+		})
+		audit.EXPECT().Create(gomock.Any()).DoAndReturn(func(entry *auth_models.AuditLog) error {
+			assert.Equal(t, auth_utils.AuditLoginLocked, entry.Action)
+			assert.Equal(t, auth_utils.AuditTargetUser, entry.TargetType)
+			assert.Equal(t, userID.String(), entry.TargetID)
+
+			var details map[string]interface{}
+			require.NoError(t, json.Unmarshal(entry.Details, &details))
+			assert.Equal(t, auth_utils.ThrottleScopeMFA, details["scope"])
+			return nil
+		})
+
+		assert.NoError(t, auth_utils.RecordMFAFailure(throttles, audit, userID))
+	})
+}
@@ -0,0 +1,103 @@
+package auth_utils
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_models"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_repositories"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/mailer"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/models"
+	"github.com/google/uuid"
+)
+
+var MagicLinkTokenExpiry = time.Minute * 15 // Expiry time of a sign-in link
+
+// RequestMagicLink emails a single-use sign-in link. Emails without an account get a link
+// only if the registration policy would let them sign up, in which case following the link
+// creates the account. Nothing tells the caller which case applied, so the endpoint can't
+// be used to discover which emails are registered.
+func RequestMagicLink(db *sql.DB, m mailer.Mailer, locale string, email string, inviteCode string) error {
+	userRepo := auth_repositories.NewUserRepository(db)
+
+	userID := uuid.Nil
+	user, err := userRepo.GetByEmail(email)
+	switch {
+	case err == nil:
+		userID = user.ID
+		email = user.Email
+	case errors.Is(err, sql.ErrNoRows):
+		// Invites are checked when the link is used, everything else can be checked now
+		if inviteCode == "" && registrationOpenTo(&email, true) != nil {
+			return nil
+		}
+	default:
+		return err
+	}
+
+	token, err := GeneratePurposeToken(userID, PurposeMagicLogin, email, MagicLinkTokenExpiry)
+	if err != nil {
+		return err
+	}
+
+	return SendMagicLinkEmail(m, locale, email, token)
+}
+
+// ConsumeMagicLink uses up a sign-in link and returns the user to create a session for,
+// registering them first if the email has no account yet. Following the link proves the
+// user owns the inbox, so the email is marked verified. created reports whether the
+// account is new.
+//
+// An existing account that wasn't verified may have been registered by someone else with
+// the owner's email, so it is handed over to the owner: its password, two-factor
+// authentication, sessions, personal access tokens and unverified identities are removed.
+func ConsumeMagicLink(usedTokenRepo auth_repositories.UsedTokenStore, userRepo auth_repositories.UserStore, inviteRepo auth_repositories.InviteStore, token string, inviteCode string) (user *models.User, created bool, err error) {
+	claims, err := consumePurposeToken(usedTokenRepo, token, PurposeMagicLogin)
+	if err != nil {
+		if err == ErrTokenAlreadyUsed {
+			return nil, false, ErrInvalidToken
+		}
+		return nil, false, err
+	}
+
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil || claims.Email == "" {
+		return nil, false, ErrInvalidToken
+	}
+
+	user, err = userRepo.GetByEmail(claims.Email)
+	switch {
+	case err == nil:
+		// A link sent to an existing account only signs in to that account
+		if userID != uuid.Nil && user.ID != userID {
+			return nil, false, ErrInvalidToken
+		}
+	case errors.Is(err, sql.ErrNoRows):
+		if userID != uuid.Nil {
+			return nil, false, ErrInvalidToken
+		}
+		email := claims.Email
+		user, err = registerUser(userRepo, inviteRepo, auth_models.CreateUserTraditionalAuthRequest{
+			Email:      &email,
+			InviteCode: &inviteCode,
+		}, true)
+		if err != nil {
+			return nil, false, err
+		}
+		return user, true, nil
+	default:
+		return nil, false, err
+	}
+
+	if !user.EmailVerified {
+		if err := userRepo.ClaimUnverifiedAccount(user.ID); err != nil {
+			return nil, false, err
+		}
+		user.EmailVerified = true
+		user.TOTPEnabled = false
+	}
+
+	user.Password = nil
+	return user, false, nil
+}
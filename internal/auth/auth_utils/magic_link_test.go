@@ -0,0 +1,199 @@
+package auth_utils_test
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_models"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_repositories"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_utils"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/mocks"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// newMagicLink returns a sign-in link token for the email. userID is uuid.Nil for emails
+// without an account.
+func newMagicLink(t *testing.T, userID uuid.UUID, email string) string {
+	token, err := auth_utils.GeneratePurposeToken(userID, auth_utils.PurposeMagicLogin, email, auth_utils.MagicLinkTokenExpiry)
+	require.NoError(t, err)
+	return token
+}
+
+// expectFirstUse expects the link to be marked as used. userID is nil for sign-up links.
+func expectFirstUse(usedTokens *mocks.MockUsedTokenStore, userID *uuid.UUID, firstUse bool) {
+	usedTokens.EXPECT().DeleteExpired().Return(nil)
+	usedTokens.EXPECT().MarkUsed(gomock.Any(), auth_utils.PurposeMagicLogin.String(), userID, gomock.Any()).Return(firstUse, nil)
+}
+
+func TestConsumeMagicLink(t *testing.T) {
+	const email = "student@csusm.edu"
+
+	t.Run("Signs in to a verified account", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		usedTokens := mocks.NewMockUsedTokenStore(ctrl)
+		users := mocks.NewMockUserStore(ctrl)
+		user := &models.User{ID: uuid.New(), Email: email, Password: ptr("hash"), EmailVerified: true}
+
+		expectFirstUse(usedTokens, &user.ID, true)
+		users.EXPECT().GetByEmail(email).Return(user, nil)
+
+		found, created, err := auth_utils.ConsumeMagicLink(usedTokens, users, mocks.NewMockInviteStore(ctrl), newMagicLink(t, user.ID, email), "")
+		require.NoError(t, err)
+		assert.False(t, created)
+		assert.Equal(t, user.ID, found.ID)
+		assert.Nil(t, found.Password)
+	})
+
+	t.Run("Hands an unverified account over to the owner of the email", func(t *testing.T) {
+		// Someone else may have registered the account with a password and enabled
+		// two-factor authentication before the owner of the email signed in
+		ctrl := gomock.NewController(t)
+		usedTokens := mocks.NewMockUsedTokenStore(ctrl)
+		users := mocks.NewMockUserStore(ctrl)
+		user := &models.User{ID: uuid.New(), Email: email, Password: ptr("hash"), TOTPEnabled: true}
+
+		expectFirstUse(usedTokens, &user.ID, true)
+		users.EXPECT().GetByEmail(email).Return(user, nil)
+		users.EXPECT().ClaimUnverifiedAccount(user.ID).Return(nil)
+
+		found, created, err := auth_utils.ConsumeMagicLink(usedTokens, users, mocks.NewMockInviteStore(ctrl), newMagicLink(t, user.ID, email), "")
+		require.NoError(t, err)
+		assert.False(t, created)
+		assert.True(t, found.EmailVerified)
+		assert.False(t, found.TOTPEnabled)
+		assert.Nil(t, found.Password)
+	})
+
+	t.Run("Registers a new account", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		usedTokens := mocks.NewMockUsedTokenStore(ctrl)
+		users := mocks.NewMockUserStore(ctrl)
+
+		expectFirstUse(usedTokens, nil, true)
+		users.EXPECT().GetByEmail(email).Return(nil, sql.ErrNoRows)
+		users.EXPECT().EmailExists(email).Return(false, nil)
+		users.EXPECT().Create(gomock.Any()).DoAndReturn(func(user *models.User) error {
+			assert.Equal(t, email, user.Email)
+			assert.Nil(t, user.Password)
+			assert.True(t, user.EmailVerified, "following the link proves the email")
+			return nil
+		})
+
+		found, created, err := auth_utils.ConsumeMagicLink(usedTokens, users, mocks.NewMockInviteStore(ctrl), newMagicLink(t, uuid.Nil, email), "")
+		require.NoError(t, err)
+		assert.True(t, created)
+		assert.Equal(t, email, found.Email)
+	})
+
+	t.Run("Sign-up links are used up without a user", func(t *testing.T) {
+		// used_tokens.user_id references users, so the nil UUID of a sign-up link can't be stored
+		ctrl := gomock.NewController(t)
+		usedTokens := mocks.NewMockUsedTokenStore(ctrl)
+		users := mocks.NewMockUserStore(ctrl)
+
+		usedTokens.EXPECT().DeleteExpired().Return(nil)
+		usedTokens.EXPECT().MarkUsed(gomock.Any(), auth_utils.PurposeMagicLogin.String(), gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ uuid.UUID, _ string, userID *uuid.UUID, _ time.Time) (bool, error) {
+				assert.Nil(t, userID)
+				return true, nil
+			})
+		users.EXPECT().GetByEmail(email).Return(nil, sql.ErrNoRows)
+		users.EXPECT().EmailExists(email).Return(false, nil)
+		users.EXPECT().Create(gomock.Any()).Return(nil)
+
+		_, created, err := auth_utils.ConsumeMagicLink(usedTokens, users, mocks.NewMockInviteStore(ctrl), newMagicLink(t, uuid.Nil, email), "")
+		require.NoError(t, err)
+		assert.True(t, created)
+	})
+
+	t.Run("Registers with an invite for the email", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		usedTokens := mocks.NewMockUsedTokenStore(ctrl)
+		users := mocks.NewMockUserStore(ctrl)
+		invites := mocks.NewMockInviteStore(ctrl)
+		position := models.Student
+		invite := &auth_models.Invite{ID: uuid.New(), Email: ptr("Student@csusm.edu"), Position: &position, MaxUses: 1, ExpiresAt: time.Now().Add(time.Hour)}
+
+		expectFirstUse(usedTokens, nil, true)
+		users.EXPECT().GetByEmail(email).Return(nil, sql.ErrNoRows)
+		users.EXPECT().EmailExists(email).Return(false, nil)
+		invites.EXPECT().GetByCodeHash(auth_repositories.HashToken("invite-code")).Return(invite, nil)
+		invites.EXPECT().Redeem(invite.ID).Return(nil)
+		users.EXPECT().Create(gomock.Any()).Return(nil)
+		users.EXPECT().Update(gomock.Any(), auth_models.UpdateUserRequest{Position: &position}).Return(nil)
+
+		_, created, err := auth_utils.ConsumeMagicLink(usedTokens, users, invites, newMagicLink(t, uuid.Nil, email), "invite-code")
+		require.NoError(t, err)
+		assert.True(t, created)
+	})
+
+	t.Run("Gives the invite back when the account can't be created", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		usedTokens := mocks.NewMockUsedTokenStore(ctrl)
+		users := mocks.NewMockUserStore(ctrl)
+		invites := mocks.NewMockInviteStore(ctrl)
+		invite := &auth_models.Invite{ID: uuid.New(), MaxUses: 1, ExpiresAt: time.Now().Add(time.Hour)}
+
+		expectFirstUse(usedTokens, nil, true)
+		users.EXPECT().GetByEmail(email).Return(nil, sql.ErrNoRows)
+		users.EXPECT().EmailExists(email).Return(false, nil)
+		invites.EXPECT().GetByCodeHash(auth_repositories.HashToken("invite-code")).Return(invite, nil)
+		invites.EXPECT().Redeem(invite.ID).Return(nil)
+		users.EXPECT().Create(gomock.Any()).Return(sql.ErrConnDone)
+		invites.EXPECT().Release(invite.ID).Return(nil)
+
+		_, _, err := auth_utils.ConsumeMagicLink(usedTokens, users, invites, newMagicLink(t, uuid.Nil, email), "invite-code")
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+	})
+
+	t.Run("Link for an account that was deleted", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		usedTokens := mocks.NewMockUsedTokenStore(ctrl)
+		users := mocks.NewMockUserStore(ctrl)
+		userID := uuid.New()
+
+		expectFirstUse(usedTokens, &userID, true)
+		users.EXPECT().GetByEmail(email).Return(nil, sql.ErrNoRows)
+
+		_, _, err := auth_utils.ConsumeMagicLink(usedTokens, users, mocks.NewMockInviteStore(ctrl), newMagicLink(t, userID, email), "")
+		assert.ErrorIs(t, err, auth_utils.ErrInvalidToken)
+	})
+
+	t.Run("Email now belongs to another account", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		usedTokens := mocks.NewMockUsedTokenStore(ctrl)
+		users := mocks.NewMockUserStore(ctrl)
+		userID := uuid.New()
+
+		expectFirstUse(usedTokens, &userID, true)
+		users.EXPECT().GetByEmail(email).Return(&models.User{ID: uuid.New(), Email: email, EmailVerified: true}, nil)
+
+		_, _, err := auth_utils.ConsumeMagicLink(usedTokens, users, mocks.NewMockInviteStore(ctrl), newMagicLink(t, userID, email), "")
+		assert.ErrorIs(t, err, auth_utils.ErrInvalidToken)
+	})
+
+	t.Run("Link already used", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		usedTokens := mocks.NewMockUsedTokenStore(ctrl)
+		userID := uuid.New()
+
+		expectFirstUse(usedTokens, &userID, false)
+
+		_, _, err := auth_utils.ConsumeMagicLink(usedTokens, mocks.NewMockUserStore(ctrl), mocks.NewMockInviteStore(ctrl), newMagicLink(t, userID, email), "")
+		assert.ErrorIs(t, err, auth_utils.ErrInvalidToken)
+	})
+
+	t.Run("Token for another purpose", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		token, err := auth_utils.GeneratePurposeToken(uuid.New(), auth_utils.PurposeResetPassword, email, time.Hour)
+		require.NoError(t, err)
+
+		_, _, err = auth_utils.ConsumeMagicLink(mocks.NewMockUsedTokenStore(ctrl), mocks.NewMockUserStore(ctrl), mocks.NewMockInviteStore(ctrl), token, "")
+		assert.ErrorIs(t, err, auth_utils.ErrInvalidToken)
+	})
+}
@@ -0,0 +1,276 @@
+package auth_utils
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/base32"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/csusmGDSC/csusmgdsc-api/config"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_repositories"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/models"
+	"github.com/google/uuid"
+)
+
+var (
+	MFAPendingTokenExpiry = time.Minute * 5 // Time a user has to enter their code after a password login
+	RecoveryCodeCount     = 10
+)
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// MFARequired reports whether the user's sessions must have passed two-factor authentication
+// to use admin routes. It covers everyone who holds a permission, so officers with e.g.
+// events:write need it as well as admins.
+func MFARequired(db *sql.DB, userID uuid.UUID) (bool, error) {
+	if !config.LoadConfig().MFARequiredForAdmins {
+		return false, nil
+	}
+
+	rbacRepo := auth_repositories.NewRBACRepository(db)
+	permissions, err := rbacRepo.GetPermissionsByUserID(userID)
+	if err != nil {
+		return false, err
+	}
+
+	return len(permissions) > 0, nil
+}
+
+// EnrollTOTP generates a new secret for the user and returns it with the otpauth:// URI
+// to show as a QR code. TOTP isn't enabled until the user confirms a code with ConfirmTOTP.
+func EnrollTOTP(db *sql.DB, userID uuid.UUID, email string) (string, string, error) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		return "", "", err
+	}
+
+	mfaRepo := auth_repositories.NewMFARepository(db)
+	stored, err := mfaRepo.SetPendingTOTPSecret(userID, secret)
+	if err != nil {
+		return "", "", err
+	}
+	if !stored {
+		return "", "", ErrMFAAlreadyEnabled
+	}
+
+	return secret, TOTPURI(secret, email), nil
+}
+
+// ConfirmTOTP enables TOTP once the user proves their authenticator app produces valid codes.
+// Returns the recovery codes, which are only ever shown this once.
+func ConfirmTOTP(db *sql.DB, userID uuid.UUID, code string) ([]string, error) {
+	mfaRepo := auth_repositories.NewMFARepository(db)
+
+	settings, err := mfaRepo.GetTOTP(userID)
+	if err != nil {
+		return nil, err
+	}
+	if settings.Enabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+	if settings.Secret == nil {
+		return nil, ErrMFANotEnrolled
+	}
+
+	err = checkMFACode(db, userID, func() error {
+		return useTOTPCode(mfaRepo, userID, *settings.Secret, code)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := mfaRepo.EnableTOTP(userID, hashes); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// VerifyMFA checks a TOTP code or, if no code is given, a recovery code.
+// Each code can only be used once. Returns a *LoginLockedError after too many wrong codes.
+func VerifyMFA(db *sql.DB, userID uuid.UUID, code string, recoveryCode string) error {
+	mfaRepo := auth_repositories.NewMFARepository(db)
+
+	settings, err := mfaRepo.GetTOTP(userID)
+	if err != nil {
+		return err
+	}
+	if !settings.Enabled || settings.Secret == nil {
+		return ErrMFANotEnrolled
+	}
+
+	return checkMFACode(db, userID, func() error {
+		return verifyMFACode(mfaRepo, userID, *settings.Secret, code, recoveryCode)
+	})
+}
+
+// verifyMFACode uses up a TOTP code or, if none is given, a recovery code
+func verifyMFACode(mfaRepo *auth_repositories.MFARepository, userID uuid.UUID, secret string, code string, recoveryCode string) error {
+	if code != "" {
+		return useTOTPCode(mfaRepo, userID, secret, code)
+	}
+
+	if recoveryCode != "" {
+		used, err := mfaRepo.UseRecoveryCode(userID, auth_repositories.HashToken(normalizeRecoveryCode(recoveryCode)))
+		if err != nil {
+			return err
+		}
+		if !used {
+			return ErrInvalidMFACode
+		}
+		return nil
+	}
+
+	return ErrInvalidMFACode
+}
+
+// DisableTOTP turns off two-factor authentication after checking a code.
+// Users can't disable it while it's required for their permissions.
+func DisableTOTP(db *sql.DB, userID uuid.UUID, code string, recoveryCode string) error {
+	required, err := MFARequired(db, userID)
+	if err != nil {
+		return err
+	}
+	if required {
+		return ErrMFARequired
+	}
+
+	if err := VerifyMFA(db, userID, code, recoveryCode); err != nil {
+		return err
+	}
+
+	mfaRepo := auth_repositories.NewMFARepository(db)
+	return mfaRepo.DisableTOTP(userID)
+}
+
+// RegenerateRecoveryCodes replaces the user's recovery codes after checking a code
+func RegenerateRecoveryCodes(db *sql.DB, userID uuid.UUID, code string) ([]string, error) {
+	if err := VerifyMFA(db, userID, code, ""); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	mfaRepo := auth_repositories.NewMFARepository(db)
+	if err := mfaRepo.ReplaceRecoveryCodes(userID, hashes); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// BeginMFALogin returns the short-lived token a user exchanges, together with a code,
+// for a session once their password has been checked.
+func BeginMFALogin(user *models.User) (string, error) {
+	return GeneratePurposeToken(user.ID, PurposeMFAPending, user.Email, MFAPendingTokenExpiry)
+}
+
+// CompleteMFALogin checks the code for an mfa_pending token and returns the user to create
+// a session for. The token is consumed on the first attempt, so a stolen token can't be
+// used to guess codes; a wrong code means signing in again.
+func CompleteMFALogin(db *sql.DB, mfaToken string, code string, recoveryCode string) (*models.User, error) {
+	claims, err := ConsumePurposeToken(db, mfaToken, PurposeMFAPending)
+	if err != nil {
+		return nil, err
+	}
+
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	if err := VerifyMFA(db, userID, code, recoveryCode); err != nil {
+		return nil, err
+	}
+
+	userRepo := auth_repositories.NewUserRepository(db)
+	user, err := userRepo.GetByID(userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrInvalidToken
+		}
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// checkMFACode runs verify unless the user is locked out of entering codes, counting a
+// wrong code against them. Password logins, the mfa_pending token and authenticated
+// routes like DisableTOTP all share the one count, so none of them can be used to keep guessing.
+func checkMFACode(db *sql.DB, userID uuid.UUID, verify func() error) error {
+	throttleRepo := auth_repositories.NewLoginThrottleRepository(db)
+	if err := CheckMFAThrottle(throttleRepo, userID); err != nil {
+		return err
+	}
+
+	if err := verify(); err != nil {
+		if err == ErrInvalidMFACode {
+			auditRepo := auth_repositories.NewAuditLogRepository(db)
+			if err := RecordMFAFailure(throttleRepo, auditRepo, userID); err != nil {
+				return err
+			}
+		}
+		return err
+	}
+
+	if err := ResetMFAFailures(db, userID); err != nil {
+		log.Printf("Failed to reset two-factor failures: %v", err)
+	}
+	return nil
+}
+
+func useTOTPCode(mfaRepo *auth_repositories.MFARepository, userID uuid.UUID, secret string, code string) error {
+	step, ok := ValidateTOTPCode(secret, code, time.Now())
+	if !ok {
+		return ErrInvalidMFACode
+	}
+
+	// Reject a code that was already used, even if it's still inside its time window
+	fresh, err := mfaRepo.UseTOTPStep(userID, step)
+	if err != nil {
+		return err
+	}
+	if !fresh {
+		return ErrInvalidMFACode
+	}
+
+	return nil
+}
+
+// generateRecoveryCodes returns codes formatted for display and their hashes for storage
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, RecoveryCodeCount)
+	hashes := make([]string, 0, RecoveryCodeCount)
+
+	for i := 0; i < RecoveryCodeCount; i++ {
+		b := make([]byte, 10) // 80 bits, 16 base32 characters
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		raw := strings.ToLower(recoveryCodeEncoding.EncodeToString(b))
+
+		codes = append(codes, raw[0:4]+"-"+raw[4:8]+"-"+raw[8:12]+"-"+raw[12:16])
+		hashes = append(hashes, auth_repositories.HashToken(raw))
+	}
+
+	return codes, hashes, nil
+}
+
+// normalizeRecoveryCode strips the formatting users may or may not type
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	code = strings.ReplaceAll(code, "-", "")
+	return strings.ReplaceAll(code, " ", "")
+}
@@ -3,8 +3,9 @@ package auth_utils
 import (
 	"context"
 	"database/sql"
-	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
 	"time"
 
@@ -13,185 +14,126 @@ import (
 	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_repositories"
 	"github.com/csusmGDSC/csusmgdsc-api/internal/models"
 	"github.com/google/uuid"
-	"golang.org/x/oauth2"
 )
 
+// RegisterUserOAuthToDatabase creates a user for a first time OAuth login together with
+// the identity they signed in with
 func RegisterUserOAuthToDatabase(db *sql.DB, req auth_models.CreateUserOAuthRequest) (*models.User, error) {
 	userRepo := auth_repositories.NewUserRepository(db)
+	identityRepo := auth_repositories.NewIdentityRepository(db)
+	inviteRepo := auth_repositories.NewInviteRepository(db)
 
-	if req.AuthID == nil {
-		return nil, fmt.Errorf("auth_id is required")
+	if req.AuthID == nil || req.Provider == nil {
+		return nil, fmt.Errorf("provider and auth_id are required")
 	}
 
-	exists, err := userRepo.AuthIDExists(*req.AuthID)
-	if err != nil {
-		return nil, err
-	}
-	if exists {
+	_, err := identityRepo.GetByProviderUserID(*req.Provider, *req.AuthID)
+	if err == nil {
 		return nil, ErrUserExists
 	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
 
 	email := "not set"
 	if req.Email != nil {
 		email = *req.Email
-	}
-
-	user := &models.User{
-		ID:          uuid.New(),
-		Email:       email,
-		Password:    nil,
-		Provider:    req.Provider,
-		AuthID:      req.AuthID,
-		Image:       req.Image,
-		FullName:    req.Name,
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
-		IsOnboarded: false,
-	}
-
-	err = userRepo.Create(user)
-	if err != nil {
-		return nil, err
-	}
-
-	return user, nil
-}
-
-// GetOAuthURL generates an OAuth URL for the provider
-func GetOAuthURL(provider string) (string, error) {
-	githubConfig := auth.GetGitHubConfig()
-	googleConfig := auth.GetGoogleConfig()
-	switch provider {
-	case "github":
-		if githubConfig == nil {
-			return "", fmt.Errorf("github OAuth not initialized")
-		}
-		return githubConfig.AuthCodeURL("state"), nil
-	case "google":
-		if googleConfig == nil {
-			return "", fmt.Errorf("google OAuth not initialized")
-		}
-		return googleConfig.AuthCodeURL("state"), nil
-	default:
-		return "", fmt.Errorf("unsupported provider: %s", provider)
-	}
-}
 
-// HandleOAuthCallback handles OAuth2 callback and returns user data
-func HandleOAuthCallback(provider string, code string) (*auth.OAuthUserData, error) {
-	githubConfig := auth.GetGitHubConfig()
-	googleConfig := auth.GetGoogleConfig()
-	var config *oauth2.Config
-	var userInfoURL string
-
-	switch provider {
-	case "github":
-		if githubConfig == nil {
-			return nil, fmt.Errorf("github OAuth not initialized")
+		exists, err := userRepo.EmailExists(email)
+		if err != nil {
+			return nil, err
 		}
-		config = githubConfig
-		userInfoURL = "https://api.github.com/user"
-	case "google":
-		if googleConfig == nil {
-			return nil, fmt.Errorf("google OAuth not initialized")
+		if exists {
+			return nil, ErrUserExists
 		}
-		config = googleConfig
-		userInfoURL = "https://www.googleapis.com/oauth2/v2/userinfo"
-	default:
-		return nil, fmt.Errorf("unsupported provider: %s", provider)
 	}
 
-	token, err := config.Exchange(context.Background(), code)
-	if err != nil {
-		return nil, fmt.Errorf("failed to exchange token: %v", err)
-	}
-	client := config.Client(context.Background(), token)
-	resp, err := client.Get(userInfoURL)
+	invite, err := redeemRegistration(inviteRepo, req.Email, req.EmailVerified, false, req.InviteCode)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get user info: %v", err)
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	var rawData map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&rawData); err != nil {
-		return nil, fmt.Errorf("failed to decode user info: %v", err)
+	now := time.Now()
+	user := &models.User{
+		ID:            uuid.New(),
+		Email:         email,
+		Password:      nil,
+		Provider:      req.Provider,
+		AuthID:        req.AuthID,
+		Image:         req.Image,
+		FullName:      req.Name,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+		IsOnboarded:   false,
+		EmailVerified: req.Email != nil && req.EmailVerified,
+	}
+
+	identity := &auth_models.UserIdentity{
+		ID:             uuid.New(),
+		UserID:         user.ID,
+		Provider:       *req.Provider,
+		ProviderUserID: *req.AuthID,
+		Email:          req.Email,
+		EmailVerified:  req.EmailVerified,
+		CreatedAt:      now,
+		LastUsedAt:     &now,
+	}
+
+	if err := userRepo.CreateWithIdentity(user, identity); err != nil {
+		releaseInvite(inviteRepo, invite)
+		return nil, err
 	}
 
-	userData := &auth.OAuthUserData{
-		Provider: provider,
+	if err := applyInvite(userRepo, user.ID, invite); err != nil {
+		return nil, err
 	}
 
-	switch provider {
-	case "github":
-		// Handle required ID field
-		if id, ok := rawData["id"].(float64); ok {
-			userData.ID = fmt.Sprintf("%.0f", id)
-		} else {
-			return nil, fmt.Errorf("invalid or missing github user ID")
-		}
-		if email, ok := rawData["email"].(string); ok && email != "" {
-			userData.Email = &email
-		}
-		if name, ok := rawData["name"].(string); ok && name != "" {
-			userData.Name = name
-		}
-		if avatar, ok := rawData["avatar_url"].(string); ok {
-			userData.AvatarURL = &avatar
-		}
-	case "google":
-		if id, ok := rawData["id"].(string); ok && id != "" {
-			userData.ID = id
-		}
-		if email, ok := rawData["email"].(string); ok && email != "" {
-			userData.Email = &email
-		}
-		if name, ok := rawData["name"].(string); ok && name != "" {
-			userData.Name = name
-		}
-		if picture, ok := rawData["picture"].(string); ok {
-			userData.AvatarURL = &picture
-		}
-	}
-	return userData, nil
+	return user, nil
 }
 
-func CreateLoginSession(dbConn *sql.DB, realIP string, userAgentKey string, user *models.User) (string, *http.Cookie, error) {
-
-	accessToken, err := GenerateJWT(user.ID, user.Role, AccessTokenExpiry)
+// GetOAuthURL generates an OAuth URL for the provider, sending the state, the S256 PKCE
+// challenge derived from the code verifier and, for OpenID Connect providers, the nonce
+func GetOAuthURL(provider string, state string, codeVerifier string, nonce string) (string, error) {
+	p, err := auth.GetProvider(provider)
 	if err != nil {
-		return "", nil, ErrAccessToken
+		return "", err
 	}
+	return p.AuthCodeURL(state, codeVerifier, nonce)
+}
 
-	refreshToken, issuedAt, expiresAt, err := GenerateRefreshToken(user.ID, user.Role, RefreshTokenExpiry)
+// HandleOAuthCallback handles OAuth2 callback and returns user data
+func HandleOAuthCallback(provider string, code string, codeVerifier string, nonce string) (*auth.OAuthUserData, error) {
+	p, err := auth.GetProvider(provider)
 	if err != nil {
-		return "", nil, ErrRefreshToken
+		return nil, err
 	}
+	return p.Exchange(context.Background(), code, codeVerifier, nonce)
+}
 
-	ipAddress := realIP
-	userAgent := userAgentKey
-	sessionReq := &auth_models.CreateSessionRequest{
-		UserID:    user.ID,
-		Token:     refreshToken,
-		IssuedAt:  issuedAt,
-		ExpiresAt: expiresAt,
-		IPAddress: ipAddress,
-		UserAgent: userAgent,
+// CreateLoginSession starts a new session. mfaVerified is true when the login passed
+// two-factor authentication. Signing in cancels a scheduled deletion of the account.
+// Returns an *AccountSuspendedError for suspended users, and ErrEmailNotVerified for
+// unverified users when registration isn't open.
+func CreateLoginSession(dbConn *sql.DB, realIP string, userAgentKey string, user *models.User, mfaVerified bool) (string, *http.Cookie, error) {
+	if !user.EmailVerified && EmailVerificationRequired() {
+		return "", nil, ErrEmailNotVerified
 	}
-
-	err = CreateSession(dbConn, *sessionReq)
-	if err != nil {
-		return "", nil, ErrNewSession
+	if err := CheckAccountStatus(dbConn, user.ID); err != nil {
+		return "", nil, err
 	}
 
-	cookie := &http.Cookie{
-		Name:     "refresh_token",
-		Value:    refreshToken,
-		HttpOnly: true,
-		// Secure = true TODO: Once in production set enable this line
-		SameSite: http.SameSiteStrictMode,
-		Path:     "/",
-		Expires:  expiresAt,
+	err := CancelAccountDeletion(auth_repositories.NewUserRepository(dbConn), user.ID)
+	if err == nil {
+		details := map[string]interface{}{"reason": "signed in"}
+		if err := RecordAudit(dbConn, &user.ID, AuditUserDeletionCancelled, AuditTargetUser, user.ID.String(), realIP, details); err != nil {
+			log.Printf("Failed to record cancelled deletion of user %s: %v", user.ID, err)
+		}
+	} else if err != ErrNoDeletionScheduled {
+		return "", nil, err
 	}
 
-	return accessToken, cookie, nil
+	refreshTokenRepo := auth_repositories.NewRefreshTokenRepository(dbConn)
+
+	// Every login starts a new refresh token family
+	return issueSession(refreshTokenRepo, user.ID, user.Role, mfaVerified, time.Now(), uuid.New(), nil, realIP, userAgentKey)
 }
@@ -0,0 +1,107 @@
+package auth_utils
+
+import (
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_models"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_repositories"
+	"github.com/google/uuid"
+	"golang.org/x/oauth2"
+)
+
+var OAuthStateExpiry = time.Minute * 10 // Time a user has to finish signing in with the provider
+
+// CreateOAuthState generates a random state and PKCE code verifier for a login attempt
+// and stores them until the provider redirects back to the callback.
+// linkUserID is set when a signed in user is linking the provider to their account, and
+// inviteCode when someone is signing up with an invite.
+func CreateOAuthState(oauthStateRepo auth_repositories.OAuthStateStore, provider string, linkUserID *uuid.UUID, inviteCode string) (*auth_models.OAuthState, error) {
+	if _, err := auth.GetProvider(provider); err != nil {
+		return nil, err
+	}
+
+	state, err := GenerateRandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce, err := GenerateRandomToken(16)
+	if err != nil {
+		return nil, err
+	}
+
+	oauthState := &auth_models.OAuthState{
+		State:        state,
+		Provider:     provider,
+		CodeVerifier: oauth2.GenerateVerifier(),
+		Nonce:        nonce,
+		LinkUserID:   linkUserID,
+		InviteCode:   inviteCode,
+		CreatedAt:    time.Now(),
+		ExpiresAt:    time.Now().Add(OAuthStateExpiry),
+	}
+
+	// Opportunistically clean up states from abandoned logins
+	if err := oauthStateRepo.DeleteExpired(); err != nil {
+		return nil, err
+	}
+
+	if err := oauthStateRepo.Create(oauthState); err != nil {
+		return nil, err
+	}
+
+	return oauthState, nil
+}
+
+// ConsumeOAuthState validates the state returned to the callback against the value bound
+// to the browser's cookie and the state stored for the provider, then deletes it so it
+// can't be replayed.
+func ConsumeOAuthState(oauthStateRepo auth_repositories.OAuthStateStore, provider string, state string, cookieState string) (*auth_models.OAuthState, error) {
+	if state == "" || subtle.ConstantTimeCompare([]byte(state), []byte(cookieState)) != 1 {
+		return nil, ErrInvalidOAuthState
+	}
+
+	oauthState, err := oauthStateRepo.Consume(state, provider)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrInvalidOAuthState
+		}
+		return nil, err
+	}
+
+	if oauthState.Provider != provider || time.Now().After(oauthState.ExpiresAt) {
+		return nil, ErrInvalidOAuthState
+	}
+
+	return oauthState, nil
+}
+
+// OAuthStateCookieName is the name of the cookie binding a login to the browser that started it
+func OAuthStateCookieName() string {
+	return cookiePolicy().name(oauthStateCookieBaseName)
+}
+
+// NewOAuthStateCookie binds the state to the browser that started the login
+func NewOAuthStateCookie(oauthState *auth_models.OAuthState) *http.Cookie {
+	return oauthStateCookie(oauthState.State, oauthState.ExpiresAt)
+}
+
+// ClearOAuthStateCookie returns a cookie that removes the state from the browser
+func ClearOAuthStateCookie() *http.Cookie {
+	return oauthStateCookie("", time.Unix(0, 0))
+}
+
+// oauthStateCookie follows the cookie policy, except that SameSite is relaxed from Strict
+// to Lax since the provider redirects back to the callback cross-site
+func oauthStateCookie(value string, expires time.Time) *http.Cookie {
+	cookie := cookiePolicy().cookie(oauthStateCookieBaseName, value, expires, true)
+	if cookie.SameSite == http.SameSiteStrictMode {
+		cookie.SameSite = http.SameSiteLaxMode
+	}
+	return cookie
+}
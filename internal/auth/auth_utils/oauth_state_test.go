@@ -0,0 +1,149 @@
+package auth_utils_test
+
+import (
+	"database/sql"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_models"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_utils"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCreateOAuthState(t *testing.T) {
+	require.NoError(t, auth.InitOAuth())
+
+	t.Run("Stores a state and PKCE verifier for the provider", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		states := mocks.NewMockOAuthStateStore(ctrl)
+
+		states.EXPECT().DeleteExpired().Return(nil)
+		states.EXPECT().Create(gomock.Any()).Return(nil)
+
+		oauthState, err := auth_utils.CreateOAuthState(states, "github", nil, "")
+		require.NoError(t, err)
+		assert.Equal(t, "github", oauthState.Provider)
+		assert.NotEmpty(t, oauthState.State)
+		assert.NotEmpty(t, oauthState.CodeVerifier)
+		assert.NotEmpty(t, oauthState.Nonce)
+		assert.WithinDuration(t, time.Now().Add(auth_utils.OAuthStateExpiry), oauthState.ExpiresAt, time.Minute)
+	})
+
+	t.Run("Unknown provider", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		_, err := auth_utils.CreateOAuthState(mocks.NewMockOAuthStateStore(ctrl), "myspace", nil, "")
+		assert.Error(t, err)
+	})
+}
+
+func TestConsumeOAuthState(t *testing.T) {
+	const state = "state-from-the-provider"
+
+	storedState := func(provider string, expiresAt time.Time) *auth_models.OAuthState {
+		return &auth_models.OAuthState{State: state, Provider: provider, CodeVerifier: "verifier", ExpiresAt: expiresAt}
+	}
+
+	t.Run("Returns the stored state and PKCE verifier", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		states := mocks.NewMockOAuthStateStore(ctrl)
+
+		states.EXPECT().Consume(state, "github").Return(storedState("github", time.Now().Add(time.Minute)), nil)
+
+		oauthState, err := auth_utils.ConsumeOAuthState(states, "github", state, state)
+		require.NoError(t, err)
+		assert.Equal(t, "verifier", oauthState.CodeVerifier)
+	})
+
+	t.Run("Missing cookie", func(t *testing.T) {
+		// Checked before the store, so a state can't be used from another browser
+		ctrl := gomock.NewController(t)
+
+		_, err := auth_utils.ConsumeOAuthState(mocks.NewMockOAuthStateStore(ctrl), "github", state, "")
+		assert.ErrorIs(t, err, auth_utils.ErrInvalidOAuthState)
+	})
+
+	t.Run("Missing state", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		_, err := auth_utils.ConsumeOAuthState(mocks.NewMockOAuthStateStore(ctrl), "github", "", "")
+		assert.ErrorIs(t, err, auth_utils.ErrInvalidOAuthState)
+	})
+
+	t.Run("State doesn't match the cookie", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		_, err := auth_utils.ConsumeOAuthState(mocks.NewMockOAuthStateStore(ctrl), "github", state, "state-from-another-login")
+		assert.ErrorIs(t, err, auth_utils.ErrInvalidOAuthState)
+	})
+
+	t.Run("Unknown or already used state", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		states := mocks.NewMockOAuthStateStore(ctrl)
+
+		states.EXPECT().Consume(state, "github").Return(nil, sql.ErrNoRows)
+
+		_, err := auth_utils.ConsumeOAuthState(states, "github", state, state)
+		assert.ErrorIs(t, err, auth_utils.ErrInvalidOAuthState)
+	})
+
+	t.Run("Expired state", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		states := mocks.NewMockOAuthStateStore(ctrl)
+
+		states.EXPECT().Consume(state, "github").Return(storedState("github", time.Now().Add(-time.Second)), nil)
+
+		_, err := auth_utils.ConsumeOAuthState(states, "github", state, state)
+		assert.ErrorIs(t, err, auth_utils.ErrInvalidOAuthState)
+	})
+
+	t.Run("State for another provider", func(t *testing.T) {
+		// The store only consumes a state for the provider it was created for
+		ctrl := gomock.NewController(t)
+		states := mocks.NewMockOAuthStateStore(ctrl)
+
+		states.EXPECT().Consume(state, "google").Return(nil, sql.ErrNoRows)
+
+		_, err := auth_utils.ConsumeOAuthState(states, "google", state, state)
+		assert.ErrorIs(t, err, auth_utils.ErrInvalidOAuthState)
+	})
+
+	t.Run("Store returns a state for another provider", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		states := mocks.NewMockOAuthStateStore(ctrl)
+
+		states.EXPECT().Consume(state, "google").Return(storedState("github", time.Now().Add(time.Minute)), nil)
+
+		_, err := auth_utils.ConsumeOAuthState(states, "google", state, state)
+		assert.ErrorIs(t, err, auth_utils.ErrInvalidOAuthState)
+	})
+
+	t.Run("Store error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		states := mocks.NewMockOAuthStateStore(ctrl)
+
+		states.EXPECT().Consume(state, "github").Return(nil, sql.ErrConnDone)
+
+		_, err := auth_utils.ConsumeOAuthState(states, "github", state, state)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+	})
+}
+
+func TestOAuthStateCookie(t *testing.T) {
+	oauthState := &auth_models.OAuthState{State: "state-from-the-provider", ExpiresAt: time.Now().Add(auth_utils.OAuthStateExpiry)}
+	refreshCookie := auth_utils.ClearRefreshCookie()
+
+	for _, cookie := range []*http.Cookie{auth_utils.NewOAuthStateCookie(oauthState), auth_utils.ClearOAuthStateCookie()} {
+		assert.Equal(t, auth_utils.OAuthStateCookieName(), cookie.Name)
+		assert.True(t, cookie.HttpOnly)
+		assert.Equal(t, refreshCookie.Secure, cookie.Secure, "follows the cookie policy")
+		assert.Equal(t, refreshCookie.Path, cookie.Path)
+		assert.Equal(t, refreshCookie.Domain, cookie.Domain)
+		assert.NotEqual(t, http.SameSiteStrictMode, cookie.SameSite, "the provider redirects back cross-site")
+	}
+}
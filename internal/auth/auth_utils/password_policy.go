@@ -0,0 +1,270 @@
+package auth_utils
+
+import (
+	"bufio"
+	"crypto/sha1"
+	_ "embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/csusmGDSC/csusmgdsc-api/config"
+)
+
+// bcrypt ignores everything after the first 72 bytes
+const maxPasswordBytes = 72
+
+// Password policy violation codes, for the frontend to render its own messages
+const (
+	PasswordTooShort      = "too_short"
+	PasswordTooLong       = "too_long"
+	PasswordTooWeak       = "too_weak"
+	PasswordContainsEmail = "contains_email"
+	PasswordBreached      = "breached"
+)
+
+type PasswordViolation struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// PasswordPolicyError lists every requirement a password failed
+type PasswordPolicyError struct {
+	Violations []PasswordViolation
+}
+
+func (e *PasswordPolicyError) Error() string {
+	codes := make([]string, len(e.Violations))
+	for i, violation := range e.Violations {
+		codes[i] = violation.Code
+	}
+	return "password does not meet the policy: " + strings.Join(codes, ", ")
+}
+
+//go:embed common_passwords.txt
+var commonPasswordsFile string
+
+// commonPasswords maps the most common passwords and words to their popularity rank
+var commonPasswords = func() map[string]int {
+	ranks := map[string]int{}
+	for i, line := range strings.Split(commonPasswordsFile, "\n") {
+		if word := strings.TrimSpace(line); word != "" {
+			ranks[word] = i + 1
+		}
+	}
+	return ranks
+}()
+
+// CheckPasswordPolicy checks a new password against the configured policy: a minimum
+// length, a minimum strength score, not containing the user's email and not appearing in
+// the breached password list. Returns a *PasswordPolicyError listing every violation.
+func CheckPasswordPolicy(password string, email string) error {
+	cfg := config.LoadConfig()
+	var violations []PasswordViolation
+
+	if length := len([]rune(password)); length < cfg.PasswordMinLength {
+		violations = append(violations, PasswordViolation{
+			Code:    PasswordTooShort,
+			Message: fmt.Sprintf("Password must be at least %d characters", cfg.PasswordMinLength),
+		})
+	}
+	if len(password) > maxPasswordBytes {
+		violations = append(violations, PasswordViolation{
+			Code:    PasswordTooLong,
+			Message: fmt.Sprintf("Password must be at most %d bytes", maxPasswordBytes),
+		})
+	}
+	if containsEmail(password, email) {
+		violations = append(violations, PasswordViolation{
+			Code:    PasswordContainsEmail,
+			Message: "Password must not contain your email address",
+		})
+	}
+	localPart, _, _ := strings.Cut(email, "@")
+	if PasswordStrength(password, localPart) < cfg.PasswordMinStrength {
+		violations = append(violations, PasswordViolation{
+			Code:    PasswordTooWeak,
+			Message: "Password is too easy to guess, try a longer phrase or mix in uncommon words",
+		})
+	}
+
+	breached, err := IsBreachedPassword(cfg.BreachedPasswordsDir, password)
+	if err != nil {
+		return err
+	}
+	if breached {
+		violations = append(violations, PasswordViolation{
+			Code:    PasswordBreached,
+			Message: "Password has appeared in a data breach, choose a different one",
+		})
+	}
+
+	if len(violations) > 0 {
+		return &PasswordPolicyError{Violations: violations}
+	}
+	return nil
+}
+
+// containsEmail reports whether the password contains the email or the part before the @
+func containsEmail(password string, email string) bool {
+	password = strings.ToLower(password)
+	email = strings.ToLower(strings.TrimSpace(email))
+	if email == "" {
+		return false
+	}
+
+	localPart, _, _ := strings.Cut(email, "@")
+	return strings.Contains(password, email) || (len(localPart) >= 3 && strings.Contains(password, localPart))
+}
+
+// PasswordStrength scores a password from 0 (trivial to guess) to 4 (very hard), using the
+// same thresholds on the estimated number of guesses as zxcvbn. The estimate assumes the
+// attacker tries common passwords, words from userInputs such as the user's name,
+// repeated characters and keyboard or alphabet sequences before brute force.
+func PasswordStrength(password string, userInputs ...string) int {
+	dictionary := commonPasswords
+	if len(userInputs) > 0 {
+		dictionary = make(map[string]int, len(commonPasswords)+len(userInputs))
+		for word, rank := range commonPasswords {
+			dictionary[word] = rank
+		}
+		for _, input := range userInputs {
+			if input = strings.ToLower(strings.TrimSpace(input)); input != "" {
+				dictionary[input] = 1
+			}
+		}
+	}
+
+	guesses := estimateGuessesLog10(password, dictionary)
+	switch {
+	case guesses < 3:
+		return 0
+	case guesses < 6:
+		return 1
+	case guesses < 8:
+		return 2
+	case guesses < 10:
+		return 3
+	}
+	return 4
+}
+
+var leetSubstitutions = strings.NewReplacer("@", "a", "4", "a", "3", "e", "1", "i", "!", "i", "0", "o", "$", "s", "5", "s", "7", "t")
+
+var sequences = []string{
+	"abcdefghijklmnopqrstuvwxyz",
+	"01234567890",
+	"qwertyuiop",
+	"asdfghjkl",
+	"zxcvbnm",
+	"1qaz2wsx3edc4rfv5tgb6yhn7ujm8ik9ol0p",
+}
+
+// estimateGuessesLog10 returns log10 of the number of guesses needed to find the password.
+// Like zxcvbn, brute force is counted as 10 guesses per character.
+func estimateGuessesLog10(password string, dictionary map[string]int) float64 {
+	if password == "" {
+		return 0
+	}
+
+	runes := []rune(password)
+	guesses := float64(len(runes))
+
+	lower := strings.ToLower(password)
+	// Common passwords, also with leetspeak and digits or symbols tacked on the end
+	base := strings.TrimRightFunc(lower, func(r rune) bool { return !unicode.IsLetter(r) })
+	for _, candidate := range []string{lower, leetSubstitutions.Replace(lower), base, leetSubstitutions.Replace(base)} {
+		if rank, ok := dictionary[candidate]; ok {
+			suffix := len(runes) - len([]rune(candidate))
+			if suffix < 0 {
+				suffix = 0
+			}
+			guesses = math.Min(guesses, math.Log10(float64(rank))+float64(suffix)+1)
+		}
+	}
+
+	// One character or chunk repeated, e.g. "aaaaaa" or "abcabcabc"
+	if chunk := repeatedChunk(lower); chunk != "" {
+		repeats := float64(len(lower) / len(chunk))
+		guesses = math.Min(guesses, estimateGuessesLog10(chunk, dictionary)+math.Log10(repeats))
+	}
+
+	// Runs along the alphabet, digits or keyboard rows, forwards or backwards
+	if isSequence(lower) {
+		guesses = math.Min(guesses, math.Log10(float64(len(runes)*len(sequences)*2)))
+	}
+
+	return guesses
+}
+
+// repeatedChunk returns the shortest string the password is made of repeating, if any
+func repeatedChunk(password string) string {
+	for size := 1; size <= len(password)/2; size++ {
+		if len(password)%size == 0 && strings.Repeat(password[:size], len(password)/size) == password {
+			return password[:size]
+		}
+	}
+	return ""
+}
+
+func isSequence(password string) bool {
+	if len(password) < 3 {
+		return false
+	}
+
+	reversed := []rune(password)
+	for i, j := 0, len(reversed)-1; i < j; i, j = i+1, j-1 {
+		reversed[i], reversed[j] = reversed[j], reversed[i]
+	}
+
+	for _, sequence := range sequences {
+		if strings.Contains(sequence, password) || strings.Contains(sequence, string(reversed)) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsBreachedPassword looks the password up in a local copy of a breached password list
+// split by hash prefix, like the Have I Been Pwned range files. dir holds one file per
+// 5 character prefix of the uppercase SHA-1 hash, named after the prefix with an optional
+// .txt extension, listing the rest of each hash as "SUFFIX:COUNT" lines. Only the file of
+// the password's prefix is read, and nothing is sent over the network. An empty dir
+// disables the check.
+func IsBreachedPassword(dir string, password string) (bool, error) {
+	if dir == "" {
+		return false, nil
+	}
+
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:5], hash[5:]
+
+	file, err := os.Open(filepath.Join(dir, prefix))
+	if errors.Is(err, os.ErrNotExist) {
+		file, err = os.Open(filepath.Join(dir, prefix+".txt"))
+	}
+	if errors.Is(err, os.ErrNotExist) {
+		// No breached password has this prefix
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		lineSuffix, _, _ := strings.Cut(line, ":")
+		if strings.EqualFold(lineSuffix, suffix) {
+			return true, nil
+		}
+	}
+	return false, scanner.Err()
+}
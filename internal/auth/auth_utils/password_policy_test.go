@@ -0,0 +1,73 @@
+package auth_utils_test
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPasswordStrength(t *testing.T) {
+	weak := []string{"password", "P@ssw0rd", "password123", "qwertyuiop", "aaaaaaaaaaaa", "abcabcabcabc", "1234567890", "9876543210"}
+	for _, password := range weak {
+		assert.Less(t, auth_utils.PasswordStrength(password), 2, password)
+	}
+
+	strong := []string{"correct horse battery staple", "vR7#qLm2!xTz", "tangerine-orbit-ladder-42"}
+	for _, password := range strong {
+		assert.GreaterOrEqual(t, auth_utils.PasswordStrength(password), 3, password)
+	}
+}
+
+func TestCheckPasswordPolicy(t *testing.T) {
+	violationCodes := func(err error) []string {
+		var policyErr *auth_utils.PasswordPolicyError
+		require.True(t, errors.As(err, &policyErr))
+		var codes []string
+		for _, violation := range policyErr.Violations {
+			codes = append(codes, violation.Code)
+		}
+		return codes
+	}
+
+	t.Run("Accepts a strong password", func(t *testing.T) {
+		assert.NoError(t, auth_utils.CheckPasswordPolicy("tangerine-orbit-ladder-42", "alex@csusm.edu"))
+	})
+
+	t.Run("Lists every violation", func(t *testing.T) {
+		err := auth_utils.CheckPasswordPolicy("alex1", "alex@csusm.edu")
+		assert.ElementsMatch(t, []string{auth_utils.PasswordTooShort, auth_utils.PasswordContainsEmail, auth_utils.PasswordTooWeak}, violationCodes(err))
+	})
+
+	t.Run("Rejects passwords bcrypt would truncate", func(t *testing.T) {
+		err := auth_utils.CheckPasswordPolicy(strings.Repeat("vR7#qLm2!xTz", 7), "alex@csusm.edu")
+		assert.Equal(t, []string{auth_utils.PasswordTooLong}, violationCodes(err))
+	})
+}
+
+func TestIsBreachedPassword(t *testing.T) {
+	dir := t.TempDir()
+	sum := sha1.Sum([]byte("tangerine-orbit-ladder-42"))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	rangeFile := "0000000000000000000000000000000000A:3\n" + hash[5:] + ":12\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, hash[:5]+".txt"), []byte(rangeFile), 0o600))
+
+	breached, err := auth_utils.IsBreachedPassword(dir, "tangerine-orbit-ladder-42")
+	require.NoError(t, err)
+	assert.True(t, breached)
+
+	breached, err = auth_utils.IsBreachedPassword(dir, "another-orbit-ladder-42")
+	require.NoError(t, err)
+	assert.False(t, breached)
+
+	breached, err = auth_utils.IsBreachedPassword("", "tangerine-orbit-ladder-42")
+	require.NoError(t, err)
+	assert.False(t, breached)
+}
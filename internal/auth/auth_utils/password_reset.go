@@ -0,0 +1,153 @@
+package auth_utils
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_repositories"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/mailer"
+	"github.com/google/uuid"
+)
+
+var PasswordResetTokenExpiry = time.Minute * 30 // Expiry time of a password reset link
+
+// RequestPasswordReset emails a single-use reset link if the email belongs to an account
+// that signs in with a password. Unknown emails and OAuth-only accounts are silently
+// ignored so the caller can't use the endpoint to discover which emails are registered.
+func RequestPasswordReset(db *sql.DB, m mailer.Mailer, locale string, email string) error {
+	userRepo := auth_repositories.NewUserRepository(db)
+
+	user, err := userRepo.GetByEmail(email)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+
+	if user.Password == nil {
+		return nil
+	}
+
+	token, err := GeneratePurposeToken(user.ID, PurposeResetPassword, user.Email, PasswordResetTokenExpiry)
+	if err != nil {
+		return err
+	}
+
+	return SendPasswordResetEmail(m, locale, user.Email, token)
+}
+
+// ResetPassword consumes a reset token, sets the new password and logs the user
+// out of every session and revokes their personal access tokens, since whoever held the
+// old password may still be signed in.
+// A password that fails the policy returns a *PasswordPolicyError and leaves the token
+// unused, so the user can try another.
+func ResetPassword(db *sql.DB, token string, newPassword string) error {
+	claims, err := ValidatePurposeToken(token, PurposeResetPassword)
+	if err != nil {
+		return err
+	}
+
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		return ErrInvalidToken
+	}
+
+	userRepo := auth_repositories.NewUserRepository(db)
+
+	// The link is only valid for the address it was sent to
+	user, err := userRepo.GetByID(userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrInvalidToken
+		}
+		return err
+	}
+	if user.Email != claims.Email {
+		return ErrInvalidToken
+	}
+
+	if err := CheckPasswordPolicy(newPassword, user.Email); err != nil {
+		return err
+	}
+
+	if _, err := ConsumePurposeToken(db, token, PurposeResetPassword); err != nil {
+		if err == ErrTokenAlreadyUsed {
+			return ErrInvalidToken
+		}
+		return err
+	}
+
+	hashedPassword, err := HashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+
+	err = userRepo.UpdatePassword(userID, hashedPassword)
+	if err != nil {
+		return err
+	}
+
+	refreshTokenRepo := auth_repositories.NewRefreshTokenRepository(db)
+	if err := refreshTokenRepo.DeleteAllByUserID(userID.String()); err != nil {
+		return err
+	}
+
+	patRepo := auth_repositories.NewPersonalAccessTokenRepository(db)
+	return patRepo.DeleteAllByUserID(userID)
+}
+
+// ChangePassword sets a new password for a signed in user after checking their current
+// one. Wrong guesses count towards the same lockout as failed logins, so a stolen session
+// can't be used to brute force the password. With revokeOthers, every session except the
+// one currentToken belongs to is signed out. Returns the user's email for the notification.
+func ChangePassword(db *sql.DB, userID uuid.UUID, currentPassword string, newPassword string, ipAddress string, currentToken string, revokeOthers bool) (string, error) {
+	userRepo := auth_repositories.NewUserRepository(db)
+	user, err := userRepo.GetByID(userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", ErrUserDoesntExist
+		}
+		return "", err
+	}
+
+	if user.Password == nil {
+		return "", ErrNoPassword
+	}
+
+	throttleRepo := auth_repositories.NewLoginThrottleRepository(db)
+	auditRepo := auth_repositories.NewAuditLogRepository(db)
+	if err := CheckLoginThrottle(throttleRepo, user.Email, ipAddress); err != nil {
+		return "", err
+	}
+	if err := ComparePasswords(*user.Password, currentPassword); err != nil {
+		if err := RecordLoginFailure(throttleRepo, auditRepo, user.Email, ipAddress, &user.ID); err != nil {
+			return "", err
+		}
+		return "", ErrInvalidCredentials
+	}
+
+	if ComparePasswords(*user.Password, newPassword) == nil {
+		return "", ErrPasswordUnchanged
+	}
+	if err := CheckPasswordPolicy(newPassword, user.Email); err != nil {
+		return "", err
+	}
+
+	hashedPassword, err := HashPassword(newPassword)
+	if err != nil {
+		return "", err
+	}
+	if err := userRepo.UpdatePassword(userID, hashedPassword); err != nil {
+		return "", err
+	}
+
+	if revokeOthers {
+		if err := RevokeOtherSessions(db, userID, currentToken); err != nil {
+			return "", err
+		}
+	}
+
+	return user.Email, nil
+}
@@ -0,0 +1,153 @@
+package auth_utils
+
+import (
+	"database/sql"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_models"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_repositories"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/models"
+	"github.com/google/uuid"
+)
+
+const (
+	// PersonalAccessTokenPrefix marks personal access tokens so they can be told apart
+	// from JWTs, and found by secret scanners if they are leaked.
+	PersonalAccessTokenPrefix = "gdsc_pat_"
+
+	PersonalAccessTokenDefaultExpiry = 90 * 24 * time.Hour
+	MaxPersonalAccessTokensPerUser   = 20
+
+	// Number of characters after the prefix kept in plain text so users can recognise their tokens
+	personalAccessTokenHintLength = 4
+)
+
+// IsPersonalAccessToken reports whether a bearer token is a personal access token rather than a JWT
+func IsPersonalAccessToken(token string) bool {
+	return strings.HasPrefix(token, PersonalAccessTokenPrefix)
+}
+
+// CreatePersonalAccessToken issues a new token for the user. Scopes are permission names
+// (e.g. events:write) and must be permissions the user currently holds; a token with no
+// scopes can only do what any signed-in user can. mfaVerified should be true if the
+// session creating the token passed two-factor authentication.
+//
+// The returned token string is not stored and can't be retrieved again.
+func CreatePersonalAccessToken(patRepo auth_repositories.PersonalAccessTokenStore, rbacRepo auth_repositories.PermissionStore, userID uuid.UUID, name string, scopes []string, expiry time.Duration, mfaVerified bool) (string, *auth_models.PersonalAccessToken, error) {
+	count, err := patRepo.CountByUserID(userID)
+	if err != nil {
+		return "", nil, err
+	}
+	if count >= MaxPersonalAccessTokensPerUser {
+		return "", nil, ErrTooManyTokens
+	}
+
+	scopes, err = checkTokenScopes(rbacRepo, userID, scopes)
+	if err != nil {
+		return "", nil, err
+	}
+
+	secret, err := GenerateRandomToken(32)
+	if err != nil {
+		return "", nil, err
+	}
+	token := PersonalAccessTokenPrefix + secret
+
+	now := time.Now()
+	pat := &auth_models.PersonalAccessToken{
+		ID:          uuid.New(),
+		UserID:      userID,
+		Name:        name,
+		TokenHash:   auth_repositories.HashToken(token),
+		TokenPrefix: token[:len(PersonalAccessTokenPrefix)+personalAccessTokenHintLength],
+		Scopes:      scopes,
+		MFAVerified: mfaVerified,
+		ExpiresAt:   now.Add(expiry),
+		CreatedAt:   now,
+	}
+	if err := patRepo.Create(pat); err != nil {
+		return "", nil, err
+	}
+
+	return token, pat, nil
+}
+
+// ValidatePersonalAccessToken looks up an unexpired token and records that it was used
+func ValidatePersonalAccessToken(patRepo auth_repositories.PersonalAccessTokenStore, token string) (*auth_models.PersonalAccessToken, error) {
+	if !IsPersonalAccessToken(token) {
+		return nil, ErrInvalidToken
+	}
+
+	pat, err := patRepo.GetByHash(auth_repositories.HashToken(token))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrInvalidToken
+		}
+		return nil, err
+	}
+
+	if time.Now().After(pat.ExpiresAt) {
+		return nil, ErrInvalidToken
+	}
+
+	if err := patRepo.UpdateLastUsed(pat.ID); err != nil {
+		log.Printf("Failed to update personal access token last used time: %v", err)
+	}
+
+	return pat, nil
+}
+
+// AuthenticatePersonalAccessToken validates a token and loads its owner. Returns an
+// *AccountSuspendedError if the owner is suspended.
+func AuthenticatePersonalAccessToken(patRepo auth_repositories.PersonalAccessTokenStore, userRepo auth_repositories.UserStore, token string) (*auth_models.PersonalAccessToken, *models.User, error) {
+	pat, err := ValidatePersonalAccessToken(patRepo, token)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	user, err := userRepo.GetByID(pat.UserID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil, ErrInvalidToken
+		}
+		return nil, nil, err
+	}
+
+	if err := checkAccountStatus(userRepo, user.ID); err != nil {
+		return nil, nil, err
+	}
+
+	return pat, user, nil
+}
+
+// checkTokenScopes removes duplicate scopes and rejects any the user doesn't hold
+func checkTokenScopes(rbacRepo auth_repositories.PermissionStore, userID uuid.UUID, scopes []string) ([]string, error) {
+	unique := []string{}
+	if len(scopes) == 0 {
+		return unique, nil
+	}
+
+	held, err := rbacRepo.GetPermissionsByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	heldSet := map[string]bool{}
+	for _, permission := range held {
+		heldSet[permission] = true
+	}
+
+	seen := map[string]bool{}
+	for _, scope := range scopes {
+		if !heldSet[scope] {
+			return nil, ErrInvalidScope
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			unique = append(unique, scope)
+		}
+	}
+
+	return unique, nil
+}
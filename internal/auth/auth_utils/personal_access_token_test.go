@@ -0,0 +1,184 @@
+package auth_utils_test
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_models"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_repositories"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_utils"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/mocks"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCreatePersonalAccessToken(t *testing.T) {
+	userID := uuid.New()
+
+	t.Run("Stores only the hash of the token", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		tokens := mocks.NewMockPersonalAccessTokenStore(ctrl)
+		rbac := mocks.NewMockPermissionStore(ctrl)
+
+		var stored *auth_models.PersonalAccessToken
+		tokens.EXPECT().CountByUserID(userID).Return(0, nil)
+		rbac.EXPECT().GetPermissionsByUserID(userID).Return([]string{"events:write", "media:upload"}, nil)
+		tokens.EXPECT().Create(gomock.Any()).DoAndReturn(func(pat *auth_models.PersonalAccessToken) error {
+			stored = pat
+			return nil
+		})
+
+		token, pat, err := auth_utils.CreatePersonalAccessToken(tokens, rbac, userID, "deploy bot", []string{"events:write", "events:write"}, time.Hour, true)
+		require.NoError(t, err)
+		require.NotNil(t, stored)
+
+		assert.True(t, auth_utils.IsPersonalAccessToken(token))
+		assert.Equal(t, auth_repositories.HashToken(token), stored.TokenHash)
+		assert.NotContains(t, stored.TokenHash, token)
+		assert.True(t, strings.HasPrefix(token, stored.TokenPrefix))
+		assert.Equal(t, []string{"events:write"}, pat.Scopes, "duplicate scopes are removed")
+		assert.Equal(t, userID, pat.UserID)
+		assert.True(t, pat.MFAVerified)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), pat.ExpiresAt, time.Minute)
+	})
+
+	t.Run("Without scopes", func(t *testing.T) {
+		// Permissions aren't looked up
+		ctrl := gomock.NewController(t)
+		tokens := mocks.NewMockPersonalAccessTokenStore(ctrl)
+
+		tokens.EXPECT().CountByUserID(userID).Return(0, nil)
+		tokens.EXPECT().Create(gomock.Any()).Return(nil)
+
+		_, pat, err := auth_utils.CreatePersonalAccessToken(tokens, mocks.NewMockPermissionStore(ctrl), userID, "read only", nil, time.Hour, false)
+		require.NoError(t, err)
+		assert.Empty(t, pat.Scopes)
+	})
+
+	t.Run("Scope the user doesn't hold", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		tokens := mocks.NewMockPersonalAccessTokenStore(ctrl)
+		rbac := mocks.NewMockPermissionStore(ctrl)
+
+		tokens.EXPECT().CountByUserID(userID).Return(0, nil)
+		rbac.EXPECT().GetPermissionsByUserID(userID).Return([]string{"events:write"}, nil)
+
+		_, _, err := auth_utils.CreatePersonalAccessToken(tokens, rbac, userID, "escalation", []string{"events:write", "users:manage"}, time.Hour, false)
+		assert.ErrorIs(t, err, auth_utils.ErrInvalidScope)
+	})
+
+	t.Run("Too many tokens", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		tokens := mocks.NewMockPersonalAccessTokenStore(ctrl)
+
+		tokens.EXPECT().CountByUserID(userID).Return(auth_utils.MaxPersonalAccessTokensPerUser, nil)
+
+		_, _, err := auth_utils.CreatePersonalAccessToken(tokens, mocks.NewMockPermissionStore(ctrl), userID, "one too many", nil, time.Hour, false)
+		assert.ErrorIs(t, err, auth_utils.ErrTooManyTokens)
+	})
+}
+
+func TestValidatePersonalAccessToken(t *testing.T) {
+	const token = auth_utils.PersonalAccessTokenPrefix + "secret"
+
+	t.Run("Valid token", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		tokens := mocks.NewMockPersonalAccessTokenStore(ctrl)
+		pat := &auth_models.PersonalAccessToken{ID: uuid.New(), UserID: uuid.New(), ExpiresAt: time.Now().Add(time.Hour)}
+
+		tokens.EXPECT().GetByHash(auth_repositories.HashToken(token)).Return(pat, nil)
+		tokens.EXPECT().UpdateLastUsed(pat.ID).Return(nil)
+
+		validated, err := auth_utils.ValidatePersonalAccessToken(tokens, token)
+		require.NoError(t, err)
+		assert.Equal(t, pat.ID, validated.ID)
+	})
+
+	t.Run("Expired token", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		tokens := mocks.NewMockPersonalAccessTokenStore(ctrl)
+		pat := &auth_models.PersonalAccessToken{ID: uuid.New(), UserID: uuid.New(), ExpiresAt: time.Now().Add(-time.Minute)}
+
+		tokens.EXPECT().GetByHash(auth_repositories.HashToken(token)).Return(pat, nil)
+
+		_, err := auth_utils.ValidatePersonalAccessToken(tokens, token)
+		assert.ErrorIs(t, err, auth_utils.ErrInvalidToken)
+	})
+
+	t.Run("Unknown or revoked token", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		tokens := mocks.NewMockPersonalAccessTokenStore(ctrl)
+
+		tokens.EXPECT().GetByHash(auth_repositories.HashToken(token)).Return(nil, sql.ErrNoRows)
+
+		_, err := auth_utils.ValidatePersonalAccessToken(tokens, token)
+		assert.ErrorIs(t, err, auth_utils.ErrInvalidToken)
+	})
+
+	t.Run("Not a personal access token", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		_, err := auth_utils.ValidatePersonalAccessToken(mocks.NewMockPersonalAccessTokenStore(ctrl), "eyJhbGciOiJFZERTQSJ9.e30.sig")
+		assert.ErrorIs(t, err, auth_utils.ErrInvalidToken)
+	})
+}
+
+func TestAuthenticatePersonalAccessToken(t *testing.T) {
+	const token = auth_utils.PersonalAccessTokenPrefix + "secret"
+
+	expectValidToken := func(tokens *mocks.MockPersonalAccessTokenStore, userID uuid.UUID) *auth_models.PersonalAccessToken {
+		pat := &auth_models.PersonalAccessToken{ID: uuid.New(), UserID: userID, Scopes: []string{"events:write"}, ExpiresAt: time.Now().Add(time.Hour)}
+		tokens.EXPECT().GetByHash(auth_repositories.HashToken(token)).Return(pat, nil)
+		tokens.EXPECT().UpdateLastUsed(pat.ID).Return(nil)
+		return pat
+	}
+
+	t.Run("Loads the owner's current role", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		tokens := mocks.NewMockPersonalAccessTokenStore(ctrl)
+		users := mocks.NewMockUserStore(ctrl)
+		userID := uuid.New()
+
+		expectValidToken(tokens, userID)
+		expectActiveUser(users, userID, models.AdminRole)
+
+		pat, user, err := auth_utils.AuthenticatePersonalAccessToken(tokens, users, token)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"events:write"}, pat.Scopes)
+		assert.Equal(t, models.AdminRole, *user.Role)
+	})
+
+	t.Run("Suspended owner", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		tokens := mocks.NewMockPersonalAccessTokenStore(ctrl)
+		users := mocks.NewMockUserStore(ctrl)
+		userID := uuid.New()
+		until := time.Now().Add(time.Hour)
+
+		expectValidToken(tokens, userID)
+		users.EXPECT().GetByID(userID).Return(&models.User{ID: userID}, nil)
+		users.EXPECT().GetStatus(userID).Return(&auth_models.AccountStatus{SuspendedUntil: &until}, nil)
+
+		_, _, err := auth_utils.AuthenticatePersonalAccessToken(tokens, users, token)
+		var suspendedErr *auth_utils.AccountSuspendedError
+		assert.ErrorAs(t, err, &suspendedErr)
+	})
+
+	t.Run("Deleted owner", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		tokens := mocks.NewMockPersonalAccessTokenStore(ctrl)
+		users := mocks.NewMockUserStore(ctrl)
+		userID := uuid.New()
+
+		expectValidToken(tokens, userID)
+		users.EXPECT().GetByID(userID).Return(nil, sql.ErrNoRows)
+
+		_, _, err := auth_utils.AuthenticatePersonalAccessToken(tokens, users, token)
+		assert.ErrorIs(t, err, auth_utils.ErrInvalidToken)
+	})
+}
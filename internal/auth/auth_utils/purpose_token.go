@@ -0,0 +1,141 @@
+package auth_utils
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/csusmGDSC/csusmgdsc-api/config"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_repositories"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// TokenPurpose scopes a token to the single action it was issued for, such as
+// verifying an email address. Purpose tokens are never accepted as access tokens.
+type TokenPurpose string
+
+const (
+	PurposeVerifyEmail   TokenPurpose = "verify_email"
+	PurposeResetPassword TokenPurpose = "reset_password"
+	PurposeChangeEmail   TokenPurpose = "change_email"
+	PurposeInvite        TokenPurpose = "invite"
+	PurposeMFAPending    TokenPurpose = "mfa_pending"
+	PurposeMagicLogin    TokenPurpose = "magic_login"
+)
+
+func (p TokenPurpose) String() string {
+	return string(p)
+}
+
+// Audience is the "aud" claim a purpose token must carry
+func (p TokenPurpose) Audience() string {
+	return "gdsc-csusm:" + string(p)
+}
+
+type PurposeClaims struct {
+	UserID  string       `json:"user_id"`
+	Purpose TokenPurpose `json:"purpose"`
+	Email   string       `json:"email,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// GeneratePurposeToken signs a token that can only be used for the given purpose.
+// email records the address the token was sent to, if relevant to the purpose.
+func GeneratePurposeToken(userID uuid.UUID, purpose TokenPurpose, email string, expiry time.Duration) (string, error) {
+	token, _, err := generatePurposeToken(userID, purpose, email, expiry)
+	return token, err
+}
+
+// generatePurposeToken is GeneratePurposeToken, also returning the claims of the token
+func generatePurposeToken(userID uuid.UUID, purpose TokenPurpose, email string, expiry time.Duration) (string, *PurposeClaims, error) {
+	keys, err := signingKeys()
+	if err != nil {
+		return "", nil, err
+	}
+
+	now := time.Now()
+	claims := &PurposeClaims{
+		UserID:  userID.String(),
+		Purpose: purpose,
+		Email:   email,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			Issuer:    config.LoadConfig().JWTIssuer,
+			Audience:  jwt.ClaimStrings{purpose.Audience()},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(expiry)),
+		},
+	}
+
+	token, err := keys.Sign(claims)
+	return token, claims, err
+}
+
+// ValidatePurposeToken checks the signature, expiry, audience and purpose of a token
+func ValidatePurposeToken(tokenString string, purpose TokenPurpose) (*PurposeClaims, error) {
+	keys, err := signingKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := jwt.ParseWithClaims(tokenString, &PurposeClaims{}, keys.Keyfunc,
+		jwt.WithValidMethods([]string{jwt.SigningMethodEdDSA.Alg()}),
+		jwt.WithIssuer(config.LoadConfig().JWTIssuer),
+		jwt.WithAudience(purpose.Audience()),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	claims, ok := token.Claims.(*PurposeClaims)
+	if !ok || !token.Valid || claims.Purpose != purpose || claims.ID == "" {
+		return nil, ErrInvalidToken
+	}
+
+	return claims, nil
+}
+
+// ConsumePurposeToken validates a token and marks it as used, so the same token
+// can't be presented twice. Returns ErrTokenAlreadyUsed on replay.
+func ConsumePurposeToken(db *sql.DB, tokenString string, purpose TokenPurpose) (*PurposeClaims, error) {
+	return consumePurposeToken(auth_repositories.NewUsedTokenRepository(db), tokenString, purpose)
+}
+
+func consumePurposeToken(usedTokenRepo auth_repositories.UsedTokenStore, tokenString string, purpose TokenPurpose) (*PurposeClaims, error) {
+	claims, err := ValidatePurposeToken(tokenString, purpose)
+	if err != nil {
+		return nil, err
+	}
+
+	jti, err := uuid.Parse(claims.ID)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	parsedUserID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	// Sign-up links are issued before the account exists
+	var userID *uuid.UUID
+	if parsedUserID != uuid.Nil {
+		userID = &parsedUserID
+	}
+
+	// Opportunistically clean up tokens that have expired anyway
+	if err := usedTokenRepo.DeleteExpired(); err != nil {
+		return nil, err
+	}
+
+	firstUse, err := usedTokenRepo.MarkUsed(jti, purpose.String(), userID, claims.ExpiresAt.Time)
+	if err != nil {
+		return nil, err
+	}
+	if !firstUse {
+		return nil, ErrTokenAlreadyUsed
+	}
+
+	return claims, nil
+}
@@ -0,0 +1,53 @@
+package auth_utils_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_utils"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPurposeTokens(t *testing.T) {
+	userID := uuid.New()
+
+	verifyToken, err := auth_utils.GeneratePurposeToken(userID, auth_utils.PurposeVerifyEmail, "student@csusm.edu", time.Hour)
+	require.NoError(t, err)
+
+	t.Run("Valid for its own purpose", func(t *testing.T) {
+		claims, err := auth_utils.ValidatePurposeToken(verifyToken, auth_utils.PurposeVerifyEmail)
+		require.NoError(t, err)
+		assert.Equal(t, userID.String(), claims.UserID)
+		assert.Equal(t, "student@csusm.edu", claims.Email)
+	})
+
+	t.Run("Rejected for another purpose", func(t *testing.T) {
+		_, err := auth_utils.ValidatePurposeToken(verifyToken, auth_utils.PurposeResetPassword)
+		assert.ErrorIs(t, err, auth_utils.ErrInvalidToken)
+	})
+
+	t.Run("Rejected as an access token", func(t *testing.T) {
+		_, err := auth_utils.ValidateJWT(verifyToken, auth_utils.AccessTokenType)
+		assert.Error(t, err)
+	})
+
+	t.Run("Access token rejected as a purpose token", func(t *testing.T) {
+		role := models.UserRole
+		accessToken, err := auth_utils.GenerateJWT(userID, &role, false, time.Now(), time.Hour)
+		require.NoError(t, err)
+
+		_, err = auth_utils.ValidatePurposeToken(accessToken, auth_utils.PurposeVerifyEmail)
+		assert.ErrorIs(t, err, auth_utils.ErrInvalidToken)
+	})
+
+	t.Run("Expired token", func(t *testing.T) {
+		expiredToken, err := auth_utils.GeneratePurposeToken(userID, auth_utils.PurposeVerifyEmail, "student@csusm.edu", -time.Minute)
+		require.NoError(t, err)
+
+		_, err = auth_utils.ValidatePurposeToken(expiredToken, auth_utils.PurposeVerifyEmail)
+		assert.ErrorIs(t, err, auth_utils.ErrInvalidToken)
+	})
+}
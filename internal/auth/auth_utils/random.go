@@ -0,0 +1,15 @@
+package auth_utils
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+)
+
+// GenerateRandomToken returns a URL-safe string encoding n bytes from crypto/rand.
+func GenerateRandomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,45 @@
+package auth_utils
+
+import (
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_repositories"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/models"
+	"github.com/google/uuid"
+)
+
+// HasPermissions reports whether the user holds all of the permissions through any of their roles.
+// Roles are looked up on every call, so revoking a role takes effect immediately rather than
+// when the user's access token expires.
+func HasPermissions(rbacRepo auth_repositories.PermissionStore, userID uuid.UUID, permissions ...models.Permission) (bool, error) {
+	if len(permissions) == 0 {
+		return true, nil
+	}
+
+	names := make([]string, 0, len(permissions))
+	seen := map[models.Permission]bool{}
+	for _, permission := range permissions {
+		if !seen[permission] {
+			seen[permission] = true
+			names = append(names, permission.String())
+		}
+	}
+
+	return rbacRepo.HasPermissions(userID, names)
+}
+
+// GrantRole gives a user an additional role, such as a branch officer role.
+// Primary roles (USER, ADMIN) are set on the user instead and can't be granted.
+func GrantRole(rbacRepo auth_repositories.PermissionStore, userID uuid.UUID, role string, grantedBy uuid.UUID) error {
+	if role == models.UserRole.String() || role == models.AdminRole.String() {
+		return ErrPrimaryRole
+	}
+
+	exists, err := rbacRepo.RoleExists(role)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrRoleNotFound
+	}
+
+	return rbacRepo.GrantRole(userID, role, grantedBy)
+}
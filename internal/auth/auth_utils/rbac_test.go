@@ -0,0 +1,94 @@
+package auth_utils_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_utils"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/mocks"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestHasPermissions(t *testing.T) {
+	userID := uuid.New()
+
+	t.Run("Checks each permission once", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		rbac := mocks.NewMockPermissionStore(ctrl)
+
+		rbac.EXPECT().HasPermissions(userID, []string{"events:write", "media:upload"}).Return(true, nil)
+
+		allowed, err := auth_utils.HasPermissions(rbac, userID, models.EventsWrite, models.MediaUpload, models.EventsWrite)
+		require.NoError(t, err)
+		assert.True(t, allowed)
+	})
+
+	t.Run("Missing permission", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		rbac := mocks.NewMockPermissionStore(ctrl)
+
+		rbac.EXPECT().HasPermissions(userID, []string{"users:manage"}).Return(false, nil)
+
+		allowed, err := auth_utils.HasPermissions(rbac, userID, models.UsersManage)
+		require.NoError(t, err)
+		assert.False(t, allowed)
+	})
+
+	t.Run("No permissions required", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		allowed, err := auth_utils.HasPermissions(mocks.NewMockPermissionStore(ctrl), userID)
+		require.NoError(t, err)
+		assert.True(t, allowed)
+	})
+
+	t.Run("Store error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		rbac := mocks.NewMockPermissionStore(ctrl)
+		storeErr := errors.New("connection refused")
+
+		rbac.EXPECT().HasPermissions(userID, gomock.Any()).Return(false, storeErr)
+
+		allowed, err := auth_utils.HasPermissions(rbac, userID, models.UsersManage)
+		assert.ErrorIs(t, err, storeErr)
+		assert.False(t, allowed)
+	})
+}
+
+func TestGrantRole(t *testing.T) {
+	userID := uuid.New()
+	adminID := uuid.New()
+
+	t.Run("Grants an existing role", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		rbac := mocks.NewMockPermissionStore(ctrl)
+
+		rbac.EXPECT().RoleExists("MARKETING_OFFICER").Return(true, nil)
+		rbac.EXPECT().GrantRole(userID, "MARKETING_OFFICER", adminID).Return(nil)
+
+		require.NoError(t, auth_utils.GrantRole(rbac, userID, "MARKETING_OFFICER", adminID))
+	})
+
+	t.Run("Unknown role", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		rbac := mocks.NewMockPermissionStore(ctrl)
+
+		rbac.EXPECT().RoleExists("SUPREME_LEADER").Return(false, nil)
+
+		err := auth_utils.GrantRole(rbac, userID, "SUPREME_LEADER", adminID)
+		assert.ErrorIs(t, err, auth_utils.ErrRoleNotFound)
+	})
+
+	t.Run("Primary roles can't be granted", func(t *testing.T) {
+		for _, role := range []models.Role{models.UserRole, models.AdminRole} {
+			ctrl := gomock.NewController(t)
+
+			err := auth_utils.GrantRole(mocks.NewMockPermissionStore(ctrl), userID, role.String(), adminID)
+			assert.ErrorIs(t, err, auth_utils.ErrPrimaryRole)
+		}
+	})
+}
@@ -0,0 +1,165 @@
+package auth_utils
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/csusmGDSC/csusmgdsc-api/config"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_models"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_repositories"
+	"github.com/google/uuid"
+)
+
+// Registration modes, set with REGISTRATION_MODE
+const (
+	RegistrationOpen   = "open"   // Anyone can register
+	RegistrationDomain = "domain" // Emails in REGISTRATION_ALLOWED_DOMAINS, or anyone with an invite
+	RegistrationInvite = "invite" // Only people with an invite
+)
+
+const (
+	InviteDefaultExpiry = 7 * 24 * time.Hour
+	InviteDefaultUses   = 1
+)
+
+// EmailDomainAllowed reports whether an email address belongs to one of the allowed domains
+func EmailDomainAllowed(email string, allowedDomains []string) bool {
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return false
+	}
+	domain := strings.ToLower(email[at+1:])
+	for _, allowed := range allowedDomains {
+		if domain == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// EmailVerificationRequired reports whether accounts must verify their email before they
+// can sign in. Outside open registration the email is what lets someone register, so an
+// account mustn't be usable until the address is shown to belong to its owner.
+func EmailVerificationRequired() bool {
+	return config.LoadConfig().RegistrationMode != RegistrationOpen
+}
+
+// redeemRegistration checks that a new account may be created under the registration
+// policy. If an invite code is given it must be valid for the email, and one of its uses
+// is taken; the invite is returned so its position and branch can be applied, and so the
+// use can be given back if creating the account fails.
+//
+// emailVerified is whether the address is known to belong to the person signing up.
+// Invites for a specific email always need a verified address. The domain rule takes an
+// unverified address only with verifyBeforeSignIn, for sign ups that are sent a
+// verification link and can't sign in until it's used.
+func redeemRegistration(inviteRepo auth_repositories.InviteStore, email *string, emailVerified bool, verifyBeforeSignIn bool, inviteCode *string) (*auth_models.Invite, error) {
+	if inviteCode != nil && *inviteCode != "" {
+		return redeemInvite(inviteRepo, *inviteCode, email, emailVerified)
+	}
+
+	return nil, registrationOpenTo(email, emailVerified || verifyBeforeSignIn)
+}
+
+// registrationOpenTo checks whether the email can register without an invite
+func registrationOpenTo(email *string, emailVerified bool) error {
+	cfg := config.LoadConfig()
+	switch cfg.RegistrationMode {
+	case RegistrationOpen:
+		return nil
+	case RegistrationDomain:
+		if email == nil || !emailVerified || !EmailDomainAllowed(*email, cfg.AllowedEmailDomains) {
+			return ErrEmailDomainNotAllowed
+		}
+		return nil
+	}
+
+	return ErrInviteRequired
+}
+
+func redeemInvite(inviteRepo auth_repositories.InviteStore, code string, email *string, emailVerified bool) (*auth_models.Invite, error) {
+	invite, err := inviteRepo.GetByCodeHash(auth_repositories.HashToken(code))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrInvalidInvite
+		}
+		return nil, err
+	}
+
+	// Invites for a specific person can't be passed on
+	if invite.Email != nil {
+		if email == nil || !strings.EqualFold(*invite.Email, *email) {
+			return nil, ErrInvalidInvite
+		}
+		if !emailVerified {
+			return nil, ErrInviteEmailUnverified
+		}
+	}
+
+	if err := inviteRepo.Redeem(invite.ID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrInvalidInvite
+		}
+		return nil, err
+	}
+
+	return invite, nil
+}
+
+// applyInvite gives a newly registered user the position and branch the invite was created with
+func applyInvite(userRepo auth_repositories.UserStore, userID uuid.UUID, invite *auth_models.Invite) error {
+	if invite == nil || (invite.Position == nil && invite.Branch == nil) {
+		return nil
+	}
+
+	return userRepo.Update(userID.String(), auth_models.UpdateUserRequest{
+		Position: invite.Position,
+		Branch:   invite.Branch,
+	})
+}
+
+// releaseInvite gives back the use of an invite when the account couldn't be created
+func releaseInvite(inviteRepo auth_repositories.InviteStore, invite *auth_models.Invite) {
+	if invite == nil {
+		return
+	}
+	inviteRepo.Release(invite.ID)
+}
+
+// CreateInvite generates an invite code. The code is returned once and only its hash is stored.
+func CreateInvite(db *sql.DB, createdBy uuid.UUID, req auth_models.CreateInviteRequest) (string, *auth_models.Invite, error) {
+	code, err := GenerateRandomToken(16)
+	if err != nil {
+		return "", nil, err
+	}
+
+	maxUses := InviteDefaultUses
+	if req.MaxUses > 0 {
+		maxUses = req.MaxUses
+	}
+	expiry := InviteDefaultExpiry
+	if req.ExpiresInDays > 0 {
+		expiry = time.Duration(req.ExpiresInDays) * 24 * time.Hour
+	}
+
+	now := time.Now()
+	invite := &auth_models.Invite{
+		ID:        uuid.New(),
+		CodeHash:  auth_repositories.HashToken(code),
+		Email:     req.Email,
+		Position:  req.Position,
+		Branch:    req.Branch,
+		MaxUses:   maxUses,
+		ExpiresAt: now.Add(expiry),
+		CreatedBy: &createdBy,
+		CreatedAt: now,
+	}
+
+	inviteRepo := auth_repositories.NewInviteRepository(db)
+	if err := inviteRepo.Create(invite); err != nil {
+		return "", nil, err
+	}
+
+	return code, invite, nil
+}
@@ -0,0 +1,19 @@
+package auth_utils_test
+
+import (
+	"testing"
+
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEmailDomainAllowed(t *testing.T) {
+	domains := []string{"csusm.edu", "cougars.csusm.edu"}
+
+	assert.True(t, auth_utils.EmailDomainAllowed("student@cougars.csusm.edu", domains))
+	assert.True(t, auth_utils.EmailDomainAllowed("Professor@CSUSM.edu", domains))
+	assert.False(t, auth_utils.EmailDomainAllowed("someone@gmail.com", domains))
+	assert.False(t, auth_utils.EmailDomainAllowed("someone@evilcsusm.edu", domains))
+	assert.False(t, auth_utils.EmailDomainAllowed("someone@csusm.edu.evil.com", domains))
+	assert.False(t, auth_utils.EmailDomainAllowed("not-an-email", domains))
+}
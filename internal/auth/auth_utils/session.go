@@ -0,0 +1,165 @@
+package auth_utils
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_models"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_repositories"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/models"
+	"github.com/google/uuid"
+)
+
+// issueSession creates an access token and a refresh token belonging to the given family.
+// parentID is the refresh token that was exchanged for this one, nil for a new login.
+// mfa records whether the login passed two-factor authentication and authTime when it
+// happened. Both carry over on refresh.
+func issueSession(refreshTokenRepo auth_repositories.RefreshTokenStore, userID uuid.UUID, role *models.Role, mfa bool, authTime time.Time, familyID uuid.UUID, parentID *int, realIP string, userAgent string) (string, *http.Cookie, error) {
+	accessToken, err := GenerateJWT(userID, role, mfa, authTime, AccessTokenExpiry)
+	if err != nil {
+		return "", nil, ErrAccessToken
+	}
+
+	refreshToken, issuedAt, expiresAt, err := GenerateRefreshToken(userID, role, mfa, authTime, RefreshTokenExpiry)
+	if err != nil {
+		return "", nil, ErrRefreshToken
+	}
+
+	sessionReq := &auth_models.CreateSessionRequest{
+		UserID:    userID,
+		Token:     refreshToken,
+		FamilyID:  familyID,
+		ParentID:  parentID,
+		IssuedAt:  issuedAt,
+		ExpiresAt: expiresAt,
+		IPAddress: realIP,
+		UserAgent: userAgent,
+	}
+
+	err = refreshTokenRepo.Create(sessionReq)
+	if err != nil {
+		return "", nil, ErrNewSession
+	}
+
+	return accessToken, newRefreshCookie(refreshToken, expiresAt), nil
+}
+
+// RotateRefreshToken retires the presented refresh token and issues a new one in the same family.
+//
+// If the presented token was already retired, someone is replaying an old token (either the
+// legitimate client or an attacker holding a stolen copy), so the whole family is revoked and
+// ErrRefreshTokenReused is returned.
+//
+// The role is read from the user rather than copied from the old token, so role changes
+// apply on the next refresh. Suspended users get an *AccountSuspendedError.
+func RotateRefreshToken(refreshTokenRepo auth_repositories.RefreshTokenStore, userRepo auth_repositories.UserStore, presentedToken string, realIP string, userAgent string) (string, *http.Cookie, error) {
+	claims, err := ValidateJWT(presentedToken, RefreshTokenType)
+	if err != nil {
+		return "", nil, ErrInvalidToken
+	}
+
+	storedToken, err := refreshTokenRepo.GetByToken(presentedToken)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", nil, ErrInvalidToken
+		}
+		return "", nil, err
+	}
+
+	if claims.UserID != storedToken.UserID.String() {
+		return "", nil, ErrInvalidToken
+	}
+
+	if storedToken.RevokedAt != nil {
+		return "", nil, revokeFamily(refreshTokenRepo, storedToken.FamilyID)
+	}
+
+	if time.Now().After(storedToken.ExpiresAt) {
+		return "", nil, ErrRefreshTokenExpired
+	}
+
+	user, err := userRepo.GetByID(storedToken.UserID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", nil, ErrInvalidToken
+		}
+		return "", nil, err
+	}
+
+	if err := checkAccountStatus(userRepo, user.ID); err != nil {
+		return "", nil, err
+	}
+
+	retired, err := refreshTokenRepo.Retire(storedToken.ID)
+	if err != nil {
+		return "", nil, err
+	}
+	if !retired {
+		// Lost a race with another refresh using the same token
+		return "", nil, revokeFamily(refreshTokenRepo, storedToken.FamilyID)
+	}
+
+	var authTime time.Time
+	if claims.AuthTime != nil {
+		authTime = claims.AuthTime.Time
+	}
+
+	return issueSession(refreshTokenRepo, user.ID, user.Role, claims.MFA, authTime, storedToken.FamilyID, &storedToken.ID, realIP, userAgent)
+}
+
+func revokeFamily(refreshTokenRepo auth_repositories.RefreshTokenStore, familyID uuid.UUID) error {
+	if err := refreshTokenRepo.DeleteByFamilyID(familyID); err != nil {
+		return err
+	}
+	return ErrRefreshTokenReused
+}
+
+// ListSessions returns the user's active sessions, flagging the one the current refresh token belongs to
+func ListSessions(db *sql.DB, userID uuid.UUID, currentToken string) ([]*auth_models.Session, error) {
+	return listSessions(auth_repositories.NewRefreshTokenRepository(db), userID, currentToken)
+}
+
+func listSessions(refreshTokenRepo auth_repositories.RefreshTokenStore, userID uuid.UUID, currentToken string) ([]*auth_models.Session, error) {
+	sessions, err := refreshTokenRepo.GetActiveSessionsByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var currentFamilyID uuid.UUID
+	if currentToken != "" {
+		if currentSession, err := refreshTokenRepo.GetByToken(currentToken); err == nil && currentSession.UserID == userID {
+			currentFamilyID = currentSession.FamilyID
+		}
+	}
+
+	for _, session := range sessions {
+		session.Device = ParseUserAgent(session.UserAgent)
+		session.Current = currentFamilyID != uuid.Nil && session.ID == currentFamilyID
+	}
+
+	return sessions, nil
+}
+
+// RevokeOtherSessions signs the user out of every session except the one the refresh
+// token belongs to, and revokes their personal access tokens. Without a refresh token
+// of the user, every session is revoked.
+func RevokeOtherSessions(db *sql.DB, userID uuid.UUID, currentToken string) error {
+	if err := revokeRefreshTokens(db, userID, currentToken); err != nil {
+		return err
+	}
+
+	patRepo := auth_repositories.NewPersonalAccessTokenRepository(db)
+	return patRepo.DeleteAllByUserID(userID)
+}
+
+func revokeRefreshTokens(db *sql.DB, userID uuid.UUID, currentToken string) error {
+	refreshTokenRepo := auth_repositories.NewRefreshTokenRepository(db)
+	if currentToken != "" {
+		if session, err := refreshTokenRepo.GetByToken(currentToken); err == nil && session.UserID == userID {
+			return refreshTokenRepo.DeleteAllByUserIDExceptFamily(userID, session.FamilyID)
+		}
+	}
+	return refreshTokenRepo.DeleteAllByUserID(userID.String())
+}
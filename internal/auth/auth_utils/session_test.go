@@ -0,0 +1,262 @@
+package auth_utils_test
+
+import (
+	"database/sql"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_models"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_repositories"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_utils"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/mocks"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestMain(m *testing.M) {
+	os.Setenv("GO_ENV", "test")
+	os.Exit(m.Run())
+}
+
+func newStoredToken(t *testing.T, userID uuid.UUID) (string, *auth_models.RefreshToken) {
+	role := models.UserRole
+	token, issuedAt, expiresAt, err := auth_utils.GenerateRefreshToken(userID, &role, false, time.Now(), auth_utils.RefreshTokenExpiry)
+	require.NoError(t, err)
+
+	return token, &auth_models.RefreshToken{
+		ID:        1,
+		UserID:    userID,
+		TokenHash: auth_repositories.HashToken(token),
+		FamilyID:  uuid.New(),
+		IssuedAt:  issuedAt,
+		ExpiresAt: expiresAt,
+	}
+}
+
+// expectActiveUser makes the user store return a user in good standing with the given role
+func expectActiveUser(users *mocks.MockUserStore, userID uuid.UUID, role models.Role) {
+	users.EXPECT().GetByID(userID).Return(&models.User{ID: userID, Role: &role}, nil)
+	users.EXPECT().GetStatus(userID).Return(&auth_models.AccountStatus{}, nil)
+}
+
+func TestRotateRefreshToken(t *testing.T) {
+	userID := uuid.New()
+
+	t.Run("Issues a child token in the same family", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repo := mocks.NewMockRefreshTokenStore(ctrl)
+		users := mocks.NewMockUserStore(ctrl)
+		token, stored := newStoredToken(t, userID)
+
+		expectActiveUser(users, userID, models.UserRole)
+		repo.EXPECT().GetByToken(token).Return(stored, nil)
+		repo.EXPECT().Retire(stored.ID).Return(true, nil)
+		repo.EXPECT().Create(gomock.Any()).DoAndReturn(func(req *auth_models.CreateSessionRequest) error {
+			assert.Equal(t, userID, req.UserID)
+			assert.Equal(t, stored.FamilyID, req.FamilyID)
+			require.NotNil(t, req.ParentID)
+			assert.Equal(t, stored.ID, *req.ParentID)
+			assert.NotEqual(t, token, req.Token)
+			return nil
+		})
+
+		accessToken, cookie, err := auth_utils.RotateRefreshToken(repo, users, token, "127.0.0.1", "test-agent")
+		require.NoError(t, err)
+		assert.NotEmpty(t, accessToken)
+		assert.NotEqual(t, token, cookie.Value)
+	})
+
+	t.Run("Replaying a retired token revokes the family", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repo := mocks.NewMockRefreshTokenStore(ctrl)
+		users := mocks.NewMockUserStore(ctrl)
+		token, stored := newStoredToken(t, userID)
+		retiredAt := time.Now().Add(-time.Minute)
+		stored.RevokedAt = &retiredAt
+
+		repo.EXPECT().GetByToken(token).Return(stored, nil)
+		repo.EXPECT().DeleteByFamilyID(stored.FamilyID).Return(nil)
+
+		_, cookie, err := auth_utils.RotateRefreshToken(repo, users, token, "127.0.0.1", "test-agent")
+		assert.ErrorIs(t, err, auth_utils.ErrRefreshTokenReused)
+		assert.Nil(t, cookie)
+	})
+
+	t.Run("Concurrent refresh with the same token revokes the family", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repo := mocks.NewMockRefreshTokenStore(ctrl)
+		users := mocks.NewMockUserStore(ctrl)
+		token, stored := newStoredToken(t, userID)
+
+		expectActiveUser(users, userID, models.UserRole)
+		repo.EXPECT().GetByToken(token).Return(stored, nil)
+		repo.EXPECT().Retire(stored.ID).Return(false, nil)
+		repo.EXPECT().DeleteByFamilyID(stored.FamilyID).Return(nil)
+
+		_, _, err := auth_utils.RotateRefreshToken(repo, users, token, "127.0.0.1", "test-agent")
+		assert.ErrorIs(t, err, auth_utils.ErrRefreshTokenReused)
+	})
+
+	t.Run("Rotated token replayed after a successful refresh", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repo := mocks.NewMockRefreshTokenStore(ctrl)
+		users := mocks.NewMockUserStore(ctrl)
+		token, stored := newStoredToken(t, userID)
+
+		expectActiveUser(users, userID, models.UserRole)
+		repo.EXPECT().GetByToken(token).Return(stored, nil).Times(2)
+		repo.EXPECT().Retire(stored.ID).DoAndReturn(func(id int) (bool, error) {
+			now := time.Now()
+			stored.RevokedAt = &now
+			return true, nil
+		})
+		repo.EXPECT().Create(gomock.Any()).Return(nil)
+		repo.EXPECT().DeleteByFamilyID(stored.FamilyID).Return(nil)
+
+		_, _, err := auth_utils.RotateRefreshToken(repo, users, token, "127.0.0.1", "legit-client")
+		require.NoError(t, err)
+
+		_, _, err = auth_utils.RotateRefreshToken(repo, users, token, "10.0.0.1", "attacker")
+		assert.ErrorIs(t, err, auth_utils.ErrRefreshTokenReused)
+	})
+
+	t.Run("Keeps the two-factor authentication claim and sign-in time", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repo := mocks.NewMockRefreshTokenStore(ctrl)
+		users := mocks.NewMockUserStore(ctrl)
+		_, stored := newStoredToken(t, userID)
+
+		role := models.AdminRole
+		signedInAt := time.Now().Add(-time.Hour).Truncate(time.Second)
+		token, _, _, err := auth_utils.GenerateRefreshToken(userID, &role, true, signedInAt, auth_utils.RefreshTokenExpiry)
+		require.NoError(t, err)
+
+		expectActiveUser(users, userID, role)
+		repo.EXPECT().GetByToken(token).Return(stored, nil)
+		repo.EXPECT().Retire(stored.ID).Return(true, nil)
+		repo.EXPECT().Create(gomock.Any()).Return(nil)
+
+		accessToken, _, err := auth_utils.RotateRefreshToken(repo, users, token, "127.0.0.1", "test-agent")
+		require.NoError(t, err)
+
+		claims, err := auth_utils.ValidateJWT(accessToken, auth_utils.AccessTokenType)
+		require.NoError(t, err)
+		assert.True(t, claims.MFA)
+		assert.Equal(t, role.String(), claims.Role)
+		require.NotNil(t, claims.AuthTime)
+		assert.True(t, signedInAt.Equal(claims.AuthTime.Time), "refreshing isn't signing in again")
+	})
+
+	t.Run("Picks up a role change since the last refresh", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repo := mocks.NewMockRefreshTokenStore(ctrl)
+		users := mocks.NewMockUserStore(ctrl)
+		token, stored := newStoredToken(t, userID)
+
+		expectActiveUser(users, userID, models.AdminRole)
+		repo.EXPECT().GetByToken(token).Return(stored, nil)
+		repo.EXPECT().Retire(stored.ID).Return(true, nil)
+		repo.EXPECT().Create(gomock.Any()).Return(nil)
+
+		accessToken, _, err := auth_utils.RotateRefreshToken(repo, users, token, "127.0.0.1", "test-agent")
+		require.NoError(t, err)
+
+		claims, err := auth_utils.ValidateJWT(accessToken, auth_utils.AccessTokenType)
+		require.NoError(t, err)
+		assert.Equal(t, models.AdminRole.String(), claims.Role)
+	})
+
+	t.Run("Suspended user", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repo := mocks.NewMockRefreshTokenStore(ctrl)
+		users := mocks.NewMockUserStore(ctrl)
+		token, stored := newStoredToken(t, userID)
+		until := time.Now().Add(time.Hour)
+
+		repo.EXPECT().GetByToken(token).Return(stored, nil)
+		users.EXPECT().GetByID(userID).Return(&models.User{ID: userID}, nil)
+		users.EXPECT().GetStatus(userID).Return(&auth_models.AccountStatus{SuspendedUntil: &until}, nil)
+
+		_, cookie, err := auth_utils.RotateRefreshToken(repo, users, token, "127.0.0.1", "test-agent")
+		var suspendedErr *auth_utils.AccountSuspendedError
+		require.ErrorAs(t, err, &suspendedErr)
+		assert.WithinDuration(t, until, *suspendedErr.Until, time.Second)
+		assert.Nil(t, cookie)
+	})
+
+	t.Run("Banned user", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repo := mocks.NewMockRefreshTokenStore(ctrl)
+		users := mocks.NewMockUserStore(ctrl)
+		token, stored := newStoredToken(t, userID)
+		bannedAt := time.Now().Add(-time.Hour)
+
+		repo.EXPECT().GetByToken(token).Return(stored, nil)
+		users.EXPECT().GetByID(userID).Return(&models.User{ID: userID}, nil)
+		users.EXPECT().GetStatus(userID).Return(&auth_models.AccountStatus{BannedAt: &bannedAt}, nil)
+
+		_, _, err := auth_utils.RotateRefreshToken(repo, users, token, "127.0.0.1", "test-agent")
+		var suspendedErr *auth_utils.AccountSuspendedError
+		require.ErrorAs(t, err, &suspendedErr)
+		assert.Nil(t, suspendedErr.Until)
+	})
+
+	t.Run("Expired suspension", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repo := mocks.NewMockRefreshTokenStore(ctrl)
+		users := mocks.NewMockUserStore(ctrl)
+		token, stored := newStoredToken(t, userID)
+		until := time.Now().Add(-time.Minute)
+
+		repo.EXPECT().GetByToken(token).Return(stored, nil)
+		users.EXPECT().GetByID(userID).Return(&models.User{ID: userID}, nil)
+		users.EXPECT().GetStatus(userID).Return(&auth_models.AccountStatus{SuspendedUntil: &until}, nil)
+		repo.EXPECT().Retire(stored.ID).Return(true, nil)
+		repo.EXPECT().Create(gomock.Any()).Return(nil)
+
+		_, _, err := auth_utils.RotateRefreshToken(repo, users, token, "127.0.0.1", "test-agent")
+		require.NoError(t, err)
+	})
+
+	t.Run("Deleted user", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repo := mocks.NewMockRefreshTokenStore(ctrl)
+		users := mocks.NewMockUserStore(ctrl)
+		token, stored := newStoredToken(t, userID)
+
+		repo.EXPECT().GetByToken(token).Return(stored, nil)
+		users.EXPECT().GetByID(userID).Return(nil, sql.ErrNoRows)
+
+		_, _, err := auth_utils.RotateRefreshToken(repo, users, token, "127.0.0.1", "test-agent")
+		assert.ErrorIs(t, err, auth_utils.ErrInvalidToken)
+	})
+
+	t.Run("Unknown token", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repo := mocks.NewMockRefreshTokenStore(ctrl)
+		users := mocks.NewMockUserStore(ctrl)
+		token, _ := newStoredToken(t, userID)
+
+		repo.EXPECT().GetByToken(token).Return(nil, sql.ErrNoRows)
+
+		_, _, err := auth_utils.RotateRefreshToken(repo, users, token, "127.0.0.1", "test-agent")
+		assert.ErrorIs(t, err, auth_utils.ErrInvalidToken)
+	})
+
+	t.Run("Expired token", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repo := mocks.NewMockRefreshTokenStore(ctrl)
+		users := mocks.NewMockUserStore(ctrl)
+		token, stored := newStoredToken(t, userID)
+		stored.ExpiresAt = time.Now().Add(-time.Minute)
+
+		repo.EXPECT().GetByToken(token).Return(stored, nil)
+
+		_, _, err := auth_utils.RotateRefreshToken(repo, users, token, "127.0.0.1", "test-agent")
+		assert.ErrorIs(t, err, auth_utils.ErrRefreshTokenExpired)
+	})
+}
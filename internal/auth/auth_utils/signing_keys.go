@@ -0,0 +1,207 @@
+package auth_utils
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/csusmGDSC/csusmgdsc-api/config"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// SigningKey is an Ed25519 key tokens are signed or verified with. Private is nil for
+// retired keys that are only kept to verify tokens issued before a rotation.
+type SigningKey struct {
+	ID      string
+	Private ed25519.PrivateKey
+	Public  ed25519.PublicKey
+}
+
+// KeySet holds every key tokens may be verified with and the one new tokens are signed with
+type KeySet struct {
+	signing *SigningKey
+	keys    map[string]*SigningKey
+}
+
+// JWK is a public key in JSON Web Key format (RFC 8037)
+type JWK struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+}
+
+var (
+	keySet     *KeySet
+	keySetErr  error
+	keySetOnce sync.Once
+)
+
+// InitSigningKeys loads the token signing keys so a bad key directory stops the server at startup
+// rather than on the first login.
+func InitSigningKeys() error {
+	_, err := signingKeys()
+	return err
+}
+
+func signingKeys() (*KeySet, error) {
+	keySetOnce.Do(func() {
+		cfg := config.LoadConfig()
+		if cfg.JWTKeyDir == "" {
+			if cfg.Environment == "production" {
+				keySetErr = errors.New("JWT_KEY_DIR must be set in production")
+				return
+			}
+			log.Println("Warning: JWT_KEY_DIR is not set, signing tokens with a temporary key. Sessions won't survive a restart.")
+			keySet, keySetErr = NewEphemeralKeySet()
+			return
+		}
+		keySet, keySetErr = LoadKeySet(cfg.JWTKeyDir, cfg.JWTSigningKeyID)
+	})
+	return keySet, keySetErr
+}
+
+// LoadKeySet reads every *.pem file in dir. Each file holds a PKCS #8 Ed25519 private key,
+// or a PKIX public key for a retired key, and its name without the extension is the key ID.
+//
+// New tokens are signed with signingKeyID, or the private key whose ID sorts last when it's
+// empty, so naming keys by date (e.g. 2026-10.pem) rotates to a new key as soon as it's added.
+func LoadKeySet(dir string, signingKeyID string) (*KeySet, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	set := &KeySet{keys: map[string]*SigningKey{}}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		id := strings.TrimSuffix(filepath.Base(path), ".pem")
+		key, err := parseSigningKey(id, data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load signing key %s: %w", path, err)
+		}
+		set.keys[id] = key
+
+		if key.Private != nil && (signingKeyID == "" || signingKeyID == id) {
+			set.signing = key
+		}
+	}
+
+	if set.signing == nil {
+		if signingKeyID != "" {
+			return nil, fmt.Errorf("no private key with ID %q in %s", signingKeyID, dir)
+		}
+		return nil, fmt.Errorf("no private keys in %s", dir)
+	}
+
+	return set, nil
+}
+
+// NewEphemeralKeySet generates a key set holding a single new key, for development and tests
+func NewEphemeralKeySet() (*KeySet, error) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	key := &SigningKey{ID: "ephemeral", Private: private, Public: public}
+	return &KeySet{signing: key, keys: map[string]*SigningKey{key.ID: key}}, nil
+}
+
+func parseSigningKey(id string, data []byte) (*SigningKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM data found")
+	}
+
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		private, ok := parsed.(ed25519.PrivateKey)
+		if !ok {
+			return nil, errors.New("not an Ed25519 key")
+		}
+		return &SigningKey{ID: id, Private: private, Public: private.Public().(ed25519.PublicKey)}, nil
+	case "PUBLIC KEY":
+		parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		public, ok := parsed.(ed25519.PublicKey)
+		if !ok {
+			return nil, errors.New("not an Ed25519 key")
+		}
+		return &SigningKey{ID: id, Public: public}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+}
+
+// Sign signs the claims with the current signing key, setting the kid header
+func (s *KeySet) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims)
+	token.Header["kid"] = s.signing.ID
+	return token.SignedString(s.signing.Private)
+}
+
+// Keyfunc picks the verification key named by a token's kid header
+func (s *KeySet) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := s.keys[kid]
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+	return key.Public, nil
+}
+
+// JWKS returns the public keys in JSON Web Key Set format, for other services to verify tokens with
+func (s *KeySet) JWKS() map[string][]JWK {
+	ids := make([]string, 0, len(s.keys))
+	for id := range s.keys {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	keys := make([]JWK, 0, len(ids))
+	for _, id := range ids {
+		keys = append(keys, JWK{
+			Kty: "OKP",
+			Crv: "Ed25519",
+			X:   base64.RawURLEncoding.EncodeToString(s.keys[id].Public),
+			Kid: id,
+			Use: "sig",
+			Alg: jwt.SigningMethodEdDSA.Alg(),
+		})
+	}
+
+	return map[string][]JWK{"keys": keys}
+}
+
+// PublicJWKS returns the JSON Web Key Set of the server's signing keys
+func PublicJWKS() (map[string][]JWK, error) {
+	keys, err := signingKeys()
+	if err != nil {
+		return nil, err
+	}
+	return keys.JWKS(), nil
+}
@@ -0,0 +1,85 @@
+package auth_utils_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_utils"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeKey(t *testing.T, dir string, name string, public bool) {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	var block *pem.Block
+	if public {
+		der, err := x509.MarshalPKIXPublicKey(private.Public())
+		require.NoError(t, err)
+		block = &pem.Block{Type: "PUBLIC KEY", Bytes: der}
+	} else {
+		der, err := x509.MarshalPKCS8PrivateKey(private)
+		require.NoError(t, err)
+		block = &pem.Block{Type: "PRIVATE KEY", Bytes: der}
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name+".pem"), pem.EncodeToMemory(block), 0o600))
+}
+
+func TestLoadKeySet(t *testing.T) {
+	dir := t.TempDir()
+	writeKey(t, dir, "2026-01", true)
+	writeKey(t, dir, "2026-04", false)
+	writeKey(t, dir, "2026-10", false)
+
+	claims := jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute))}
+
+	t.Run("Signs with the last key by default", func(t *testing.T) {
+		keys, err := auth_utils.LoadKeySet(dir, "")
+		require.NoError(t, err)
+
+		token, err := keys.Sign(claims)
+		require.NoError(t, err)
+
+		parsed, err := jwt.Parse(token, keys.Keyfunc, jwt.WithValidMethods([]string{"EdDSA"}))
+		require.NoError(t, err)
+		assert.Equal(t, "2026-10", parsed.Header["kid"])
+		assert.Len(t, keys.JWKS()["keys"], 3)
+	})
+
+	t.Run("Signs with the configured key", func(t *testing.T) {
+		keys, err := auth_utils.LoadKeySet(dir, "2026-04")
+		require.NoError(t, err)
+
+		token, err := keys.Sign(claims)
+		require.NoError(t, err)
+
+		parsed, err := jwt.Parse(token, keys.Keyfunc)
+		require.NoError(t, err)
+		assert.Equal(t, "2026-04", parsed.Header["kid"])
+	})
+
+	t.Run("Public keys can't sign", func(t *testing.T) {
+		_, err := auth_utils.LoadKeySet(dir, "2026-01")
+		assert.Error(t, err)
+	})
+
+	t.Run("Rejects tokens signed by unknown keys", func(t *testing.T) {
+		other, err := auth_utils.NewEphemeralKeySet()
+		require.NoError(t, err)
+		token, err := other.Sign(claims)
+		require.NoError(t, err)
+
+		keys, err := auth_utils.LoadKeySet(dir, "")
+		require.NoError(t, err)
+		_, err = jwt.Parse(token, keys.Keyfunc)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,51 @@
+package auth_utils_test
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateTOTPCode(t *testing.T) {
+	// SHA1 test vectors from RFC 6238 Appendix B, truncated to 6 digits
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+	vectors := map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1111111111: "050471",
+		1234567890: "005924",
+		2000000000: "279037",
+	}
+
+	for unix, expected := range vectors {
+		code, err := auth_utils.GenerateTOTPCode(secret, auth_utils.TOTPStep(time.Unix(unix, 0)))
+		require.NoError(t, err)
+		assert.Equal(t, expected, code, "time %d", unix)
+	}
+}
+
+func TestValidateTOTPCode(t *testing.T) {
+	secret, err := auth_utils.GenerateTOTPSecret()
+	require.NoError(t, err)
+
+	now := time.Now()
+	code, err := auth_utils.GenerateTOTPCode(secret, auth_utils.TOTPStep(now))
+	require.NoError(t, err)
+
+	step, ok := auth_utils.ValidateTOTPCode(secret, code, now)
+	assert.True(t, ok)
+	assert.Equal(t, auth_utils.TOTPStep(now), step)
+
+	// One step of clock drift is tolerated, more is not
+	_, ok = auth_utils.ValidateTOTPCode(secret, code, now.Add(30*time.Second))
+	assert.True(t, ok)
+	_, ok = auth_utils.ValidateTOTPCode(secret, code, now.Add(2*time.Minute))
+	assert.False(t, ok)
+
+	_, ok = auth_utils.ValidateTOTPCode(secret, "12345", now)
+	assert.False(t, ok)
+}
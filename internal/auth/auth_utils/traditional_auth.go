@@ -3,32 +3,64 @@ package auth_utils
 import (
 	"database/sql"
 	"errors"
-	"fmt"
+	"log"
+	"sync"
 	"time"
 
-	"github.com/csusmGDSC/csusmgdsc-api/config"
 	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_models"
 	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_repositories"
 	"github.com/csusmGDSC/csusmgdsc-api/internal/models"
 	"github.com/google/uuid"
-	"github.com/resend/resend-go/v2"
 	"golang.org/x/crypto/bcrypt"
 )
 
 var (
-	ErrUserExists         = errors.New("user already exists")
-	ErrUserDoesntExist    = errors.New("user doesn't exist")
-	ErrInvalidCredentials = errors.New("invalid credentials")
-	ErrInvalidToken       = errors.New("invalid token")
-	ErrAccessToken        = errors.New("failed to generate access token")
-	ErrRefreshToken       = errors.New("failed to generate refresh token")
-	ErrVerificationToken  = errors.New("failed to generate a verification token")
-	ErrNewSession         = errors.New("failed to create new session")
+	ErrUserExists            = errors.New("user already exists")
+	ErrUserDoesntExist       = errors.New("user doesn't exist")
+	ErrInvalidCredentials    = errors.New("invalid credentials")
+	ErrInvalidToken          = errors.New("invalid token")
+	ErrAccessToken           = errors.New("failed to generate access token")
+	ErrRefreshToken          = errors.New("failed to generate refresh token")
+	ErrVerificationToken     = errors.New("failed to generate a verification token")
+	ErrNewSession            = errors.New("failed to create new session")
+	ErrInvalidOAuthState     = errors.New("invalid or expired oauth state")
+	ErrRefreshTokenExpired   = errors.New("refresh token expired")
+	ErrRefreshTokenReused    = errors.New("refresh token reuse detected")
+	ErrTokenAlreadyUsed      = errors.New("token has already been used")
+	ErrMFAAlreadyEnabled     = errors.New("two-factor authentication is already enabled")
+	ErrMFANotEnrolled        = errors.New("two-factor authentication is not enabled")
+	ErrInvalidMFACode        = errors.New("invalid two-factor authentication code")
+	ErrMFARequired           = errors.New("two-factor authentication is required for this account")
+	ErrIdentityInUse         = errors.New("identity is linked to another account")
+	ErrIdentityNotFound      = errors.New("identity not found")
+	ErrLastLoginMethod       = errors.New("can't remove the only way to sign in")
+	ErrRoleNotFound          = errors.New("role not found")
+	ErrPrimaryRole           = errors.New("primary roles can't be granted")
+	ErrCannotImpersonate     = errors.New("user can't be impersonated")
+	ErrTooManyTokens         = errors.New("too many personal access tokens")
+	ErrInvalidScope          = errors.New("invalid token scope")
+	ErrInviteRequired        = errors.New("registration requires an invite")
+	ErrInvalidInvite         = errors.New("invalid or expired invite")
+	ErrInviteEmailUnverified = errors.New("invite requires a verified email")
+	ErrEmailDomainNotAllowed = errors.New("email domain is not allowed to register")
+	ErrEmailNotVerified      = errors.New("email is not verified")
+	ErrEmailInUse            = errors.New("email is already in use")
+	ErrEmailUnchanged        = errors.New("new email is the same as the current email")
+	ErrReauthRequired        = errors.New("sign in again to continue")
+	ErrNoDeletionScheduled   = errors.New("account is not scheduled for deletion")
+	ErrNoPassword            = errors.New("account doesn't have a password")
+	ErrPasswordUnchanged     = errors.New("new password is the same as the current password")
 )
 
+// RegisterUserTraditionalAuthToDatabase creates an account with the email the user typed
+// in, unverified until they follow the verification link
 func RegisterUserTraditionalAuthToDatabase(db *sql.DB, req auth_models.CreateUserTraditionalAuthRequest) (*models.User, error) {
-	userRepo := auth_repositories.NewUserRepository(db)
+	return registerUser(auth_repositories.NewUserRepository(db), auth_repositories.NewInviteRepository(db), req, false)
+}
 
+// registerUser creates an account for an email and optional password. emailVerified is
+// true when the user has already shown they own the address.
+func registerUser(userRepo auth_repositories.UserStore, inviteRepo auth_repositories.InviteStore, req auth_models.CreateUserTraditionalAuthRequest, emailVerified bool) (*models.User, error) {
 	exists, err := userRepo.EmailExists(*req.Email)
 	if err != nil {
 		return nil, err
@@ -37,9 +69,22 @@ func RegisterUserTraditionalAuthToDatabase(db *sql.DB, req auth_models.CreateUse
 		return nil, ErrUserExists
 	}
 
+	if req.Password != nil {
+		if err := CheckPasswordPolicy(*req.Password, *req.Email); err != nil {
+			return nil, err
+		}
+	}
+
+	// An address that isn't verified yet is proven later by the verification email
+	invite, err := redeemRegistration(inviteRepo, req.Email, emailVerified, true, req.InviteCode)
+	if err != nil {
+		return nil, err
+	}
+
 	if req.Password != nil {
 		hashedPassword, err := HashPassword(*req.Password)
 		if err != nil {
+			releaseInvite(inviteRepo, invite)
 			return nil, err
 		}
 		req.Password = &hashedPassword
@@ -52,11 +97,16 @@ func RegisterUserTraditionalAuthToDatabase(db *sql.DB, req auth_models.CreateUse
 		CreatedAt:     time.Now(),
 		UpdatedAt:     time.Now(),
 		IsOnboarded:   false,
-		EmailVerified: false,
+		EmailVerified: emailVerified,
 	}
 
 	err = userRepo.Create(user)
 	if err != nil {
+		releaseInvite(inviteRepo, invite)
+		return nil, err
+	}
+
+	if err := applyInvite(userRepo, user.ID, invite); err != nil {
 		return nil, err
 	}
 
@@ -64,22 +114,66 @@ func RegisterUserTraditionalAuthToDatabase(db *sql.DB, req auth_models.CreateUse
 	return user, nil
 }
 
-func AuthenticateUser(db *sql.DB, req auth_models.LoginRequest) (*models.User, error) {
+// AuthenticateUser checks an email and password, counting failures towards a lockout of the
+// account and the client's IP address. Unknown emails, accounts without a password and wrong
+// passwords all return ErrInvalidCredentials so the response doesn't reveal which emails have
+// accounts. Returns a *LoginLockedError while locked out.
+func AuthenticateUser(db *sql.DB, req auth_models.LoginRequest, ipAddress string) (*models.User, error) {
+	throttleRepo := auth_repositories.NewLoginThrottleRepository(db)
+	auditRepo := auth_repositories.NewAuditLogRepository(db)
+	if err := CheckLoginThrottle(throttleRepo, req.Email, ipAddress); err != nil {
+		return nil, err
+	}
+
 	userRepo := auth_repositories.NewUserRepository(db)
 
 	user, err := userRepo.GetByEmail(req.Email)
-	if err != nil {
+	if err != nil && err != sql.ErrNoRows {
 		return nil, err
 	}
 
+	if user == nil || user.Password == nil {
+		// Spend the same time as a real comparison so response times don't reveal anything either
+		_ = ComparePasswords(dummyPasswordHash(), req.Password)
+
+		var userID *uuid.UUID
+		if user != nil {
+			userID = &user.ID
+		}
+		if err := RecordLoginFailure(throttleRepo, auditRepo, req.Email, ipAddress, userID); err != nil {
+			return nil, err
+		}
+		return nil, ErrInvalidCredentials
+	}
+
 	if err := ComparePasswords(*user.Password, req.Password); err != nil {
+		if err := RecordLoginFailure(throttleRepo, auditRepo, req.Email, ipAddress, &user.ID); err != nil {
+			return nil, err
+		}
 		return nil, ErrInvalidCredentials
 	}
 
+	if err := ResetLoginFailures(db, req.Email); err != nil {
+		log.Printf("Failed to reset login failures: %v", err)
+	}
+
 	user.Password = nil // Clear password before returning
 	return user, nil
 }
 
+var (
+	dummyHash     string
+	dummyHashOnce sync.Once
+)
+
+// dummyPasswordHash is compared against when there's no real hash to check
+func dummyPasswordHash() string {
+	dummyHashOnce.Do(func() {
+		dummyHash, _ = HashPassword("not-a-real-password")
+	})
+	return dummyHash
+}
+
 func HashPassword(password string) (string, error) {
 	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
@@ -91,45 +185,3 @@ func HashPassword(password string) (string, error) {
 func ComparePasswords(hashedPassword, password string) error {
 	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
 }
-
-func SendVerificationEmail(userEmail string, verificationToken string) error {
-
-	// Load the conigration keys
-	cfg := config.LoadConfig()
-	apiKey := cfg.ResendAPIKey
-
-	client := resend.NewClient(apiKey)
-
-	emailDomain := "gdsc-csusm.com" // Needs to be verified to be able to send email or use verified Domain
-	URL := "https://gdsc-csusm.com/verify"
-
-	params := &resend.SendEmailRequest{
-		From: fmt.Sprintf("CSUSM_GDSC <CSUSM_GDSC@%s>", emailDomain),
-		To:   []string{userEmail},
-		Html: fmt.Sprintf(`
-			<p>Hello %s,</p>
-			<p>Welcome to GDSC-CSUSM! Please verify your email by clicking the button below:</p>
-			<p>
-				<a href="%s?token=%s" style="
-					display: inline-block;
-					padding: 10px 20px;
-					font-size: 16px;
-					color: #fff;
-					background-color: #007bff;
-					text-decoration: none;
-					border-radius: 5px;">
-					Verify Email
-				</a>
-			</p>
-			<p>If you didn’t request this, please ignore this email.</p>
-			<p>Best,<br>GDSC-CSUSM Team</p>
-		`, userEmail, URL, verificationToken),
-		Subject: "Verify Your Email for GDSC-CSUSM",
-	}
-
-	_, err := client.Emails.Send(params)
-	if err != nil {
-		return err
-	}
-	return nil
-}
@@ -0,0 +1,67 @@
+package auth_utils
+
+import (
+	"strings"
+
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_models"
+)
+
+// ParseUserAgent extracts a rough browser, OS and device type from a User-Agent header
+// so sessions can be shown as e.g. "Chrome on Windows". It only needs to be good enough
+// for a user to recognize their own devices.
+func ParseUserAgent(userAgent string) auth_models.DeviceInfo {
+	device := auth_models.DeviceInfo{
+		Browser: "Unknown",
+		OS:      "Unknown",
+		Type:    "unknown",
+	}
+	if userAgent == "" {
+		return device
+	}
+
+	// Order matters: most browsers include the tokens of the browsers they are based on
+	switch {
+	case strings.Contains(userAgent, "Edg/"), strings.Contains(userAgent, "EdgiOS"), strings.Contains(userAgent, "EdgA"):
+		device.Browser = "Edge"
+	case strings.Contains(userAgent, "OPR/"), strings.Contains(userAgent, "Opera"):
+		device.Browser = "Opera"
+	case strings.Contains(userAgent, "SamsungBrowser"):
+		device.Browser = "Samsung Internet"
+	case strings.Contains(userAgent, "Firefox/"), strings.Contains(userAgent, "FxiOS"):
+		device.Browser = "Firefox"
+	case strings.Contains(userAgent, "Chrome/"), strings.Contains(userAgent, "CriOS"):
+		device.Browser = "Chrome"
+	case strings.Contains(userAgent, "Safari/"):
+		device.Browser = "Safari"
+	case strings.HasPrefix(userAgent, "curl/"):
+		device.Browser = "curl"
+	case strings.HasPrefix(userAgent, "PostmanRuntime"):
+		device.Browser = "Postman"
+	}
+
+	switch {
+	case strings.Contains(userAgent, "Windows"):
+		device.OS = "Windows"
+	case strings.Contains(userAgent, "iPhone"), strings.Contains(userAgent, "iPad"), strings.Contains(userAgent, "iPod"):
+		device.OS = "iOS"
+	case strings.Contains(userAgent, "Android"):
+		device.OS = "Android"
+	case strings.Contains(userAgent, "CrOS"):
+		device.OS = "ChromeOS"
+	case strings.Contains(userAgent, "Macintosh"), strings.Contains(userAgent, "Mac OS X"):
+		device.OS = "macOS"
+	case strings.Contains(userAgent, "Linux"):
+		device.OS = "Linux"
+	}
+
+	switch {
+	case strings.Contains(userAgent, "iPad"), strings.Contains(userAgent, "Tablet"):
+		device.Type = "tablet"
+	case strings.Contains(userAgent, "Mobi"), strings.Contains(userAgent, "iPhone"), strings.Contains(userAgent, "Android"):
+		device.Type = "mobile"
+	case strings.Contains(userAgent, "Mozilla/"):
+		device.Type = "desktop"
+	}
+
+	return device
+}
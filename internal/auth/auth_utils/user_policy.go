@@ -0,0 +1,142 @@
+package auth_utils
+
+import (
+	"database/sql"
+	"reflect"
+	"strings"
+
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_models"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_repositories"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/models"
+	"github.com/google/uuid"
+)
+
+// UserUpdateAccess describes how the caller of UpdateUser relates to the user being updated
+type UserUpdateAccess struct {
+	Self           bool // updating their own profile
+	CanManageUsers bool // holds users:manage
+	BranchOfficer  bool // officer of the target user's branch
+}
+
+// Allowed reports whether the caller may update the user at all
+func (a UserUpdateAccess) Allowed() bool {
+	return a.Self || a.CanManageUsers || a.BranchOfficer
+}
+
+// profileFields can be changed by the user themselves and by user managers
+var profileFields = map[string]bool{
+	"first_name":      true,
+	"last_name":       true,
+	"image":           true,
+	"github":          true,
+	"linkedin":        true,
+	"instagram":       true,
+	"discord":         true,
+	"bio":             true,
+	"tags":            true,
+	"website":         true,
+	"graduation_date": true,
+	"is_onboarded":    true,
+	"branch":          true,
+}
+
+// selfAssignablePositions are the positions users may give themselves.
+// Every other position is assigned by a branch officer or a user manager.
+var selfAssignablePositions = map[models.GDSCPosition]bool{
+	models.Student: true,
+	models.Alumni:  true,
+}
+
+// Role, total_points and email_verified are never accepted here; role and points
+// changes go through the audited admin endpoint and email_verified is only set
+// by verifying the email address.
+
+// AccessForUserUpdate works out the caller's relationship to the target user
+func AccessForUserUpdate(db *sql.DB, actorID uuid.UUID, target *models.User) (UserUpdateAccess, error) {
+	access := UserUpdateAccess{Self: actorID == target.ID}
+
+	rbacRepo := auth_repositories.NewRBACRepository(db)
+	canManage, err := HasPermissions(rbacRepo, actorID, models.UsersManage)
+	if err != nil {
+		return access, err
+	}
+	access.CanManageUsers = canManage
+
+	if target.Branch != nil {
+		if officerRole, ok := models.BranchOfficerRoles[*target.Branch]; ok {
+			roles, err := rbacRepo.GetRolesByUserID(actorID)
+			if err != nil {
+				return access, err
+			}
+			for _, role := range roles {
+				if role == officerRole.String() {
+					access.BranchOfficer = true
+					break
+				}
+			}
+		}
+	}
+
+	return access, nil
+}
+
+// ForbiddenUserUpdateFields returns the JSON names of the fields in req that the caller
+// isn't allowed to change. An empty result means the whole update is allowed.
+func ForbiddenUserUpdateFields(req auth_models.UpdateUserRequest, access UserUpdateAccess) []string {
+	var forbidden []string
+	for _, field := range requestedUserUpdateFields(req) {
+		if !canUpdateUserField(field, req, access) {
+			forbidden = append(forbidden, field)
+		}
+	}
+	return forbidden
+}
+
+func canUpdateUserField(field string, req auth_models.UpdateUserRequest, access UserUpdateAccess) bool {
+	if profileFields[field] {
+		return access.Self || access.CanManageUsers
+	}
+
+	if field == "position" {
+		if access.CanManageUsers || access.BranchOfficer {
+			return true
+		}
+		return access.Self && selfAssignablePositions[*req.Position]
+	}
+
+	return false
+}
+
+// requestedUserUpdateFields lists the JSON names of the fields set in req
+func requestedUserUpdateFields(req auth_models.UpdateUserRequest) []string {
+	var fields []string
+	v := reflect.ValueOf(req)
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		value := v.Field(i)
+		if (value.Kind() == reflect.Ptr || value.Kind() == reflect.Slice) && value.IsNil() {
+			continue
+		}
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		fields = append(fields, name)
+	}
+	return fields
+}
+
+// CanModerateUser reports whether a user manager with the given primary role may update,
+// suspend, ban, sign out or delete the target. Only admins can act on other admins.
+func CanModerateUser(actorRole string, target *models.User) bool {
+	if target.Role != nil && *target.Role == models.AdminRole {
+		return actorRole == models.AdminRole.String()
+	}
+	return true
+}
+
+// CanAssignRole reports whether a user manager with the given primary role may give
+// someone the role. Only admins can make someone an admin.
+func CanAssignRole(actorRole string, role models.Role) bool {
+	if role == models.AdminRole {
+		return actorRole == models.AdminRole.String()
+	}
+	return true
+}
@@ -0,0 +1,64 @@
+package auth_utils_test
+
+import (
+	"testing"
+
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_models"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_utils"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestForbiddenUserUpdateFields(t *testing.T) {
+	bio := "Hello"
+	points := 1000
+	admin := models.AdminRole
+	verified := true
+	student := models.Student
+	leader := models.Leader
+
+	self := auth_utils.UserUpdateAccess{Self: true}
+	manager := auth_utils.UserUpdateAccess{CanManageUsers: true}
+	officer := auth_utils.UserUpdateAccess{BranchOfficer: true}
+
+	t.Run("Self can update profile fields", func(t *testing.T) {
+		req := auth_models.UpdateUserRequest{Bio: &bio, Tags: []string{"go"}}
+		assert.Empty(t, auth_utils.ForbiddenUserUpdateFields(req, self))
+	})
+
+	t.Run("Self can't change role, points or email verification", func(t *testing.T) {
+		req := auth_models.UpdateUserRequest{Bio: &bio, Role: &admin, TotalPoints: &points, EmailVerified: &verified}
+		assert.Equal(t, []string{"total_points", "role", "email_verified"}, auth_utils.ForbiddenUserUpdateFields(req, self))
+	})
+
+	t.Run("Self can only pick a self-assignable position", func(t *testing.T) {
+		assert.Empty(t, auth_utils.ForbiddenUserUpdateFields(auth_models.UpdateUserRequest{Position: &student}, self))
+		assert.Equal(t, []string{"position"}, auth_utils.ForbiddenUserUpdateFields(auth_models.UpdateUserRequest{Position: &leader}, self))
+	})
+
+	t.Run("Branch officer can only change position", func(t *testing.T) {
+		assert.Empty(t, auth_utils.ForbiddenUserUpdateFields(auth_models.UpdateUserRequest{Position: &leader}, officer))
+		assert.Equal(t, []string{"bio"}, auth_utils.ForbiddenUserUpdateFields(auth_models.UpdateUserRequest{Bio: &bio}, officer))
+	})
+
+	t.Run("User managers still can't change role or points here", func(t *testing.T) {
+		req := auth_models.UpdateUserRequest{Bio: &bio, Position: &leader, Role: &admin, TotalPoints: &points}
+		assert.Equal(t, []string{"total_points", "role"}, auth_utils.ForbiddenUserUpdateFields(req, manager))
+	})
+}
+
+func TestCanModerateUser(t *testing.T) {
+	admin := models.AdminRole
+	user := models.UserRole
+
+	assert.True(t, auth_utils.CanModerateUser(models.UserRole.String(), &models.User{Role: &user}))
+	assert.True(t, auth_utils.CanModerateUser(models.UserRole.String(), &models.User{}))
+	assert.False(t, auth_utils.CanModerateUser(models.UserRole.String(), &models.User{Role: &admin}))
+	assert.True(t, auth_utils.CanModerateUser(models.AdminRole.String(), &models.User{Role: &admin}))
+}
+
+func TestCanAssignRole(t *testing.T) {
+	assert.True(t, auth_utils.CanAssignRole(models.UserRole.String(), models.UserRole))
+	assert.False(t, auth_utils.CanAssignRole(models.UserRole.String(), models.AdminRole))
+	assert.True(t, auth_utils.CanAssignRole(models.AdminRole.String(), models.AdminRole))
+}
@@ -0,0 +1,125 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"golang.org/x/oauth2"
+)
+
+type githubProvider struct {
+	config *oauth2.Config
+}
+
+func (p *githubProvider) Name() string {
+	return "github"
+}
+
+// AuthCodeURL ignores the nonce, GitHub doesn't support OpenID Connect
+func (p *githubProvider) AuthCodeURL(state string, codeVerifier string, nonce string) (string, error) {
+	return p.config.AuthCodeURL(state, oauth2.S256ChallengeOption(codeVerifier)), nil
+}
+
+func (p *githubProvider) Exchange(ctx context.Context, code string, codeVerifier string, nonce string) (*OAuthUserData, error) {
+	token, err := p.config.Exchange(ctx, code, oauth2.VerifierOption(codeVerifier))
+	if err != nil {
+		return nil, fmt.Errorf("failed to exchange token: %v", err)
+	}
+	client := p.config.Client(ctx, token)
+
+	rawData, err := getJSON(client, "https://api.github.com/user")
+	if err != nil {
+		return nil, err
+	}
+
+	userData := &OAuthUserData{
+		Provider: p.Name(),
+	}
+
+	// Handle required ID field
+	if id, ok := rawData["id"].(float64); ok {
+		userData.ID = fmt.Sprintf("%.0f", id)
+	} else {
+		return nil, fmt.Errorf("invalid or missing github user ID")
+	}
+	if email, ok := rawData["email"].(string); ok && email != "" {
+		userData.Email = &email
+	}
+	if name, ok := rawData["name"].(string); ok && name != "" {
+		userData.Name = name
+	}
+	if avatar, ok := rawData["avatar_url"].(string); ok {
+		userData.AvatarURL = &avatar
+	}
+	// The profile email isn't guaranteed to be verified, so check it against the account's
+	// email list. Without one we just can't auto-link, so a failure here isn't fatal.
+	if email, verified, err := githubVerifiedEmail(client, userData.Email); err == nil && email != "" {
+		userData.Email = &email
+		userData.EmailVerified = verified
+	}
+
+	return userData, nil
+}
+
+// githubVerifiedEmail looks up the profile email in the user's GitHub email list, falling
+// back to their primary email if the profile has none. Returns the email and whether
+// GitHub has verified it.
+func githubVerifiedEmail(client *http.Client, profileEmail *string) (string, bool, error) {
+	resp, err := client.Get("https://api.github.com/user/emails")
+	if err != nil {
+		return "", false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", false, fmt.Errorf("unexpected status from github: %d", resp.StatusCode)
+	}
+
+	var emails []struct {
+		Email    string `json:"email"`
+		Primary  bool   `json:"primary"`
+		Verified bool   `json:"verified"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&emails); err != nil {
+		return "", false, err
+	}
+
+	for _, e := range emails {
+		if profileEmail != nil && strings.EqualFold(e.Email, *profileEmail) {
+			return e.Email, e.Verified, nil
+		}
+	}
+
+	if profileEmail == nil {
+		for _, e := range emails {
+			if e.Primary {
+				return e.Email, e.Verified, nil
+			}
+		}
+	}
+
+	return "", false, nil
+}
+
+// getJSON fetches a JSON object with an authenticated client
+func getJSON(client *http.Client, url string) (map[string]interface{}, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user info: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get user info: status %d", resp.StatusCode)
+	}
+
+	var rawData map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&rawData); err != nil {
+		return nil, fmt.Errorf("failed to decode user info: %v", err)
+	}
+
+	return rawData, nil
+}
@@ -0,0 +1,55 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+
+	"golang.org/x/oauth2"
+)
+
+type googleProvider struct {
+	config *oauth2.Config
+}
+
+func (p *googleProvider) Name() string {
+	return "google"
+}
+
+func (p *googleProvider) AuthCodeURL(state string, codeVerifier string, nonce string) (string, error) {
+	return p.config.AuthCodeURL(state, oauth2.S256ChallengeOption(codeVerifier)), nil
+}
+
+func (p *googleProvider) Exchange(ctx context.Context, code string, codeVerifier string, nonce string) (*OAuthUserData, error) {
+	token, err := p.config.Exchange(ctx, code, oauth2.VerifierOption(codeVerifier))
+	if err != nil {
+		return nil, fmt.Errorf("failed to exchange token: %v", err)
+	}
+	client := p.config.Client(ctx, token)
+
+	rawData, err := getJSON(client, "https://www.googleapis.com/oauth2/v2/userinfo")
+	if err != nil {
+		return nil, err
+	}
+
+	userData := &OAuthUserData{
+		Provider: p.Name(),
+	}
+
+	if id, ok := rawData["id"].(string); ok && id != "" {
+		userData.ID = id
+	}
+	if email, ok := rawData["email"].(string); ok && email != "" {
+		userData.Email = &email
+	}
+	if verified, ok := rawData["verified_email"].(bool); ok {
+		userData.EmailVerified = verified
+	}
+	if name, ok := rawData["name"].(string); ok && name != "" {
+		userData.Name = name
+	}
+	if picture, ok := rawData["picture"].(string); ok {
+		userData.AvatarURL = &picture
+	}
+
+	return userData, nil
+}
@@ -0,0 +1,311 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/csusmGDSC/csusmgdsc-api/config"
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/oauth2"
+)
+
+var ErrInvalidIDToken = errors.New("invalid ID token")
+
+// Signature algorithms accepted for ID tokens. Symmetric algorithms are deliberately
+// missing so the client secret can never be used to forge a token.
+var supportedIDTokenAlgs = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}
+
+const (
+	jwksRefreshInterval = time.Minute // Minimum time between refetching keys for an unknown key ID
+	idTokenLeeway       = time.Minute // Allowed clock skew with the issuer
+)
+
+// OIDCProvider signs users in with any OpenID Connect issuer. Endpoints and signing keys
+// come from the issuer's discovery document, fetched on first use and then cached.
+type OIDCProvider struct {
+	cfg         config.OIDCProviderConfig
+	redirectURL string
+	client      *http.Client
+
+	mu            sync.Mutex
+	discovery     *oidcDiscovery
+	keys          map[string]crypto.PublicKey
+	keysFetchedAt time.Time
+}
+
+type oidcDiscovery struct {
+	Issuer                string   `json:"issuer"`
+	AuthorizationEndpoint string   `json:"authorization_endpoint"`
+	TokenEndpoint         string   `json:"token_endpoint"`
+	JWKSURI               string   `json:"jwks_uri"`
+	SigningAlgs           []string `json:"id_token_signing_alg_values_supported"`
+}
+
+func NewOIDCProvider(cfg config.OIDCProviderConfig, redirectURL string) *OIDCProvider {
+	return &OIDCProvider{
+		cfg:         cfg,
+		redirectURL: redirectURL,
+		client:      &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (p *OIDCProvider) Name() string {
+	return p.cfg.Name
+}
+
+func (p *OIDCProvider) AuthCodeURL(state string, codeVerifier string, nonce string) (string, error) {
+	discovery, err := p.discover(context.Background())
+	if err != nil {
+		return "", err
+	}
+
+	return p.oauth2Config(discovery).AuthCodeURL(state,
+		oauth2.S256ChallengeOption(codeVerifier),
+		oauth2.SetAuthURLParam("nonce", nonce),
+	), nil
+}
+
+func (p *OIDCProvider) Exchange(ctx context.Context, code string, codeVerifier string, nonce string) (*OAuthUserData, error) {
+	discovery, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, p.client)
+	token, err := p.oauth2Config(discovery).Exchange(ctx, code, oauth2.VerifierOption(codeVerifier))
+	if err != nil {
+		return nil, fmt.Errorf("failed to exchange token: %v", err)
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok || rawIDToken == "" {
+		return nil, fmt.Errorf("%s didn't return an ID token", p.cfg.Name)
+	}
+
+	claims, err := p.VerifyIDToken(ctx, rawIDToken, nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.mapClaims(claims)
+}
+
+// VerifyIDToken checks the signature, issuer, audience, expiry and nonce of an ID token
+func (p *OIDCProvider) VerifyIDToken(ctx context.Context, rawIDToken string, nonce string) (jwt.MapClaims, error) {
+	discovery, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := jwt.Parse(rawIDToken, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return p.signingKey(ctx, discovery, kid)
+	},
+		jwt.WithValidMethods(p.signingAlgs(discovery)),
+		jwt.WithIssuer(discovery.Issuer),
+		jwt.WithAudience(p.cfg.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(idTokenLeeway),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return nil, ErrInvalidIDToken
+	}
+
+	// The nonce ties the token to the login attempt that requested it
+	tokenNonce, _ := claims["nonce"].(string)
+	if subtle.ConstantTimeCompare([]byte(tokenNonce), []byte(nonce)) != 1 {
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrInvalidIDToken)
+	}
+
+	// A token issued to several audiences must name us as the authorized party
+	audience, _ := claims.GetAudience()
+	if len(audience) > 1 {
+		if azp, _ := claims["azp"].(string); azp != p.cfg.ClientID {
+			return nil, fmt.Errorf("%w: unexpected authorized party", ErrInvalidIDToken)
+		}
+	}
+
+	return claims, nil
+}
+
+// mapClaims maps ID token claims into the user's profile using the configured claim names
+func (p *OIDCProvider) mapClaims(claims jwt.MapClaims) (*OAuthUserData, error) {
+	userData := &OAuthUserData{
+		Provider: p.cfg.Name,
+		ID:       claimString(claims, p.cfg.IDClaim),
+		Name:     claimString(claims, p.cfg.NameClaim),
+	}
+
+	if userData.ID == "" {
+		return nil, fmt.Errorf("%w: missing %s claim", ErrInvalidIDToken, p.cfg.IDClaim)
+	}
+
+	if email := claimString(claims, p.cfg.EmailClaim); email != "" {
+		userData.Email = &email
+
+		switch verified := claims[p.cfg.EmailVerifiedClaim].(type) {
+		case bool:
+			userData.EmailVerified = verified
+		case string:
+			// Some issuers send booleans as strings
+			userData.EmailVerified = verified == "true"
+		case nil:
+			userData.EmailVerified = p.cfg.TrustEmail
+		}
+	}
+
+	if picture := claimString(claims, p.cfg.PictureClaim); picture != "" {
+		userData.AvatarURL = &picture
+	}
+
+	return userData, nil
+}
+
+func claimString(claims jwt.MapClaims, name string) string {
+	switch value := claims[name].(type) {
+	case string:
+		return value
+	case float64:
+		// Numeric IDs like Discord's or GitLab's
+		return fmt.Sprintf("%.0f", value)
+	}
+	return ""
+}
+
+func (p *OIDCProvider) oauth2Config(discovery *oidcDiscovery) *oauth2.Config {
+	return &oauth2.Config{
+		ClientID:     p.cfg.ClientID,
+		ClientSecret: p.cfg.ClientSecret,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  discovery.AuthorizationEndpoint,
+			TokenURL: discovery.TokenEndpoint,
+		},
+		RedirectURL: p.redirectURL,
+		Scopes:      p.cfg.Scopes,
+	}
+}
+
+// signingAlgs returns the algorithms both we and the issuer support, RS256 if the issuer
+// doesn't say (the default required by the spec)
+func (p *OIDCProvider) signingAlgs(discovery *oidcDiscovery) []string {
+	if len(discovery.SigningAlgs) == 0 {
+		return []string{"RS256"}
+	}
+
+	var algs []string
+	for _, alg := range discovery.SigningAlgs {
+		for _, supported := range supportedIDTokenAlgs {
+			if alg == supported {
+				algs = append(algs, alg)
+			}
+		}
+	}
+	return algs
+}
+
+// discover fetches and caches the issuer's discovery document
+func (p *OIDCProvider) discover(ctx context.Context) (*oidcDiscovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+
+	body, err := p.get(ctx, strings.TrimSuffix(p.cfg.Issuer, "/")+"/.well-known/openid-configuration")
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s discovery document: %v", p.cfg.Name, err)
+	}
+
+	discovery := &oidcDiscovery{}
+	if err := json.Unmarshal(body, discovery); err != nil {
+		return nil, fmt.Errorf("failed to decode %s discovery document: %v", p.cfg.Name, err)
+	}
+
+	if strings.TrimSuffix(discovery.Issuer, "/") != strings.TrimSuffix(p.cfg.Issuer, "/") {
+		return nil, fmt.Errorf("%s discovery document is for issuer %q", p.cfg.Name, discovery.Issuer)
+	}
+	if discovery.AuthorizationEndpoint == "" || discovery.TokenEndpoint == "" || discovery.JWKSURI == "" {
+		return nil, fmt.Errorf("%s discovery document is missing endpoints", p.cfg.Name)
+	}
+
+	p.discovery = discovery
+	return discovery, nil
+}
+
+// signingKey returns the issuer's key with the given ID, refetching the key set when the
+// ID is unknown since the issuer may have rotated its keys
+func (p *OIDCProvider) signingKey(ctx context.Context, discovery *oidcDiscovery, kid string) (crypto.PublicKey, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key := lookupKey(p.keys, kid); key != nil {
+		return key, nil
+	}
+
+	if time.Since(p.keysFetchedAt) < jwksRefreshInterval {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+
+	body, err := p.get(ctx, discovery.JWKSURI)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch signing keys: %v", err)
+	}
+
+	keys, err := parseJWKS(body)
+	if err != nil {
+		return nil, err
+	}
+	p.keys = keys
+	p.keysFetchedAt = time.Now()
+
+	if key := lookupKey(p.keys, kid); key != nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+// lookupKey finds a key by ID. Tokens without a key ID are accepted only when the
+// issuer has a single key.
+func lookupKey(keys map[string]crypto.PublicKey, kid string) crypto.PublicKey {
+	if kid == "" && len(keys) == 1 {
+		for _, key := range keys {
+			return key
+		}
+	}
+	return keys[kid]
+}
+
+func (p *OIDCProvider) get(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+}
@@ -0,0 +1,212 @@
+package auth_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/csusmGDSC/csusmgdsc-api/config"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubIssuer is a minimal OpenID Connect provider. The token endpoint returns
+// whatever ID token the test sets.
+type stubIssuer struct {
+	server  *httptest.Server
+	key     *rsa.PrivateKey
+	idToken string
+}
+
+func newStubIssuer(t *testing.T) *stubIssuer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	issuer := &stubIssuer{key: key}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"issuer":                                issuer.server.URL,
+			"authorization_endpoint":                issuer.server.URL + "/authorize",
+			"token_endpoint":                        issuer.server.URL + "/token",
+			"jwks_uri":                              issuer.server.URL + "/jwks",
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "test-key",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": "access",
+			"token_type":   "Bearer",
+			"id_token":     issuer.idToken,
+		})
+	})
+
+	issuer.server = httptest.NewServer(mux)
+	t.Cleanup(issuer.server.Close)
+	return issuer
+}
+
+func (s *stubIssuer) sign(t *testing.T, key *rsa.PrivateKey, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = "test-key"
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+	return signed
+}
+
+func (s *stubIssuer) claims(nonce string) jwt.MapClaims {
+	return jwt.MapClaims{
+		"iss":            s.server.URL,
+		"aud":            "client-id",
+		"sub":            "user-123",
+		"email":          "student@csusm.edu",
+		"email_verified": true,
+		"name":           "Test Student",
+		"nonce":          nonce,
+		"iat":            time.Now().Unix(),
+		"exp":            time.Now().Add(time.Hour).Unix(),
+	}
+}
+
+func newProvider(issuer *stubIssuer) *auth.OIDCProvider {
+	return auth.NewOIDCProvider(config.OIDCProviderConfig{
+		Name:               "stub",
+		Issuer:             issuer.server.URL,
+		ClientID:           "client-id",
+		ClientSecret:       "client-secret",
+		Scopes:             []string{"openid", "email", "profile"},
+		IDClaim:            "sub",
+		EmailClaim:         "email",
+		EmailVerifiedClaim: "email_verified",
+		NameClaim:          "name",
+		PictureClaim:       "picture",
+	}, "http://localhost:8080/auth/stub/callback")
+}
+
+func TestOIDCProviderAuthCodeURL(t *testing.T) {
+	issuer := newStubIssuer(t)
+	provider := newProvider(issuer)
+
+	authURL, err := provider.AuthCodeURL("state", "verifier-verifier-verifier-verifier-verifier", "nonce")
+	require.NoError(t, err)
+
+	parsed, err := url.Parse(authURL)
+	require.NoError(t, err)
+	assert.Equal(t, issuer.server.URL+"/authorize", parsed.Scheme+"://"+parsed.Host+parsed.Path)
+
+	query := parsed.Query()
+	assert.Equal(t, "client-id", query.Get("client_id"))
+	assert.Equal(t, "state", query.Get("state"))
+	assert.Equal(t, "nonce", query.Get("nonce"))
+	assert.Equal(t, "S256", query.Get("code_challenge_method"))
+	assert.Equal(t, "openid email profile", query.Get("scope"))
+}
+
+func TestOIDCProviderExchange(t *testing.T) {
+	issuer := newStubIssuer(t)
+
+	t.Run("Maps ID token claims", func(t *testing.T) {
+		provider := newProvider(issuer)
+		issuer.idToken = issuer.sign(t, issuer.key, issuer.claims("nonce"))
+
+		userData, err := provider.Exchange(context.Background(), "code", "verifier", "nonce")
+		require.NoError(t, err)
+		assert.Equal(t, "stub", userData.Provider)
+		assert.Equal(t, "user-123", userData.ID)
+		assert.Equal(t, "Test Student", userData.Name)
+		require.NotNil(t, userData.Email)
+		assert.Equal(t, "student@csusm.edu", *userData.Email)
+		assert.True(t, userData.EmailVerified)
+	})
+
+	t.Run("Uses the configured claim names", func(t *testing.T) {
+		provider := auth.NewOIDCProvider(config.OIDCProviderConfig{
+			Name:               "entra",
+			Issuer:             issuer.server.URL,
+			ClientID:           "client-id",
+			IDClaim:            "oid",
+			EmailClaim:         "preferred_username",
+			EmailVerifiedClaim: "email_verified",
+			NameClaim:          "name",
+			TrustEmail:         true,
+		}, "")
+
+		claims := issuer.claims("nonce")
+		delete(claims, "email_verified")
+		claims["oid"] = "object-id"
+		claims["preferred_username"] = "student@csusm.edu"
+		issuer.idToken = issuer.sign(t, issuer.key, claims)
+
+		userData, err := provider.Exchange(context.Background(), "code", "verifier", "nonce")
+		require.NoError(t, err)
+		assert.Equal(t, "object-id", userData.ID)
+		require.NotNil(t, userData.Email)
+		assert.Equal(t, "student@csusm.edu", *userData.Email)
+		assert.True(t, userData.EmailVerified)
+	})
+
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	invalid := map[string]func() string{
+		"Wrong nonce": func() string {
+			return issuer.sign(t, issuer.key, issuer.claims("other-nonce"))
+		},
+		"Wrong audience": func() string {
+			claims := issuer.claims("nonce")
+			claims["aud"] = "other-client"
+			return issuer.sign(t, issuer.key, claims)
+		},
+		"Wrong issuer": func() string {
+			claims := issuer.claims("nonce")
+			claims["iss"] = "https://evil.example.com"
+			return issuer.sign(t, issuer.key, claims)
+		},
+		"Expired": func() string {
+			claims := issuer.claims("nonce")
+			claims["exp"] = time.Now().Add(-time.Hour).Unix()
+			return issuer.sign(t, issuer.key, claims)
+		},
+		"Signed with an unknown key": func() string {
+			return issuer.sign(t, otherKey, issuer.claims("nonce"))
+		},
+		"Signed with the client secret": func() string {
+			token := jwt.NewWithClaims(jwt.SigningMethodHS256, issuer.claims("nonce"))
+			signed, err := token.SignedString([]byte("client-secret"))
+			require.NoError(t, err)
+			return signed
+		},
+	}
+
+	for name, idToken := range invalid {
+		t.Run(name, func(t *testing.T) {
+			provider := newProvider(issuer)
+			issuer.idToken = idToken()
+
+			_, err := provider.Exchange(context.Background(), "code", "verifier", "nonce")
+			assert.ErrorIs(t, err, auth.ErrInvalidIDToken)
+		})
+	}
+}
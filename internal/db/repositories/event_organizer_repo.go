@@ -37,7 +37,8 @@ func (r *EventOrganizerRepository) InsertEventOrganizer(eventOrganizer models.Ev
 // GetEventOrganizers retrieves a list of users who are organizers of a given event ID.
 //
 // It queries the event_organizers table, joins it with the users table, and returns a list
-// of User objects that correspond to the organizers of the event.
+// of User objects that correspond to the organizers of the event. Organizers whose accounts
+// are scheduled for deletion are left out.
 //
 // The function returns an error if the query fails.
 func (r *EventOrganizerRepository) GetEventOrganizers(eventID uuid.UUID) ([]models.User, error) {
@@ -52,7 +53,7 @@ func (r *EventOrganizerRepository) GetEventOrganizers(eventID uuid.UUID) ([]mode
 			u.branch
         FROM event_organizers eo
         JOIN users u ON eo.user_id = u.id
-        WHERE eo.event_id = $1
+        WHERE eo.event_id = $1 AND u.deletion_scheduled_at IS NULL
     `
 
 	rows, err := r.db.Query(query, eventID)
@@ -2,8 +2,11 @@ package handlers
 
 import (
 	"net/http"
+	"slices"
 	"time"
 
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_repositories"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_utils"
 	"github.com/csusmGDSC/csusmgdsc-api/internal/db/repositories"
 	"github.com/csusmGDSC/csusmgdsc-api/internal/models"
 	"github.com/go-playground/validator"
@@ -82,6 +85,7 @@ func (h *Handler) InsertCommentHandler(c echo.Context) error {
 //
 // This handler is used to delete a comment from the database.
 // The comment ID is passed as a parameter in the URL.
+// Only the comment's author or a user with the comments:moderate permission can delete it.
 // The handler returns a JSON object with a single field, "message",
 // which contains a success message if the comment is deleted
 // successfully, or an error message if there is a failure.
@@ -96,13 +100,17 @@ func (h *Handler) DeleteCommentHandler(c echo.Context) error {
 
 	dbConn := h.DB.GetDB()
 	commentRepo := repositories.NewCommentRepository(dbConn)
-	utilsRepo := repositories.NewUtilsRepository(dbConn)
 
 	// Check if comment exists
-	if exists, err := utilsRepo.CheckIfUUIDExists("comments", "id", commentUUID); !exists || err != nil {
+	existing, err := commentRepo.GetCommentByCommentId(commentUUID)
+	if err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Comment not found"})
 	}
 
+	if allowed, err := h.canModifyComment(c, existing); err != nil || !allowed {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "Insufficient permissions"})
+	}
+
 	err = commentRepo.DeleteCommentById(commentUUID)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to delete comment"})
@@ -111,6 +119,27 @@ func (h *Handler) DeleteCommentHandler(c echo.Context) error {
 	return c.JSON(http.StatusOK, map[string]string{"message": "Comment deleted successfully"})
 }
 
+// canModifyComment reports whether the authenticated user wrote the comment or holds the
+// comments:moderate permission
+func (h *Handler) canModifyComment(c echo.Context, comment *models.Comment) (bool, error) {
+	userIDStr, _ := c.Get("user_id").(string)
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		return false, err
+	}
+
+	if comment.UserId == userID {
+		return true, nil
+	}
+
+	// Personal access tokens can only moderate if they were given the scope
+	if scopes, ok := c.Get("token_scopes").([]string); ok && !slices.Contains(scopes, models.CommentsModerate.String()) {
+		return false, nil
+	}
+
+	return auth_utils.HasPermissions(auth_repositories.NewRBACRepository(h.DB.GetDB()), userID, models.CommentsModerate)
+}
+
 // GetCommentsHandler returns a list of comments that match the query parameters.
 //
 // The handler takes two query parameters, user_id and event_id, which are used to filter the results.
@@ -231,6 +260,7 @@ func (h *Handler) GetCommentByIdHandler(c echo.Context) error {
 // This handler is used to update an existing comment in the database.
 // The comment ID is passed as a parameter in the URL, and the updated
 // fields are provided in the request body as a JSON object.
+// Only the comment's author or a user with the comments:moderate permission can update it.
 //
 // The JSON object in the request body should contain one or more of the
 // following fields:
@@ -277,13 +307,17 @@ func (h *Handler) UpdateCommentHandler(c echo.Context) error {
 
 	dbConn := h.DB.GetDB()
 	commentRepo := repositories.NewCommentRepository(dbConn)
-	utilsRepo := repositories.NewUtilsRepository(dbConn)
 
 	// Check if comment exists
-	if exists, err := utilsRepo.CheckIfUUIDExists("comments", "id", commentUUID); !exists || err != nil {
+	existing, err := commentRepo.GetCommentByCommentId(commentUUID)
+	if err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Comment not found"})
 	}
 
+	if allowed, err := h.canModifyComment(c, existing); err != nil || !allowed {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "Insufficient permissions"})
+	}
+
 	err = commentRepo.UpdateCommentByCommentId(commentUUID, comment)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to update comment"})
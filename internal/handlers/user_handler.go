@@ -63,6 +63,15 @@ func (h *Handler) GetUserByIDHandler(c echo.Context) error {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get user"})
 	}
 
+	// Accounts scheduled for deletion are hidden unless the owner signs back in
+	scheduledAt, err := userRepo.GetDeletionScheduledAt(userID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get user"})
+	}
+	if scheduledAt != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "User not found"})
+	}
+
 	user.Password = nil
 	return c.JSON(http.StatusOK, user)
 }
@@ -22,11 +22,6 @@ import (
 // The response is a JSON object with a single key "url" which contains the URL
 // of the uploaded image.
 func (h *Handler) UploadImage(c echo.Context) error {
-	userRole, ok := c.Get("user_role").(string)
-	if !ok || userRole != "ADMIN" {
-		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Insufficient permissions"})
-	}
-
 	file, err := c.FormFile("image")
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid file request. Send file as FormFile."})
@@ -75,11 +70,6 @@ func (h *Handler) UploadImage(c echo.Context) error {
 // The response is a JSON object with a single key "message" which contains the
 // message of the deletion result.
 func (h *Handler) RemoveImage(c echo.Context) error {
-	userRole, ok := c.Get("user_role").(string)
-	if !ok || userRole != "ADMIN" {
-		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Insufficient permissions"})
-	}
-
 	imageURL := c.QueryParam("url")
 	if imageURL == "" {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Image URL is required"})
@@ -0,0 +1,61 @@
+package mailer
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type FileMailer struct {
+	dir  string
+	from string
+}
+
+// NewFileMailer writes every message to dir as an .eml file instead of sending it.
+// Useful for local development and for tests that need to inspect outgoing mail.
+func NewFileMailer(dir string, from string) *FileMailer {
+	return &FileMailer{dir: dir, from: from}
+}
+
+func (m *FileMailer) Send(msg *Message) error {
+	raw, err := buildMIME(m.from, msg)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(m.dir, 0o755); err != nil {
+		return err
+	}
+
+	// Timestamp prefix keeps files sorted in the order they were sent
+	name := fmt.Sprintf("%s-%s.eml", time.Now().UTC().Format("20060102T150405.000000000"), uuid.NewString())
+	return os.WriteFile(filepath.Join(m.dir, name), raw, 0o644)
+}
+
+type ConsoleMailer struct {
+	mu   sync.Mutex
+	out  io.Writer
+	from string
+}
+
+// NewConsoleMailer prints every message to stdout instead of sending it
+func NewConsoleMailer(from string) *ConsoleMailer {
+	return &ConsoleMailer{out: os.Stdout, from: from}
+}
+
+func (m *ConsoleMailer) Send(msg *Message) error {
+	raw, err := buildMIME(m.from, msg)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, err = fmt.Fprintf(m.out, "----- outgoing email -----\n%s\n----- end of email -----\n", raw)
+	return err
+}
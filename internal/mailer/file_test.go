@@ -0,0 +1,68 @@
+package mailer_test
+
+import (
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/csusmGDSC/csusmgdsc-api/internal/mailer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileMailer(t *testing.T) {
+	dir := t.TempDir()
+	m := mailer.NewFileMailer(dir, "CSUSM_GDSC <CSUSM_GDSC@gdsc-csusm.com>")
+
+	err := m.Send(&mailer.Message{
+		To:      []string{"student@csusm.edu"},
+		Subject: "Verify Your Email for GDSC-CSUSM",
+		HTML:    `<a href="https://gdsc-csusm.com/verify?token=abc">Verify Email</a>`,
+		Text:    "Verify your email: https://gdsc-csusm.com/verify?token=abc",
+	})
+	require.NoError(t, err)
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+
+	f, err := os.Open(files[0])
+	require.NoError(t, err)
+	defer f.Close()
+
+	msg, err := mail.ReadMessage(f)
+	require.NoError(t, err)
+	assert.Equal(t, "student@csusm.edu", msg.Header.Get("To"))
+	assert.Equal(t, "Verify Your Email for GDSC-CSUSM", decodeHeader(t, msg.Header.Get("Subject")))
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	assert.Equal(t, "multipart/alternative", mediaType)
+
+	bodies := map[string]string{}
+	reader := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+
+		content, err := io.ReadAll(part)
+		require.NoError(t, err)
+		bodies[part.Header.Get("Content-Type")] = string(content)
+	}
+
+	assert.Contains(t, bodies["text/plain; charset=utf-8"], "https://gdsc-csusm.com/verify?token=abc")
+	assert.Contains(t, bodies["text/html; charset=utf-8"], `href="https://gdsc-csusm.com/verify?token=abc"`)
+}
+
+func decodeHeader(t *testing.T, header string) string {
+	decoded, err := new(mime.WordDecoder).DecodeHeader(header)
+	require.NoError(t, err)
+	return decoded
+}
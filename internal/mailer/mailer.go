@@ -0,0 +1,44 @@
+package mailer
+
+import (
+	"fmt"
+
+	"github.com/csusmGDSC/csusmgdsc-api/config"
+)
+
+type Message struct {
+	To      []string
+	Subject string
+	HTML    string
+	Text    string
+}
+
+// Mailer sends transactional email. The implementation is picked by MAIL_BACKEND
+// so the API can run locally and in tests without a Resend API key.
+type Mailer interface {
+	Send(msg *Message) error
+}
+
+// New returns the Mailer configured by cfg.MailBackend:
+//
+//   - "resend": sends through the Resend API (default)
+//   - "smtp": sends through the SMTP server at SMTP_HOST:SMTP_PORT
+//   - "file": writes each message as an .eml file into MAIL_OUTPUT_DIR
+//   - "console": prints each message to stdout
+func New(cfg *config.Config) (Mailer, error) {
+	switch cfg.MailBackend {
+	case "resend":
+		return NewResendMailer(cfg.ResendAPIKey, cfg.MailFrom), nil
+	case "smtp":
+		return NewSMTPMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.MailFrom), nil
+	case "file":
+		if cfg.MailOutputDir == "" {
+			return nil, fmt.Errorf("MAIL_OUTPUT_DIR is required for the file mail backend")
+		}
+		return NewFileMailer(cfg.MailOutputDir, cfg.MailFrom), nil
+	case "console":
+		return NewConsoleMailer(cfg.MailFrom), nil
+	default:
+		return nil, fmt.Errorf("unsupported mail backend: %s", cfg.MailBackend)
+	}
+}
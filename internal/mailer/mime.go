@@ -0,0 +1,76 @@
+package mailer
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// buildMIME renders a message as an RFC 5322 email with text and HTML alternatives
+func buildMIME(from string, msg *Message) ([]byte, error) {
+	var buf bytes.Buffer
+
+	headers := []string{
+		"From: " + from,
+		"To: " + strings.Join(msg.To, ", "),
+		"Subject: " + mime.QEncoding.Encode("utf-8", msg.Subject),
+		"Date: " + time.Now().Format(time.RFC1123Z),
+		"MIME-Version: 1.0",
+	}
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	headers = append(headers, fmt.Sprintf("Content-Type: multipart/alternative; boundary=%q", writer.Boundary()))
+
+	parts := []struct {
+		contentType string
+		content     string
+	}{
+		{"text/plain; charset=utf-8", msg.Text},
+		{"text/html; charset=utf-8", msg.HTML},
+	}
+	for _, part := range parts {
+		if part.content == "" {
+			continue
+		}
+
+		partWriter, err := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		qp := quotedprintable.NewWriter(partWriter)
+		if _, err := qp.Write([]byte(part.content)); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	buf.WriteString(strings.Join(headers, "\r\n"))
+	buf.WriteString("\r\n\r\n")
+	buf.Write(body.Bytes())
+	return buf.Bytes(), nil
+}
+
+// addressOnly strips the display name from an address like "Name <user@example.com>"
+func addressOnly(address string) (string, error) {
+	parsed, err := mail.ParseAddress(address)
+	if err != nil {
+		return "", err
+	}
+	return parsed.Address, nil
+}
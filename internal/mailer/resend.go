@@ -0,0 +1,29 @@
+package mailer
+
+import "github.com/resend/resend-go/v2"
+
+type ResendMailer struct {
+	client *resend.Client
+	from   string
+}
+
+// NewResendMailer sends mail through Resend. The domain of from must be verified in Resend.
+func NewResendMailer(apiKey string, from string) *ResendMailer {
+	return &ResendMailer{
+		client: resend.NewClient(apiKey),
+		from:   from,
+	}
+}
+
+func (m *ResendMailer) Send(msg *Message) error {
+	params := &resend.SendEmailRequest{
+		From:    m.from,
+		To:      msg.To,
+		Subject: msg.Subject,
+		Html:    msg.HTML,
+		Text:    msg.Text,
+	}
+
+	_, err := m.client.Emails.Send(params)
+	return err
+}
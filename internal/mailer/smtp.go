@@ -0,0 +1,41 @@
+package mailer
+
+import (
+	"net"
+	"net/smtp"
+)
+
+type SMTPMailer struct {
+	addr string
+	auth smtp.Auth
+	from string
+}
+
+// NewSMTPMailer sends mail through an SMTP server, authenticating with PLAIN auth
+// when a username is set. net/smtp upgrades to TLS with STARTTLS when the server supports it.
+func NewSMTPMailer(host string, port string, username string, password string, from string) *SMTPMailer {
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return &SMTPMailer{
+		addr: net.JoinHostPort(host, port),
+		auth: auth,
+		from: from,
+	}
+}
+
+func (m *SMTPMailer) Send(msg *Message) error {
+	raw, err := buildMIME(m.from, msg)
+	if err != nil {
+		return err
+	}
+
+	envelopeFrom, err := addressOnly(m.from)
+	if err != nil {
+		return err
+	}
+
+	return smtp.SendMail(m.addr, m.auth, envelopeFrom, msg.To, raw)
+}
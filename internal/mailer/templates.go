@@ -0,0 +1,127 @@
+package mailer
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+
+	"github.com/csusmGDSC/csusmgdsc-api/config"
+)
+
+//go:embed templates
+var templateFS embed.FS
+
+// Template names a transactional email. Every template has an HTML and a plaintext
+// variant for each supported locale under templates/<locale>/, rendered inside the
+// shared layout. The plaintext variant also defines the subject.
+type Template string
+
+const (
+	VerificationTemplate      Template = "verification"
+	PasswordResetTemplate     Template = "password_reset"
+	WelcomeTemplate           Template = "welcome"
+	EventReminderTemplate     Template = "event_reminder"
+	MagicLinkTemplate         Template = "magic_link"
+	EmailChangeTemplate       Template = "email_change"
+	EmailChangeNoticeTemplate Template = "email_change_notice"
+	PasswordChangedTemplate   Template = "password_changed"
+)
+
+const DefaultLocale = "en"
+
+var SupportedLocales = []string{"en", "es"}
+
+var templateFuncs = map[string]interface{}{
+	// dict builds a map from key/value pairs so templates can pass several values to a sub-template
+	"dict": func(pairs ...interface{}) (map[string]interface{}, error) {
+		if len(pairs)%2 != 0 {
+			return nil, fmt.Errorf("dict requires key/value pairs")
+		}
+		m := make(map[string]interface{}, len(pairs)/2)
+		for i := 0; i < len(pairs); i += 2 {
+			key, ok := pairs[i].(string)
+			if !ok {
+				return nil, fmt.Errorf("dict keys must be strings")
+			}
+			m[key] = pairs[i+1]
+		}
+		return m, nil
+	},
+}
+
+// Render builds a message from a template in the given locale, falling back to
+// DefaultLocale for unsupported locales. SiteURL and Locale are added to data.
+func Render(name Template, locale string, to []string, data map[string]interface{}) (*Message, error) {
+	if !isSupportedLocale(locale) {
+		locale = DefaultLocale
+	}
+
+	templateData := map[string]interface{}{}
+	for key, value := range data {
+		templateData[key] = value
+	}
+	templateData["SiteURL"] = config.LoadConfig().FrontendURL
+	templateData["Locale"] = locale
+
+	textTmpl, err := texttemplate.New("").Funcs(templateFuncs).ParseFS(templateFS,
+		"templates/layout.txt",
+		"templates/"+locale+"/common.txt",
+		"templates/"+locale+"/"+string(name)+".txt",
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s text template: %w", name, err)
+	}
+
+	htmlTmpl, err := htmltemplate.New("").Funcs(templateFuncs).ParseFS(templateFS,
+		"templates/layout.html",
+		"templates/"+locale+"/common.html",
+		"templates/"+locale+"/"+string(name)+".html",
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s html template: %w", name, err)
+	}
+
+	var subject, text, html bytes.Buffer
+	if err := textTmpl.ExecuteTemplate(&subject, "subject", templateData); err != nil {
+		return nil, fmt.Errorf("failed to render %s subject: %w", name, err)
+	}
+	if err := textTmpl.ExecuteTemplate(&text, "layout", templateData); err != nil {
+		return nil, fmt.Errorf("failed to render %s text body: %w", name, err)
+	}
+	if err := htmlTmpl.ExecuteTemplate(&html, "layout", templateData); err != nil {
+		return nil, fmt.Errorf("failed to render %s html body: %w", name, err)
+	}
+
+	return &Message{
+		To:      to,
+		Subject: strings.TrimSpace(subject.String()),
+		HTML:    html.String(),
+		Text:    strings.TrimSpace(text.String()) + "\n",
+	}, nil
+}
+
+// LocaleFromAcceptLanguage picks the first supported locale from an Accept-Language
+// header such as "es-MX,es;q=0.9,en;q=0.8", ignoring quality values since browsers
+// already list languages in order of preference.
+func LocaleFromAcceptLanguage(acceptLanguage string) string {
+	for _, entry := range strings.Split(acceptLanguage, ",") {
+		tag := strings.TrimSpace(strings.SplitN(entry, ";", 2)[0])
+		language := strings.ToLower(strings.SplitN(tag, "-", 2)[0])
+		if isSupportedLocale(language) {
+			return language
+		}
+	}
+	return DefaultLocale
+}
+
+func isSupportedLocale(locale string) bool {
+	for _, supported := range SupportedLocales {
+		if locale == supported {
+			return true
+		}
+	}
+	return false
+}
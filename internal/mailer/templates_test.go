@@ -0,0 +1,72 @@
+package mailer_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/csusmGDSC/csusmgdsc-api/internal/mailer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	os.Setenv("GO_ENV", "test")
+	os.Exit(m.Run())
+}
+
+func TestRenderAllTemplates(t *testing.T) {
+	templates := []mailer.Template{
+		mailer.VerificationTemplate,
+		mailer.PasswordResetTemplate,
+		mailer.WelcomeTemplate,
+		mailer.EventReminderTemplate,
+		mailer.MagicLinkTemplate,
+		mailer.EmailChangeTemplate,
+		mailer.EmailChangeNoticeTemplate,
+		mailer.PasswordChangedTemplate,
+	}
+	data := map[string]interface{}{
+		"Email":            "student@csusm.edu",
+		"Name":             "Alex",
+		"ActionURL":        "https://gdsc-csusm.com/action?token=abc",
+		"ExpiresInHours":   2,
+		"ExpiresInMinutes": 30,
+		"EventTitle":       "Intro to Go",
+		"StartTime":        "tomorrow at 5:00 PM",
+		"Location":         "Markstein Hall 125",
+		"OldEmail":         "student@csusm.edu",
+		"NewEmail":         "alex@gmail.com",
+	}
+
+	for _, locale := range mailer.SupportedLocales {
+		for _, tmpl := range templates {
+			t.Run(locale+"/"+string(tmpl), func(t *testing.T) {
+				msg, err := mailer.Render(tmpl, locale, []string{"student@csusm.edu"}, data)
+				require.NoError(t, err)
+				assert.NotEmpty(t, msg.Subject)
+				assert.NotContains(t, msg.Subject, "\n")
+				assert.Contains(t, msg.HTML, `href="https://gdsc-csusm.com/action?token=abc"`)
+				assert.Contains(t, msg.HTML, `lang="`+locale+`"`)
+				assert.Contains(t, msg.Text, "https://gdsc-csusm.com/action?token=abc")
+				assert.NotContains(t, msg.Text, "<no value>")
+			})
+		}
+	}
+}
+
+func TestRenderEscapesHTML(t *testing.T) {
+	msg, err := mailer.Render(mailer.WelcomeTemplate, "en", []string{"student@csusm.edu"}, map[string]interface{}{
+		"Name":      "<script>alert(1)</script>",
+		"ActionURL": "https://gdsc-csusm.com/onboarding",
+	})
+	require.NoError(t, err)
+	assert.NotContains(t, msg.HTML, "<script>")
+}
+
+func TestLocaleFromAcceptLanguage(t *testing.T) {
+	assert.Equal(t, "es", mailer.LocaleFromAcceptLanguage("es-MX,es;q=0.9,en;q=0.8"))
+	assert.Equal(t, "en", mailer.LocaleFromAcceptLanguage("en-US,en;q=0.9"))
+	assert.Equal(t, "es", mailer.LocaleFromAcceptLanguage("fr-FR, es;q=0.5"))
+	assert.Equal(t, "en", mailer.LocaleFromAcceptLanguage("fr-FR"))
+	assert.Equal(t, "en", mailer.LocaleFromAcceptLanguage(""))
+}
@@ -0,0 +1,16 @@
+package models
+
+// Permission allows a user to perform a group of actions.
+// Roles are granted permissions in the role_permissions table.
+type Permission string
+
+const (
+	EventsWrite      Permission = "events:write"
+	CommentsModerate Permission = "comments:moderate"
+	UsersManage      Permission = "users:manage"
+	MediaUpload      Permission = "media:upload"
+)
+
+func (p Permission) String() string {
+	return string(p)
+}
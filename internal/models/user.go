@@ -12,8 +12,20 @@ type Role string
 const (
 	UserRole  Role = "USER"
 	AdminRole Role = "ADMIN"
+
+	// Built-in officer roles, granted in addition to a user's primary role
+	ProjectsOfficerRole  Role = "PROJECTS_OFFICER"
+	InterviewOfficerRole Role = "INTERVIEW_OFFICER"
+	MarketingOfficerRole Role = "MARKETING_OFFICER"
 )
 
+// BranchOfficerRoles maps each branch to the role of its officers
+var BranchOfficerRoles = map[GDSCBranch]Role{
+	Projects:  ProjectsOfficerRole,
+	Interview: InterviewOfficerRole,
+	Marketing: MarketingOfficerRole,
+}
+
 func (r Role) String() string {
 	return string(r)
 }
@@ -44,4 +56,5 @@ type User struct {
 	AuthID         *string        `json:"auth_id" db:"auth_id"`
 	IsOnboarded    bool           `json:"is_onboarded" db:"is_onboarded"`
 	EmailVerified  bool           `json:"email_verified" db:"email_verified"`
+	TOTPEnabled    bool           `json:"totp_enabled" db:"totp_enabled"`
 }
@@ -0,0 +1,11 @@
+-- Per-login OAuth state and PKCE verifier. Rows are deleted when the callback
+-- consumes them, so a state value can only ever be used once.
+CREATE TABLE IF NOT EXISTS oauth_states (
+    state         TEXT PRIMARY KEY,
+    provider      TEXT NOT NULL,
+    code_verifier TEXT NOT NULL,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at    TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_oauth_states_expires_at ON oauth_states (expires_at);
@@ -0,0 +1,13 @@
+-- Refresh token rotation: every refresh retires the presented token and issues a
+-- child token in the same family. Presenting a retired token revokes the family.
+ALTER TABLE refresh_tokens
+    ADD COLUMN IF NOT EXISTS family_id UUID,
+    ADD COLUMN IF NOT EXISTS parent_id INTEGER REFERENCES refresh_tokens (id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS revoked_at TIMESTAMPTZ;
+
+-- Existing sessions each become the root of their own family
+UPDATE refresh_tokens SET family_id = gen_random_uuid() WHERE family_id IS NULL;
+
+ALTER TABLE refresh_tokens ALTER COLUMN family_id SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_family_id ON refresh_tokens (family_id);
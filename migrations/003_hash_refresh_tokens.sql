@@ -0,0 +1,13 @@
+-- Refresh tokens are stored as the hex SHA-256 of the signed token instead of the
+-- token itself. Existing rows are hashed in place so current sessions keep working.
+ALTER TABLE refresh_tokens ADD COLUMN IF NOT EXISTS token_hash TEXT;
+
+UPDATE refresh_tokens
+SET token_hash = encode(sha256(convert_to(token, 'UTF8')), 'hex')
+WHERE token_hash IS NULL;
+
+ALTER TABLE refresh_tokens ALTER COLUMN token_hash SET NOT NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_refresh_tokens_token_hash ON refresh_tokens (token_hash);
+
+ALTER TABLE refresh_tokens DROP COLUMN IF EXISTS token;
@@ -0,0 +1,11 @@
+-- Purpose-scoped tokens (email verification, password reset, ...) are signed JWTs.
+-- Their IDs are recorded here when consumed so each token only works once.
+CREATE TABLE IF NOT EXISTS used_tokens (
+    jti        UUID PRIMARY KEY,
+    purpose    TEXT NOT NULL,
+    user_id    UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at    TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_used_tokens_expires_at ON used_tokens (expires_at);
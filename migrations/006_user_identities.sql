@@ -0,0 +1,27 @@
+-- Login identities from OAuth providers. A user can have several, e.g. both GitHub
+-- and Google, in addition to a password. users.provider/auth_id are kept for
+-- compatibility but no longer used to look users up.
+CREATE TABLE IF NOT EXISTS user_identities (
+    id               UUID PRIMARY KEY,
+    user_id          UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    provider         TEXT NOT NULL,
+    provider_user_id TEXT NOT NULL,
+    email            TEXT,
+    email_verified   BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_used_at     TIMESTAMPTZ,
+    UNIQUE (provider, provider_user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_identities_user_id ON user_identities (user_id);
+
+-- Backfill the identity every existing OAuth user signed up with
+INSERT INTO user_identities (id, user_id, provider, provider_user_id, email, created_at)
+SELECT gen_random_uuid(), id, provider, auth_id, email, created_at
+FROM users
+WHERE provider IS NOT NULL AND auth_id IS NOT NULL
+ON CONFLICT (provider, provider_user_id) DO NOTHING;
+
+-- Set when the OAuth flow was started by a signed in user linking another provider
+ALTER TABLE oauth_states
+    ADD COLUMN IF NOT EXISTS link_user_id UUID REFERENCES users (id) ON DELETE CASCADE;
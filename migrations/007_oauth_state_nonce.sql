@@ -0,0 +1,4 @@
+-- OpenID Connect nonce sent with the authorization request and checked against the
+-- ID token in the callback
+ALTER TABLE oauth_states
+    ADD COLUMN IF NOT EXISTS nonce TEXT NOT NULL DEFAULT '';
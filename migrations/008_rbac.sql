@@ -0,0 +1,52 @@
+-- Role based access control. users.role remains the user's primary role (USER or ADMIN);
+-- user_roles grants additional roles such as a branch officer role. A user has every
+-- permission of every role they hold.
+CREATE TABLE IF NOT EXISTS permissions (
+    name        TEXT PRIMARY KEY,
+    description TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS roles (
+    name        TEXT PRIMARY KEY,
+    description TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+    role       TEXT NOT NULL REFERENCES roles (name) ON DELETE CASCADE,
+    permission TEXT NOT NULL REFERENCES permissions (name) ON DELETE CASCADE,
+    PRIMARY KEY (role, permission)
+);
+
+CREATE TABLE IF NOT EXISTS user_roles (
+    user_id    UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    role       TEXT NOT NULL REFERENCES roles (name) ON DELETE CASCADE,
+    granted_by UUID REFERENCES users (id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, role)
+);
+
+INSERT INTO permissions (name, description) VALUES
+    ('events:write', 'Create, edit and delete events and their organizers'),
+    ('comments:moderate', 'Edit and delete any comment'),
+    ('users:manage', 'Manage users, their roles and sessions'),
+    ('media:upload', 'Upload and remove images')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO roles (name, description) VALUES
+    ('ADMIN', 'Full access'),
+    ('USER', 'Regular member'),
+    ('PROJECTS_OFFICER', 'Officer of the Projects branch'),
+    ('INTERVIEW_OFFICER', 'Officer of the Interview branch'),
+    ('MARKETING_OFFICER', 'Officer of the Marketing branch')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('ADMIN', 'events:write'),
+    ('ADMIN', 'comments:moderate'),
+    ('ADMIN', 'users:manage'),
+    ('ADMIN', 'media:upload'),
+    ('PROJECTS_OFFICER', 'events:write'),
+    ('INTERVIEW_OFFICER', 'events:write'),
+    ('MARKETING_OFFICER', 'media:upload'),
+    ('MARKETING_OFFICER', 'comments:moderate')
+ON CONFLICT (role, permission) DO NOTHING;
@@ -0,0 +1,16 @@
+-- Long-lived tokens users create for scripts and bots. Only a SHA-256 hash of
+-- the token is stored; token_prefix is kept so users can tell tokens apart.
+CREATE TABLE IF NOT EXISTS personal_access_tokens (
+    id           UUID PRIMARY KEY,
+    user_id      UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    name         TEXT NOT NULL,
+    token_hash   TEXT NOT NULL UNIQUE,
+    token_prefix TEXT NOT NULL,
+    scopes       TEXT[] NOT NULL DEFAULT '{}',
+    mfa_verified BOOLEAN NOT NULL DEFAULT FALSE,
+    expires_at   TIMESTAMPTZ NOT NULL,
+    last_used_at TIMESTAMPTZ,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_personal_access_tokens_user_id ON personal_access_tokens (user_id);
@@ -0,0 +1,12 @@
+-- Failed login attempts per account (scope 'email') and per client IP (scope 'ip').
+-- Rows are reset by a successful login or once the last failure is old enough.
+CREATE TABLE IF NOT EXISTS login_throttles (
+    scope           TEXT NOT NULL,
+    key             TEXT NOT NULL,
+    failures        INTEGER NOT NULL DEFAULT 0,
+    last_failure_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    locked_until    TIMESTAMPTZ,
+    PRIMARY KEY (scope, key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_login_throttles_locked_until ON login_throttles (locked_until);
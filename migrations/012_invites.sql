@@ -0,0 +1,17 @@
+-- Invite codes for REGISTRATION_MODE=invite (or to let someone outside the allowed
+-- domains register). Only a SHA-256 hash of the code is stored.
+CREATE TABLE IF NOT EXISTS invites (
+    id         UUID PRIMARY KEY,
+    code_hash  TEXT NOT NULL UNIQUE,
+    email      TEXT,             -- Only this address can use the invite, if set
+    position   INTEGER,          -- GDSCPosition given to users who register with the invite
+    branch     INTEGER,          -- GDSCBranch given to users who register with the invite
+    max_uses   INTEGER NOT NULL DEFAULT 1,
+    uses       INTEGER NOT NULL DEFAULT 0,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_by UUID REFERENCES users (id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Invite code carried through an OAuth sign up
+ALTER TABLE oauth_states ADD COLUMN IF NOT EXISTS invite_code TEXT NOT NULL DEFAULT '';
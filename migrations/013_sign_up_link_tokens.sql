@@ -0,0 +1,3 @@
+-- Sign-up links are issued before the account exists, so there's no user to record
+-- when one is used. Those rows keep a NULL user_id.
+ALTER TABLE used_tokens ALTER COLUMN user_id DROP NOT NULL;
@@ -0,0 +1,7 @@
+-- The email change link a user was sent most recently. Asking for another change
+-- replaces the row, so only the newest link can be used.
+CREATE TABLE IF NOT EXISTS pending_email_changes (
+    user_id    UUID PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+    jti        UUID NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL
+);
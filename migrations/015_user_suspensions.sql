@@ -0,0 +1,6 @@
+-- Admins can suspend an account until a given time or ban it outright. Either way the
+-- user can't sign in, refresh a session or use an access token they already hold.
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS suspended_until TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS banned_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS suspension_reason TEXT;
@@ -0,0 +1,11 @@
+-- Users can ask to delete their account. The account is purged once the grace period
+-- ends, unless the user cancels before then.
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS deletion_scheduled_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_users_deletion_scheduled_at ON users (deletion_scheduled_at)
+    WHERE deletion_scheduled_at IS NOT NULL;
+
+-- Comments of purged users are kept without an author instead of being deleted
+ALTER TABLE comments
+    ALTER COLUMN user_id DROP NOT NULL;
@@ -4,10 +4,13 @@ import (
 	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_handlers"
 	"github.com/csusmGDSC/csusmgdsc-api/internal/auth/auth_middleware"
 	"github.com/csusmGDSC/csusmgdsc-api/internal/handlers"
+	"github.com/csusmGDSC/csusmgdsc-api/internal/models"
 	"github.com/labstack/echo/v4"
 )
 
 func InitRoutes(e *echo.Echo, h *handlers.Handler) {
+	authMiddleware := auth_middleware.AuthMiddleware(h.DB)
+
 	e.GET("/users", h.GetUsersHandler) // supports pagination ?page=x&limit=y
 	e.GET("/users/:id", h.GetUserByIDHandler)
 
@@ -16,35 +19,90 @@ func InitRoutes(e *echo.Echo, h *handlers.Handler) {
 	e.GET("/events/:id/organizers", h.GetEventOrganizers)
 	e.GET("/users/:id/events", h.GetUserAssignedEvents)
 
-	e.POST("/comments", h.InsertCommentHandler, auth_middleware.AuthMiddleware)
+	e.POST("/comments", h.InsertCommentHandler, authMiddleware)
 	e.GET("/comments", h.GetCommentsHandler) // supports optional params ?event_id=x&user_id=y
 	e.GET("/comments/:id/replies", h.GetCommentRepliesHandler)
 	e.GET("/comments/:id", h.GetCommentByIdHandler)
-	e.PUT("/comments/:id", h.UpdateCommentHandler, auth_middleware.AuthMiddleware)
-	e.DELETE("/comments/:id", h.DeleteCommentHandler, auth_middleware.AuthMiddleware)
+	e.PUT("/comments/:id", h.UpdateCommentHandler, authMiddleware)
+	e.DELETE("/comments/:id", h.DeleteCommentHandler, authMiddleware)
+
+	eventsWrite := auth_middleware.RequirePermission(h.DB, models.EventsWrite)
+	mediaUpload := auth_middleware.RequirePermission(h.DB, models.MediaUpload)
 
 	adminGroup := e.Group("/admin")
-	adminGroup.Use(auth_middleware.AuthMiddleware)
-	adminGroup.POST("/events", h.InsertEventHandler)
-	adminGroup.PUT("/events/:id", h.UpdateEventByID)
-	adminGroup.DELETE("/events/:id", h.DeleteEventByID)
-	adminGroup.POST("/events/:id/organizers/:userId", h.AddEventOrganizer)
-	adminGroup.DELETE("/events/:id/organizers/:userId", h.DeleteOrganizerFromEvent)
-	adminGroup.POST("/utils/image", h.UploadImage)
-	adminGroup.DELETE("/utils/image", h.RemoveImage)
+	adminGroup.Use(authMiddleware, auth_middleware.RequireAdminMFA(h.DB))
+	adminGroup.POST("/events", h.InsertEventHandler, eventsWrite)
+	adminGroup.PUT("/events/:id", h.UpdateEventByID, eventsWrite)
+	adminGroup.DELETE("/events/:id", h.DeleteEventByID, eventsWrite)
+	adminGroup.POST("/events/:id/organizers/:userId", h.AddEventOrganizer, eventsWrite)
+	adminGroup.DELETE("/events/:id/organizers/:userId", h.DeleteOrganizerFromEvent, eventsWrite)
+	adminGroup.POST("/utils/image", h.UploadImage, mediaUpload)
+	adminGroup.DELETE("/utils/image", h.RemoveImage, mediaUpload)
 }
 
 func InitOAuthRoutes(e *echo.Echo, h *auth_handlers.OAuthHandler) {
+	authMiddleware := auth_middleware.AuthMiddleware(h.DB)
+
+	e.GET("/.well-known/jwks.json", h.GetJWKS)
+
 	authGroup := e.Group("/auth")
 	authGroup.POST("/register", h.RegisterUser)
+	authGroup.GET("/registration", h.GetRegistrationPolicy)
 	authGroup.POST("/login", h.LoginUser)
 	authGroup.POST("/verify", h.VerifyUser)
-	authGroup.PATCH("/refresh", h.RefreshUser)
-	authGroup.POST("/logout", h.LogoutUser)
-	authGroup.POST("/logoutAll", h.LogoutAll, auth_middleware.AuthMiddleware)
+	authGroup.GET("/password/policy", h.GetPasswordPolicy)
+	authGroup.POST("/password/forgot", h.ForgotPassword)
+	authGroup.POST("/password/reset", h.ResetPassword)
+	authGroup.POST("/password/change", h.ChangePassword, authMiddleware, auth_middleware.RequireSession)
+	authGroup.POST("/magic-link", h.RequestMagicLink)
+	authGroup.POST("/magic-link/consume", h.ConsumeMagicLink)
+	authGroup.PATCH("/refresh", h.RefreshUser, auth_middleware.RequireCSRFToken)
+	authGroup.POST("/logout", h.LogoutUser, auth_middleware.RequireCSRFToken)
+	authGroup.POST("/logoutAll", h.LogoutAll, authMiddleware, auth_middleware.RequireSession)
 	authGroup.GET("/:provider/login", h.OAuthLogin)
 	authGroup.GET("/:provider/callback", h.OAuthCallback)
-	authGroup.PUT("/update/:id", h.UpdateUser, auth_middleware.AuthMiddleware)
-	authGroup.DELETE("/delete/:id", h.DeleteUser, auth_middleware.AuthMiddleware)
-	authGroup.GET("/me", h.GetUserByIDHandler, auth_middleware.AuthMiddleware)
+	authGroup.PUT("/update/:id", h.UpdateUser, authMiddleware, auth_middleware.RequireSession)
+	authGroup.DELETE("/delete/:id", h.DeleteUser, authMiddleware, auth_middleware.RequireSession)
+	authGroup.POST("/email/change", h.RequestEmailChange, authMiddleware, auth_middleware.RequireSession)
+	authGroup.POST("/email/confirm", h.ConfirmEmailChange)
+	authGroup.GET("/me", h.GetUserByIDHandler, authMiddleware)
+	authGroup.GET("/me/permissions", h.GetMyPermissions, authMiddleware)
+	authGroup.GET("/me/export", h.ExportAccount, authMiddleware, auth_middleware.RequireSession)
+	authGroup.POST("/me/deletion/cancel", h.CancelAccountDeletion, authMiddleware, auth_middleware.RequireSession)
+	authGroup.GET("/sessions", h.ListSessions, authMiddleware, auth_middleware.RequireSession)
+	authGroup.DELETE("/sessions/:id", h.RevokeSession, authMiddleware, auth_middleware.RequireSession)
+	authGroup.GET("/identities", h.ListIdentities, authMiddleware, auth_middleware.RequireSession)
+	authGroup.POST("/identities/:provider/link", h.LinkIdentity, authMiddleware, auth_middleware.RequireSession)
+	authGroup.DELETE("/identities/:id", h.UnlinkIdentity, authMiddleware, auth_middleware.RequireSession)
+	authGroup.POST("/mfa/verify", h.VerifyMFA)
+	authGroup.POST("/mfa/totp/enroll", h.EnrollTOTP, authMiddleware, auth_middleware.RequireSession)
+	authGroup.POST("/mfa/totp/confirm", h.ConfirmTOTP, authMiddleware, auth_middleware.RequireSession)
+	authGroup.POST("/mfa/totp/disable", h.DisableTOTP, authMiddleware, auth_middleware.RequireSession)
+	authGroup.POST("/mfa/recovery-codes", h.RegenerateRecoveryCodes, authMiddleware, auth_middleware.RequireSession)
+	authGroup.POST("/tokens", h.CreatePersonalAccessToken, authMiddleware, auth_middleware.RequireSession)
+	authGroup.GET("/tokens", h.ListPersonalAccessTokens, authMiddleware, auth_middleware.RequireSession)
+	authGroup.DELETE("/tokens/:id", h.RevokePersonalAccessToken, authMiddleware, auth_middleware.RequireSession)
+
+	usersManage := auth_middleware.RequirePermission(h.DB, models.UsersManage)
+
+	adminGroup := e.Group("/admin")
+	adminGroup.Use(authMiddleware, auth_middleware.RequireAdminMFA(h.DB))
+	adminGroup.GET("/users/:id/sessions", h.ListUserSessions, usersManage)
+	adminGroup.DELETE("/users/:id/sessions/:sessionId", h.RevokeUserSession, usersManage)
+	adminGroup.GET("/roles", h.ListRoles, usersManage)
+	adminGroup.POST("/users/:id/roles", h.GrantUserRole, usersManage)
+	adminGroup.DELETE("/users/:id/roles/:role", h.RevokeUserRole, usersManage)
+	adminGroup.PATCH("/users/:id", h.AdminUpdateUser, usersManage)
+	adminGroup.DELETE("/users/:id", h.AdminDeleteUser, usersManage)
+	adminGroup.POST("/users/:id/impersonate", h.ImpersonateUser, usersManage)
+	adminGroup.POST("/users/:id/suspend", h.SuspendUser, usersManage)
+	adminGroup.POST("/users/:id/ban", h.BanUser, usersManage)
+	adminGroup.POST("/users/:id/reinstate", h.ReinstateUser, usersManage)
+	adminGroup.POST("/users/:id/logout", h.ForceLogoutUser, usersManage)
+	adminGroup.GET("/audit-logs", h.ListAuditLogs, usersManage)
+	adminGroup.GET("/login-lockouts", h.ListLoginLockouts, usersManage)
+	adminGroup.DELETE("/login-lockouts", h.ClearLoginLockout, usersManage)
+	adminGroup.GET("/invites", h.ListInvites, usersManage)
+	adminGroup.POST("/invites", h.CreateInvite, usersManage)
+	adminGroup.DELETE("/invites/:id", h.RevokeInvite, usersManage)
 }